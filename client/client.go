@@ -14,10 +14,12 @@
 package pd
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
-	"crypto/x509"
+	"encoding/json"
 	"io/ioutil"
+	"net/http"
 	"net/url"
 	"strings"
 	"sync"
@@ -27,6 +29,7 @@ import (
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	log "github.com/pingcap/log"
+	"github.com/pingcap/pd/pkg/tlsutil"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
@@ -64,6 +67,15 @@ type Client interface {
 	// If the given safePoint is less than the current one, it will not be updated.
 	// Returns the new safePoint after updating.
 	UpdateGCSafePoint(ctx context.Context, safePoint uint64) (uint64, error)
+	// UpdateServiceGCSafePoint updates the safe point of a service, GC can not
+	// go beyond it until it expires after ttl seconds. The service is removed
+	// if ttl is not positive. The safe point is not updated if it is less than
+	// the minimal safe point of all services.
+	// Returns the minimal safe point of all services after updating.
+	UpdateServiceGCSafePoint(ctx context.Context, serviceID string, ttl int64, safePoint uint64) (uint64, error)
+	// WatchStores watches the store changes, such as state and label changes.
+	// The returned channel is closed when ctx is done or the client is closed.
+	WatchStores(ctx context.Context) (<-chan *StoreEvent, error)
 	// Close closes the client.
 	Close()
 }
@@ -90,6 +102,8 @@ type tsoRequest struct {
 }
 
 const (
+	serviceGCSafePointPath = "/pd/api/v1/gc/safepoint/service"
+
 	pdTimeout             = 3 * time.Second
 	updateLeaderTimeout   = time.Second // Use a shorter timeout to recover faster from network isolation.
 	maxMergeTSORequests   = 10000
@@ -124,6 +138,8 @@ type client struct {
 	cancel context.CancelFunc
 
 	security SecurityOption
+	// httpClient is used for the APIs which are only served by HTTP.
+	httpClient *http.Client
 }
 
 // SecurityOption records options about tls
@@ -133,6 +149,30 @@ type SecurityOption struct {
 	KeyPath  string
 }
 
+// toTLSConfig generates tls config. It returns nil if TLS is not enabled.
+func (s SecurityOption) toTLSConfig() (*tls.Config, error) {
+	if len(s.CAPath) == 0 {
+		return nil, nil
+	}
+
+	// The CA certificates are reloaded when the file is modified.
+	caReloader, err := tlsutil.GetCAReloader(s.CAPath)
+	if err != nil {
+		return nil, errors.Errorf("could not load ca certificate: %s", err)
+	}
+	cfg := &tls.Config{}
+	caReloader.Apply(cfg)
+	if len(s.CertPath) != 0 && len(s.KeyPath) != 0 {
+		// The client certificates are reloaded when the files are modified.
+		reloader, err := tlsutil.GetCertReloader(s.CertPath, s.KeyPath)
+		if err != nil {
+			return nil, errors.Errorf("could not load client key pair: %s", err)
+		}
+		reloader.Apply(cfg)
+	}
+	return cfg, nil
+}
+
 // NewClient creates a PD client.
 func NewClient(pdAddrs []string, security SecurityOption) (Client, error) {
 	log.Info("[pd] create pd client with endpoints", zap.Strings("pd-address", pdAddrs))
@@ -148,6 +188,12 @@ func NewClient(pdAddrs []string, security SecurityOption) (Client, error) {
 	}
 	c.connMu.clientConns = make(map[string]*grpc.ClientConn)
 
+	tlsCfg, err := security.toTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	c.httpClient = &http.Client{Transport: &http.Transport{TLSClientConfig: tlsCfg}}
+
 	if err := c.initClusterID(); err != nil {
 		return nil, err
 	}
@@ -257,36 +303,12 @@ func (c *client) getOrCreateGRPCConn(addr string) (*grpc.ClientConn, error) {
 	}
 
 	opt := grpc.WithInsecure()
-	if len(c.security.CAPath) != 0 {
-
-		certificates := []tls.Certificate{}
-		if len(c.security.CertPath) != 0 && len(c.security.KeyPath) != 0 {
-			// Load the client certificates from disk
-			certificate, err := tls.LoadX509KeyPair(c.security.CertPath, c.security.KeyPath)
-			if err != nil {
-				return nil, errors.Errorf("could not load client key pair: %s", err)
-			}
-			certificates = append(certificates, certificate)
-		}
-
-		// Create a certificate pool from the certificate authority
-		certPool := x509.NewCertPool()
-		ca, err := ioutil.ReadFile(c.security.CAPath)
-		if err != nil {
-			return nil, errors.Errorf("could not read ca certificate: %s", err)
-		}
-
-		// Append the certificates from the CA
-		if !certPool.AppendCertsFromPEM(ca) {
-			return nil, errors.New("failed to append ca certs")
-		}
-
-		creds := credentials.NewTLS(&tls.Config{
-			Certificates: certificates,
-			RootCAs:      certPool,
-		})
-
-		opt = grpc.WithTransportCredentials(creds)
+	tlsCfg, err := c.security.toTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	if tlsCfg != nil {
+		opt = grpc.WithTransportCredentials(credentials.NewTLS(tlsCfg))
 	}
 	u, err := url.Parse(addr)
 	if err != nil {
@@ -752,6 +774,72 @@ func (c *client) UpdateGCSafePoint(ctx context.Context, safePoint uint64) (uint6
 	return resp.GetNewSafePoint(), nil
 }
 
+// UpdateServiceGCSafePoint is sent by the HTTP API of the leader, since the
+// PD service of the kvproto in use does not have such an RPC. It should be
+// moved to gRPC once kvproto is upgraded.
+func (c *client) UpdateServiceGCSafePoint(ctx context.Context, serviceID string, ttl int64, safePoint uint64) (uint64, error) {
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		span = opentracing.StartSpan("pdclient.UpdateServiceGCSafePoint", opentracing.ChildOf(span.Context()))
+		defer span.Finish()
+	}
+	start := time.Now()
+	defer func() {
+		cmdDuration.WithLabelValues("update_service_gc_safe_point").Observe(time.Since(start).Seconds())
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, pdTimeout)
+	minSafePoint, err := c.updateServiceGCSafePoint(ctx, serviceID, ttl, safePoint)
+	cancel()
+
+	if err != nil {
+		cmdFailedDuration.WithLabelValues("update_service_gc_safe_point").Observe(time.Since(start).Seconds())
+		c.ScheduleCheckLeader()
+		return 0, err
+	}
+	return minSafePoint, nil
+}
+
+// updateServiceGCSafePoint calls the HTTP API of the leader, since there is no
+// such gRPC method.
+func (c *client) updateServiceGCSafePoint(ctx context.Context, serviceID string, ttl int64, safePoint uint64) (uint64, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"service_id": serviceID,
+		"ttl":        ttl,
+		"safe_point": safePoint,
+	})
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	req, err := http.NewRequest("POST", c.GetLeaderAddr()+serviceGCSafePointPath, bytes.NewReader(body))
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, errors.Errorf("[pd] update service gc safe point returns status %d: %s", resp.StatusCode, data)
+	}
+	var min *struct {
+		SafePoint uint64 `json:"safe_point"`
+	}
+	if err := json.Unmarshal(data, &min); err != nil {
+		return 0, errors.WithStack(err)
+	}
+	if min == nil {
+		// No service holds back GC.
+		return 0, nil
+	}
+	return min.SafePoint, nil
+}
+
 func (c *client) requestHeader() *pdpb.RequestHeader {
 	return &pdpb.RequestHeader{
 		ClusterId: c.clusterID,
@@ -42,10 +42,19 @@ var (
 			Help:      "Bucketed histogram of processing time (s) of handled requests.",
 			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 13),
 		}, []string{"type"})
+
+	regionCacheCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pd_client",
+			Subsystem: "region_cache",
+			Name:      "operations_total",
+			Help:      "Counter of region cache hits, misses and invalidations.",
+		}, []string{"name", "type"})
 )
 
 func init() {
 	prometheus.MustRegister(cmdDuration)
 	prometheus.MustRegister(cmdFailedDuration)
 	prometheus.MustRegister(requestDuration)
+	prometheus.MustRegister(regionCacheCounter)
 }
@@ -0,0 +1,425 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pd
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/btree"
+	"github.com/pingcap/kvproto/pkg/errorpb"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	log "github.com/pingcap/log"
+	"github.com/pingcap/pd/server/cache"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultRegionCacheName       = "default"
+	defaultRegionCacheTTL        = 10 * time.Minute
+	defaultRegionCacheGCInterval = time.Minute
+	defaultRegionCacheScanBatch  = 1
+	regionCacheBTreeDegree       = 64
+	// regionCachePrefetchQueue is the number of missed keys waiting for the
+	// prefetch, the later misses are not prefetched if it is full.
+	regionCachePrefetchQueue   = 16
+	regionCachePrefetchTimeout = 10 * time.Second
+)
+
+// RegionCacheOp represents available options when creating a RegionCache.
+type RegionCacheOp struct {
+	name      string
+	ttl       time.Duration
+	scanBatch int
+}
+
+// RegionCacheOption configures RegionCacheOp.
+type RegionCacheOption func(*RegionCacheOp)
+
+// WithRegionCacheName sets the name used to label the metrics of the cache.
+func WithRegionCacheName(name string) RegionCacheOption {
+	return func(op *RegionCacheOp) { op.name = name }
+}
+
+// WithRegionCacheTTL sets how long a cached region is trusted before it is
+// loaded from PD again.
+func WithRegionCacheTTL(ttl time.Duration) RegionCacheOption {
+	return func(op *RegionCacheOp) { op.ttl = ttl }
+}
+
+// WithRegionCacheScanBatch sets how many regions are loaded from PD when a key
+// misses the cache. Only the region of the key is loaded in the lookup, the
+// regions after it are prefetched in the background.
+func WithRegionCacheScanBatch(batch int) RegionCacheOption {
+	return func(op *RegionCacheOp) { op.scanBatch = batch }
+}
+
+// regionCacheItem is never changed after it is inserted, so that it can be
+// read without the lock. A new item is swapped in to update it.
+type regionCacheItem struct {
+	region *metapb.Region
+	leader *metapb.Peer
+}
+
+// Less returns true if the region start key is less than the other.
+func (r *regionCacheItem) Less(other btree.Item) bool {
+	left := r.region.GetStartKey()
+	right := other.(*regionCacheItem).region.GetStartKey()
+	return bytes.Compare(left, right) < 0
+}
+
+func (r *regionCacheItem) contains(key []byte) bool {
+	start, end := r.region.GetStartKey(), r.region.GetEndKey()
+	return bytes.Compare(key, start) >= 0 && (len(end) == 0 || bytes.Compare(key, end) < 0)
+}
+
+// RegionCache is an optional routing cache on top of a Client. Regions are
+// kept in a btree ordered by start key. An entry is dropped when a caller
+// reports a stale epoch or a NotLeader error for it, or when its TTL expires.
+// The expired entries are dropped when they are looked up, and by a periodic
+// sweep.
+type RegionCache struct {
+	client    Client
+	name      string
+	scanBatch int
+
+	prefetchCh chan []byte
+	quit       chan struct{}
+	closeOnce  sync.Once
+	wg         sync.WaitGroup
+
+	mu struct {
+		sync.RWMutex
+		tree    *btree.BTree
+		regions map[uint64]*regionCacheItem
+	}
+	// fresh records the regions which are still within their TTL.
+	fresh *cache.TTLUint64
+}
+
+// NewRegionCache creates a RegionCache which loads regions through client.
+func NewRegionCache(client Client, opts ...RegionCacheOption) *RegionCache {
+	options := &RegionCacheOp{
+		name:      defaultRegionCacheName,
+		ttl:       defaultRegionCacheTTL,
+		scanBatch: defaultRegionCacheScanBatch,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.scanBatch < 1 {
+		options.scanBatch = 1
+	}
+
+	c := &RegionCache{
+		client:    client,
+		name:      options.name,
+		scanBatch: options.scanBatch,
+		fresh:     cache.NewIDTTL(defaultRegionCacheGCInterval, options.ttl),
+		quit:      make(chan struct{}),
+	}
+	if c.scanBatch > 1 {
+		c.prefetchCh = make(chan []byte, regionCachePrefetchQueue)
+	}
+	c.mu.tree = btree.New(regionCacheBTreeDegree)
+	c.mu.regions = make(map[uint64]*regionCacheItem)
+	c.wg.Add(1)
+	go c.backgroundLoop()
+	return c
+}
+
+// LocateKey returns the region and its leader Peer which contain the key.
+// Both are loaded from PD when the key is not cached.
+func (c *RegionCache) LocateKey(ctx context.Context, key []byte) (*metapb.Region, *metapb.Peer, error) {
+	if item := c.searchKey(key); item != nil {
+		regionCacheCounter.WithLabelValues(c.name, "hit").Inc()
+		return item.region, item.leader, nil
+	}
+	regionCacheCounter.WithLabelValues(c.name, "miss").Inc()
+
+	region, leader, err := c.client.GetRegion(ctx, key)
+	if err != nil || region == nil {
+		return nil, nil, err
+	}
+	c.insert(region, leader)
+	c.prefetch(region.GetEndKey())
+	return region, leader, nil
+}
+
+// LocateRegionByID returns the region and its leader Peer by region id.
+// Both are loaded from PD when the region is not cached.
+func (c *RegionCache) LocateRegionByID(ctx context.Context, regionID uint64) (*metapb.Region, *metapb.Peer, error) {
+	if item := c.getRegion(regionID); item != nil {
+		regionCacheCounter.WithLabelValues(c.name, "hit").Inc()
+		return item.region, item.leader, nil
+	}
+	regionCacheCounter.WithLabelValues(c.name, "miss").Inc()
+
+	region, leader, err := c.client.GetRegionByID(ctx, regionID)
+	if err != nil || region == nil {
+		return nil, nil, err
+	}
+	c.insert(region, leader)
+	return region, leader, nil
+}
+
+// InvalidateRegion drops the region from the cache, so that the next lookup
+// loads it from PD.
+func (c *RegionCache) InvalidateRegion(regionID uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removeLocked(regionID)
+}
+
+// OnNotLeader updates the cached leader of the region. The region is dropped
+// from the cache if the new leader is unknown or is not a peer of the region.
+func (c *RegionCache) OnNotLeader(regionID uint64, leader *metapb.Peer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, ok := c.mu.regions[regionID]
+	if !ok {
+		return
+	}
+	if leader != nil {
+		for _, p := range item.region.GetPeers() {
+			if p.GetId() == leader.GetId() {
+				item = &regionCacheItem{region: item.region, leader: p}
+				c.mu.tree.ReplaceOrInsert(item)
+				c.mu.regions[regionID] = item
+				regionCacheCounter.WithLabelValues(c.name, "update_leader").Inc()
+				return
+			}
+		}
+	}
+	c.removeLocked(regionID)
+}
+
+// OnEpochNotMatch drops the stale region from the cache and inserts the
+// current regions reported by TiKV, if any.
+func (c *RegionCache) OnEpochNotMatch(regionID uint64, currentRegions []*metapb.Region) {
+	c.InvalidateRegion(regionID)
+	for _, region := range currentRegions {
+		c.insert(region, nil)
+	}
+}
+
+// OnRegionError updates the cache according to a region error returned by
+// TiKV. Errors that do not affect routing are ignored.
+func (c *RegionCache) OnRegionError(regionID uint64, err *errorpb.Error) {
+	switch {
+	case err.GetNotLeader() != nil:
+		c.OnNotLeader(regionID, err.GetNotLeader().GetLeader())
+	case err.GetEpochNotMatch() != nil:
+		c.OnEpochNotMatch(regionID, err.GetEpochNotMatch().GetCurrentRegions())
+	case err.GetRegionNotFound() != nil, err.GetKeyNotInRegion() != nil, err.GetStoreNotMatch() != nil:
+		c.InvalidateRegion(regionID)
+	}
+}
+
+// Len returns the number of cached regions.
+func (c *RegionCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.mu.regions)
+}
+
+// Clear drops all cached regions.
+func (c *RegionCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mu.tree = btree.New(regionCacheBTreeDegree)
+	c.mu.regions = make(map[uint64]*regionCacheItem)
+	c.fresh.Clear()
+}
+
+// Close stops the background prefetch and sweep of the cache.
+func (c *RegionCache) Close() {
+	c.closeOnce.Do(func() {
+		close(c.quit)
+		c.wg.Wait()
+		c.fresh.Close()
+	})
+}
+
+// prefetch queues the key to load the regions from it in the background. The
+// key is dropped if the queue is full, as prefetching is best effort.
+func (c *RegionCache) prefetch(key []byte) {
+	if c.prefetchCh == nil || len(key) == 0 {
+		return
+	}
+	select {
+	case c.prefetchCh <- key:
+	default:
+	}
+}
+
+func (c *RegionCache) backgroundLoop() {
+	defer c.wg.Done()
+	ticker := time.NewTicker(defaultRegionCacheGCInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.quit:
+			return
+		case key := <-c.prefetchCh:
+			c.loadRegionsFrom(key)
+		case <-ticker.C:
+			c.sweep()
+		}
+	}
+}
+
+// loadRegionsFrom loads up to scanBatch-1 regions from the key. PD does not
+// provide a scan RPC, so the batch is built by walking GetRegion over region
+// boundaries.
+func (c *RegionCache) loadRegionsFrom(key []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), regionCachePrefetchTimeout)
+	defer cancel()
+	for i := 1; i < c.scanBatch && len(key) > 0; i++ {
+		if c.searchKey(key) != nil {
+			return
+		}
+		region, leader, err := c.client.GetRegion(ctx, key)
+		if err != nil || region == nil {
+			log.Debug("[pd] failed to prefetch region", zap.String("cache", c.name), zap.Error(err))
+			return
+		}
+		c.insert(region, leader)
+		key = region.GetEndKey()
+	}
+}
+
+// sweep drops the expired regions.
+func (c *RegionCache) sweep() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, item := range c.mu.regions {
+		if !c.fresh.Exists(id) {
+			c.expireLocked(item)
+		}
+	}
+}
+
+func (c *RegionCache) searchKey(key []byte) *regionCacheItem {
+	c.mu.RLock()
+	var result *regionCacheItem
+	c.mu.tree.DescendLessOrEqual(&regionCacheItem{region: &metapb.Region{StartKey: key}}, func(i btree.Item) bool {
+		result = i.(*regionCacheItem)
+		return false
+	})
+	c.mu.RUnlock()
+	if result == nil || !result.contains(key) {
+		return nil
+	}
+	if !c.fresh.Exists(result.region.GetId()) {
+		c.expire(result)
+		return nil
+	}
+	return result
+}
+
+func (c *RegionCache) getRegion(regionID uint64) *regionCacheItem {
+	c.mu.RLock()
+	item, ok := c.mu.regions[regionID]
+	c.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	if !c.fresh.Exists(regionID) {
+		c.expire(item)
+		return nil
+	}
+	return item
+}
+
+// expire drops the expired item, unless it has been replaced.
+func (c *RegionCache) expire(item *regionCacheItem) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.mu.regions[item.region.GetId()] == item && !c.fresh.Exists(item.region.GetId()) {
+		c.expireLocked(item)
+	}
+}
+
+func (c *RegionCache) expireLocked(item *regionCacheItem) {
+	c.deleteLocked(item.region.GetId())
+	regionCacheCounter.WithLabelValues(c.name, "expire").Inc()
+}
+
+// insert puts the region into the cache and drops every cached region it
+// overlaps with. It does nothing if the cached copy of the same region has a
+// newer epoch.
+func (c *RegionCache) insert(region *metapb.Region, leader *metapb.Peer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if old, ok := c.mu.regions[region.GetId()]; ok && isEpochStale(region.GetRegionEpoch(), old.region.GetRegionEpoch()) {
+		return
+	}
+
+	item := &regionCacheItem{region: region, leader: leader}
+	for _, overlap := range c.getOverlapsLocked(region) {
+		c.removeLocked(overlap.region.GetId())
+	}
+	c.mu.tree.ReplaceOrInsert(item)
+	c.mu.regions[region.GetId()] = item
+	c.fresh.Put(region.GetId())
+}
+
+func (c *RegionCache) getOverlapsLocked(region *metapb.Region) []*regionCacheItem {
+	var overlaps []*regionCacheItem
+	start := &regionCacheItem{region: &metapb.Region{StartKey: region.GetStartKey()}}
+	c.mu.tree.DescendLessOrEqual(start, func(i btree.Item) bool {
+		if item := i.(*regionCacheItem); item.contains(region.GetStartKey()) {
+			overlaps = append(overlaps, item)
+		}
+		return false
+	})
+	c.mu.tree.AscendGreaterOrEqual(start, func(i btree.Item) bool {
+		item := i.(*regionCacheItem)
+		if len(region.GetEndKey()) > 0 && bytes.Compare(region.GetEndKey(), item.region.GetStartKey()) <= 0 {
+			return false
+		}
+		if len(overlaps) == 0 || overlaps[len(overlaps)-1] != item {
+			overlaps = append(overlaps, item)
+		}
+		return true
+	})
+	return overlaps
+}
+
+func (c *RegionCache) removeLocked(regionID uint64) {
+	if c.deleteLocked(regionID) {
+		regionCacheCounter.WithLabelValues(c.name, "invalidate").Inc()
+	}
+}
+
+func (c *RegionCache) deleteLocked(regionID uint64) bool {
+	item, ok := c.mu.regions[regionID]
+	if !ok {
+		return false
+	}
+	c.mu.tree.Delete(item)
+	delete(c.mu.regions, regionID)
+	c.fresh.Remove(regionID)
+	return true
+}
+
+// isEpochStale checks whether the epoch is older than the other one.
+func isEpochStale(epoch, other *metapb.RegionEpoch) bool {
+	return epoch.GetVersion() < other.GetVersion() || epoch.GetConfVer() < other.GetConfVer()
+}
@@ -0,0 +1,209 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pd
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/errorpb"
+	"github.com/pingcap/kvproto/pkg/metapb"
+)
+
+var _ = Suite(&testRegionCacheSuite{})
+
+type testRegionCacheSuite struct{}
+
+// mockRegionClient serves regions from a static list and counts the calls.
+type mockRegionClient struct {
+	Client
+	regions []*metapb.Region
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (m *mockRegionClient) callCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls
+}
+
+func (m *mockRegionClient) count() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+}
+
+func (m *mockRegionClient) GetRegion(ctx context.Context, key []byte) (*metapb.Region, *metapb.Peer, error) {
+	m.count()
+	for _, r := range m.regions {
+		if bytes.Compare(key, r.GetStartKey()) >= 0 && (len(r.GetEndKey()) == 0 || bytes.Compare(key, r.GetEndKey()) < 0) {
+			return r, r.GetPeers()[0], nil
+		}
+	}
+	return nil, nil, nil
+}
+
+func (m *mockRegionClient) GetRegionByID(ctx context.Context, regionID uint64) (*metapb.Region, *metapb.Peer, error) {
+	m.count()
+	for _, r := range m.regions {
+		if r.GetId() == regionID {
+			return r, r.GetPeers()[0], nil
+		}
+	}
+	return nil, nil, nil
+}
+
+func newCacheTestRegion(id uint64, start, end string, version uint64) *metapb.Region {
+	return &metapb.Region{
+		Id:          id,
+		StartKey:    []byte(start),
+		EndKey:      []byte(end),
+		RegionEpoch: &metapb.RegionEpoch{Version: version, ConfVer: 1},
+		Peers: []*metapb.Peer{
+			{Id: id*10 + 1, StoreId: 1},
+			{Id: id*10 + 2, StoreId: 2},
+		},
+	}
+}
+
+func (s *testRegionCacheSuite) TestLocateKey(c *C) {
+	mock := &mockRegionClient{regions: []*metapb.Region{
+		newCacheTestRegion(1, "", "b", 1),
+		newCacheTestRegion(2, "b", "d", 1),
+		newCacheTestRegion(3, "d", "", 1),
+	}}
+	rc := NewRegionCache(mock, WithRegionCacheScanBatch(2))
+	defer rc.Close()
+
+	region, leader, err := rc.LocateKey(context.Background(), []byte("a"))
+	c.Assert(err, IsNil)
+	c.Assert(region.GetId(), Equals, uint64(1))
+	c.Assert(leader.GetId(), Equals, uint64(11))
+	// The adjacent region is prefetched in the background.
+	for i := 0; i < 100 && rc.Len() < 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(mock.callCount(), Equals, 2)
+	c.Assert(rc.Len(), Equals, 2)
+
+	region, _, err = rc.LocateKey(context.Background(), []byte("c"))
+	c.Assert(err, IsNil)
+	c.Assert(region.GetId(), Equals, uint64(2))
+	c.Assert(mock.callCount(), Equals, 2)
+
+	region, _, err = rc.LocateRegionByID(context.Background(), 3)
+	c.Assert(err, IsNil)
+	c.Assert(region.GetId(), Equals, uint64(3))
+	c.Assert(mock.callCount(), Equals, 3)
+	c.Assert(rc.Len(), Equals, 3)
+}
+
+func (s *testRegionCacheSuite) TestInvalidate(c *C) {
+	mock := &mockRegionClient{regions: []*metapb.Region{
+		newCacheTestRegion(1, "", "", 1),
+	}}
+	rc := NewRegionCache(mock)
+	defer rc.Close()
+	_, _, err := rc.LocateKey(context.Background(), []byte("a"))
+	c.Assert(err, IsNil)
+
+	// NotLeader with a known peer only switches the leader.
+	rc.OnRegionError(1, &errorpb.Error{NotLeader: &errorpb.NotLeader{RegionId: 1, Leader: &metapb.Peer{Id: 12, StoreId: 2}}})
+	_, leader, err := rc.LocateKey(context.Background(), []byte("a"))
+	c.Assert(err, IsNil)
+	c.Assert(leader.GetId(), Equals, uint64(12))
+	c.Assert(mock.callCount(), Equals, 1)
+
+	// NotLeader without a leader drops the region.
+	rc.OnRegionError(1, &errorpb.Error{NotLeader: &errorpb.NotLeader{RegionId: 1}})
+	c.Assert(rc.Len(), Equals, 0)
+	_, _, err = rc.LocateKey(context.Background(), []byte("a"))
+	c.Assert(err, IsNil)
+	c.Assert(mock.callCount(), Equals, 2)
+
+	// EpochNotMatch replaces the region with the current ones.
+	rc.OnRegionError(1, &errorpb.Error{EpochNotMatch: &errorpb.EpochNotMatch{CurrentRegions: []*metapb.Region{
+		newCacheTestRegion(1, "", "m", 2),
+		newCacheTestRegion(4, "m", "", 2),
+	}}})
+	c.Assert(rc.Len(), Equals, 2)
+	region, _, err := rc.LocateKey(context.Background(), []byte("x"))
+	c.Assert(err, IsNil)
+	c.Assert(region.GetId(), Equals, uint64(4))
+	c.Assert(mock.callCount(), Equals, 2)
+
+	// A region with a stale epoch does not replace the cached one.
+	rc.OnEpochNotMatch(0, []*metapb.Region{newCacheTestRegion(4, "a", "", 1)})
+	region, _, err = rc.LocateKey(context.Background(), []byte("b"))
+	c.Assert(err, IsNil)
+	c.Assert(region.GetId(), Equals, uint64(1))
+}
+
+func (s *testRegionCacheSuite) TestExpire(c *C) {
+	mock := &mockRegionClient{regions: []*metapb.Region{
+		newCacheTestRegion(1, "", "", 1),
+	}}
+	rc := NewRegionCache(mock, WithRegionCacheTTL(100*time.Millisecond))
+	defer rc.Close()
+	_, _, err := rc.LocateKey(context.Background(), []byte("a"))
+	c.Assert(err, IsNil)
+	time.Sleep(200 * time.Millisecond)
+	_, _, err = rc.LocateKey(context.Background(), []byte("a"))
+	c.Assert(err, IsNil)
+	c.Assert(mock.callCount(), Equals, 2)
+
+	// The expired regions are dropped from the cache.
+	rc.OnEpochNotMatch(0, []*metapb.Region{newCacheTestRegion(2, "", "", 2)})
+	c.Assert(rc.Len(), Equals, 1)
+	time.Sleep(200 * time.Millisecond)
+	c.Assert(rc.getRegion(2), IsNil)
+	c.Assert(rc.Len(), Equals, 0)
+	rc.OnEpochNotMatch(0, []*metapb.Region{newCacheTestRegion(3, "", "", 3)})
+	time.Sleep(200 * time.Millisecond)
+	rc.sweep()
+	c.Assert(rc.Len(), Equals, 0)
+}
+
+func (s *testRegionCacheSuite) TestConcurrentNotLeader(c *C) {
+	mock := &mockRegionClient{regions: []*metapb.Region{
+		newCacheTestRegion(1, "", "", 1),
+	}}
+	rc := NewRegionCache(mock)
+	defer rc.Close()
+	_, leader, err := rc.LocateKey(context.Background(), []byte("a"))
+	c.Assert(err, IsNil)
+	c.Assert(leader.GetId(), Equals, uint64(11))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			rc.OnNotLeader(1, &metapb.Peer{Id: uint64(11 + i%2)})
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		_, l, err := rc.LocateRegionByID(context.Background(), 1)
+		c.Assert(err, IsNil)
+		c.Assert(l.GetId(), Not(Equals), uint64(0))
+	}
+	wg.Wait()
+	// The leader returned before is not changed by the later updates.
+	c.Assert(leader.GetId(), Equals, uint64(11))
+}
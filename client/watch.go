@@ -0,0 +1,181 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	log "github.com/pingcap/log"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	watchStoresPath     = "/pd/api/v1/watch/stores"
+	watchRetryInterval  = time.Second
+	watchEventChanSize  = 16
+	watchEventTypeStart = "start"
+	storeEventTypeReset = "reset"
+)
+
+// StoreEvent is a change of a store watched from PD.
+type StoreEvent struct {
+	// Revision is the revision of the change. It increases monotonically.
+	Revision uint64 `json:"revision"`
+	// Type is one of "store_put", "store_delete" and "reset". A reset event
+	// means some changes are lost, e.g. after the PD leader changed, the
+	// caller should reload all stores by GetAllStores.
+	Type  string        `json:"type"`
+	Store *metapb.Store `json:"store,omitempty"`
+}
+
+// IsReset checks whether the caller should reload all stores.
+func (e *StoreEvent) IsReset() bool {
+	return e.Type == storeEventTypeReset
+}
+
+// watchStream is an opened watch stream whose start event has been read.
+type watchStream struct {
+	body    io.ReadCloser
+	decoder *json.Decoder
+	// revision is the revision the stream starts after.
+	revision uint64
+}
+
+// WatchStores watches the store changes from now on. The watch is resumed
+// from the last received revision if the stream is broken. The returned
+// channel is closed when ctx is done or the client is closed.
+func (c *client) WatchStores(ctx context.Context) (<-chan *StoreEvent, error) {
+	stream, err := c.openWatchStream(ctx, watchStoresPath, 0)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan *StoreEvent, watchEventChanSize)
+	c.wg.Add(1)
+	go c.watchStoresLoop(ctx, stream, ch)
+	return ch, nil
+}
+
+func (c *client) watchStoresLoop(ctx context.Context, stream *watchStream, ch chan<- *StoreEvent) {
+	defer c.wg.Done()
+	defer close(ch)
+
+	revision := stream.revision
+	for {
+		if stream != nil {
+			revision = c.readStoreEvents(ctx, stream.decoder, revision, ch)
+			stream.body.Close()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.ctx.Done():
+			return
+		case <-time.After(watchRetryInterval):
+		}
+
+		var err error
+		stream, err = c.openWatchStream(ctx, watchStoresPath, revision)
+		if err != nil {
+			log.Error("[pd] failed to watch stores", zap.Error(err))
+			c.ScheduleCheckLeader()
+		}
+	}
+}
+
+// readStoreEvents sends the events in the stream to ch until the stream is
+// broken. It returns the revision of the last event.
+func (c *client) readStoreEvents(ctx context.Context, decoder *json.Decoder, revision uint64, ch chan<- *StoreEvent) uint64 {
+	for {
+		e := &StoreEvent{}
+		if err := decoder.Decode(e); err != nil {
+			log.Warn("[pd] watch stores stream is broken", zap.Error(err))
+			return revision
+		}
+		select {
+		case ch <- e:
+			revision = e.Revision
+		case <-ctx.Done():
+			return revision
+		case <-c.ctx.Done():
+			return revision
+		}
+	}
+}
+
+// openWatchStream opens a watch stream on the leader and reads its start
+// event. If revision is 0, the stream starts from the latest revision.
+func (c *client) openWatchStream(ctx context.Context, path string, revision uint64) (*watchStream, error) {
+	url := c.GetLeaderAddr() + path
+	if revision > 0 {
+		url = fmt.Sprintf("%s?revision=%d", url, revision)
+	}
+
+	// The stream should be closed when either ctx is done or the client is closed.
+	streamCtx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-c.ctx.Done():
+			cancel()
+		case <-streamCtx.Done():
+		}
+	}()
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		cancel()
+		return nil, errors.WithStack(err)
+	}
+	resp, err := c.httpClient.Do(req.WithContext(streamCtx))
+	if err != nil {
+		cancel()
+		return nil, errors.WithStack(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		cancel()
+		return nil, errors.Errorf("[pd] watch %s returns status %d", url, resp.StatusCode)
+	}
+	stream := &watchStream{
+		body:    &cancelReadCloser{ReadCloser: resp.Body, cancel: cancel},
+		decoder: json.NewDecoder(resp.Body),
+	}
+	var start StoreEvent
+	if err := stream.decoder.Decode(&start); err != nil {
+		stream.body.Close()
+		return nil, errors.WithStack(err)
+	}
+	if start.Type != watchEventTypeStart {
+		stream.body.Close()
+		return nil, errors.Errorf("[pd] watch %s returns %s event before the start event", url, start.Type)
+	}
+	stream.revision = start.Revision
+	return stream, nil
+}
+
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (r *cancelReadCloser) Close() error {
+	r.cancel()
+	return r.ReadCloser.Close()
+}
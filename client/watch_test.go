@@ -0,0 +1,76 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	. "github.com/pingcap/check"
+)
+
+var _ = Suite(&testWatchSuite{})
+
+type testWatchSuite struct{}
+
+func (s *testWatchSuite) TestResumeFromStart(c *C) {
+	var (
+		mu        sync.Mutex
+		revisions []string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		revisions = append(revisions, r.URL.Query().Get("revision"))
+		first := len(revisions) == 1
+		mu.Unlock()
+		if first {
+			// The stream is broken before any change arrives.
+			fmt.Fprintln(w, `{"revision":10,"type":"start"}`)
+			return
+		}
+		fmt.Fprintln(w, `{"revision":10,"type":"start"}`)
+		fmt.Fprintln(w, `{"revision":11,"type":"store_put","store":{"id":1}}`)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cli := &client{
+		checkLeaderCh: make(chan struct{}, 1),
+		ctx:           ctx,
+		cancel:        cancel,
+		httpClient:    &http.Client{},
+	}
+	cli.connMu.leader = ts.URL
+	defer cli.Close()
+
+	ch, err := cli.WatchStores(ctx)
+	c.Assert(err, IsNil)
+	select {
+	case e := <-ch:
+		c.Assert(e.Revision, Equals, uint64(11))
+		c.Assert(e.Store.GetId(), Equals, uint64(1))
+	case <-time.After(5 * time.Second):
+		c.Fatal("no store event")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	// The watch is resumed from the revision of the start event.
+	c.Assert(revisions, DeepEquals, []string{"", "10"})
+}
@@ -103,8 +103,14 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	var sig os.Signal
 	go func() {
-		sig = <-sc
-		cancel()
+		for sig = range sc {
+			if sig == syscall.SIGHUP && cfg.IsConfigFileSpecified() {
+				reloadConfig(svr)
+				continue
+			}
+			cancel()
+			return
+		}
 	}()
 
 	if err := svr.Run(ctx); err != nil {
@@ -123,6 +129,17 @@ func main() {
 	}
 }
 
+func reloadConfig(svr *server.Server) {
+	cfg, err := svr.ReloadConfigFile()
+	if err != nil {
+		log.Error("reload config file failed", zap.Error(err))
+		return
+	}
+	for _, msg := range cfg.WarningMsgs {
+		log.Warn(msg)
+	}
+}
+
 func exit(code int) {
 	log.Sync()
 	os.Exit(code)
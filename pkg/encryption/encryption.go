@@ -0,0 +1,224 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// keySize is the size of a master key, which selects AES-256.
+	keySize = 32
+	// version is the version of the format of encrypted data.
+	version byte = 1
+)
+
+// magic starts every encrypted value. A protobuf message or a JSON document
+// never starts with a zero byte, so plaintext values can be told apart and
+// are migrated by the re-encryption.
+var magic = []byte("\x00PDE")
+
+// headerSize is the size of magic, version and key ID before the nonce.
+var headerSize = len(magic) + 1 + 8
+
+// Config is the configuration of the master keys. A master key is 32 bytes
+// encoded in hex, it is read from a file or an environment variable.
+type Config struct {
+	// MasterKeyFile is the path of the file that contains the current master
+	// key.
+	MasterKeyFile string `toml:"master-key-file" json:"master-key-file"`
+	// MasterKeyEnv is the name of the environment variable that contains the
+	// current master key. It is used if MasterKeyFile is empty.
+	MasterKeyEnv string `toml:"master-key-env" json:"master-key-env"`
+	// PreviousMasterKeyFiles are the paths of the files that contain the
+	// retired master keys. Data encrypted by them can still be read, and is
+	// re-encrypted with the current master key in the background. If there is
+	// no current master key, data is decrypted to plaintext.
+	PreviousMasterKeyFiles []string `toml:"previous-master-key-files" json:"previous-master-key-files"`
+}
+
+// IsEnabled returns true if any master key is configured.
+func (c *Config) IsEnabled() bool {
+	return c.MasterKeyFile != "" || c.MasterKeyEnv != "" || len(c.PreviousMasterKeyFiles) > 0
+}
+
+type masterKey struct {
+	id   uint64
+	aead cipher.AEAD
+}
+
+func newMasterKey(key []byte) (*masterKey, error) {
+	if len(key) != keySize {
+		return nil, errors.Errorf("invalid master key size %d, expect %d", len(key), keySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	sum := sha256.Sum256(key)
+	return &masterKey{id: binary.BigEndian.Uint64(sum[:8]), aead: aead}, nil
+}
+
+func parseMasterKey(s string) (*masterKey, error) {
+	key, err := hex.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, errors.Wrap(err, "master key is not hex encoded")
+	}
+	return newMasterKey(key)
+}
+
+func loadMasterKeyFile(path string) (*masterKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	key, err := parseMasterKey(string(data))
+	return key, errors.WithMessage(err, path)
+}
+
+// KeyManager encrypts data with the current master key, and decrypts data
+// with any configured master key.
+type KeyManager struct {
+	current *masterKey
+	keys    map[uint64]*masterKey
+}
+
+// NewKeyManager loads the master keys of the config. It returns nil if no key
+// is configured.
+func NewKeyManager(cfg *Config) (*KeyManager, error) {
+	if !cfg.IsEnabled() {
+		return nil, nil
+	}
+	m := &KeyManager{keys: make(map[uint64]*masterKey)}
+	var err error
+	switch {
+	case cfg.MasterKeyFile != "":
+		m.current, err = loadMasterKeyFile(cfg.MasterKeyFile)
+	case cfg.MasterKeyEnv != "":
+		value, ok := os.LookupEnv(cfg.MasterKeyEnv)
+		if !ok {
+			return nil, errors.Errorf("master key environment variable %s is not set", cfg.MasterKeyEnv)
+		}
+		m.current, err = parseMasterKey(value)
+		err = errors.WithMessage(err, cfg.MasterKeyEnv)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if m.current != nil {
+		m.keys[m.current.id] = m.current
+	}
+	for _, path := range cfg.PreviousMasterKeyFiles {
+		key, err := loadMasterKeyFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := m.keys[key.id]; !ok {
+			m.keys[key.id] = key
+		}
+	}
+	return m, nil
+}
+
+// NewKeyManagerFromKey creates a key manager with the hex encoded master key.
+func NewKeyManagerFromKey(key string) (*KeyManager, error) {
+	k, err := parseMasterKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyManager{current: k, keys: map[uint64]*masterKey{k.id: k}}, nil
+}
+
+// IsEncrypted returns true if the data is encrypted by a KeyManager.
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
+
+// Encrypt encrypts the plaintext with the current master key, and binds it
+// to the additional data, which must be the same to decrypt it. It returns
+// the plaintext if there is no current master key.
+func (m *KeyManager) Encrypt(plaintext, additionalData []byte) ([]byte, error) {
+	if m == nil || m.current == nil {
+		return plaintext, nil
+	}
+	aead := m.current.aead
+	out := make([]byte, headerSize+aead.NonceSize(), headerSize+aead.NonceSize()+len(plaintext)+aead.Overhead())
+	copy(out, magic)
+	out[len(magic)] = version
+	binary.BigEndian.PutUint64(out[len(magic)+1:], m.current.id)
+	nonce := out[headerSize:]
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return aead.Seal(out, nonce, plaintext, additionalData), nil
+}
+
+// Decrypt decrypts the data encrypted by Encrypt. Data which is not
+// encrypted is returned as it is.
+func (m *KeyManager) Decrypt(data, additionalData []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	if len(data) < headerSize {
+		return nil, errors.New("encrypted data is truncated")
+	}
+	if v := data[len(magic)]; v != version {
+		return nil, errors.Errorf("unsupported encryption version %d", v)
+	}
+	id := binary.BigEndian.Uint64(data[len(magic)+1:])
+	if m == nil {
+		return nil, errors.Errorf("data is encrypted by master key %016x, but no master key is configured", id)
+	}
+	key, ok := m.keys[id]
+	if !ok {
+		return nil, errors.Errorf("data is encrypted by unknown master key %016x", id)
+	}
+	nonceSize := key.aead.NonceSize()
+	if len(data) < headerSize+nonceSize {
+		return nil, errors.New("encrypted data is truncated")
+	}
+	nonce, ciphertext := data[headerSize:headerSize+nonceSize], data[headerSize+nonceSize:]
+	plaintext, err := key.aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decrypt data")
+	}
+	return plaintext, nil
+}
+
+// IsCurrent returns true if the data is encrypted by the current master key,
+// or it is plaintext and there is no current master key. Other data needs to
+// be re-encrypted.
+func (m *KeyManager) IsCurrent(data []byte) bool {
+	if !IsEncrypted(data) {
+		return m == nil || m.current == nil
+	}
+	return m != nil && m.current != nil && len(data) >= headerSize &&
+		binary.BigEndian.Uint64(data[len(magic)+1:]) == m.current.id
+}
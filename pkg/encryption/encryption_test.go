@@ -0,0 +1,118 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/pingcap/check"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testEncryptionSuite{})
+
+type testEncryptionSuite struct{}
+
+var (
+	key1 = strings.Repeat("01", keySize)
+	key2 = strings.Repeat("02", keySize)
+)
+
+func (s *testEncryptionSuite) TestEncrypt(c *C) {
+	m, err := NewKeyManagerFromKey(key1)
+	c.Assert(err, IsNil)
+	plaintext := []byte("region")
+	data, err := m.Encrypt(plaintext, []byte("key"))
+	c.Assert(err, IsNil)
+	c.Assert(IsEncrypted(data), IsTrue)
+	c.Assert(m.IsCurrent(data), IsTrue)
+	c.Assert(m.IsCurrent(plaintext), IsFalse)
+
+	decrypted, err := m.Decrypt(data, []byte("key"))
+	c.Assert(err, IsNil)
+	c.Assert(decrypted, DeepEquals, plaintext)
+	// The additional data must match.
+	_, err = m.Decrypt(data, []byte("other"))
+	c.Assert(err, NotNil)
+	// Plaintext is returned as it is.
+	decrypted, err = m.Decrypt(plaintext, nil)
+	c.Assert(err, IsNil)
+	c.Assert(decrypted, DeepEquals, plaintext)
+
+	// Other keys can not decrypt it.
+	m2, err := NewKeyManagerFromKey(key2)
+	c.Assert(err, IsNil)
+	c.Assert(m2.IsCurrent(data), IsFalse)
+	_, err = m2.Decrypt(data, []byte("key"))
+	c.Assert(err, NotNil)
+	var empty *KeyManager
+	_, err = empty.Decrypt(data, []byte("key"))
+	c.Assert(err, NotNil)
+	data, err = empty.Encrypt(plaintext, nil)
+	c.Assert(err, IsNil)
+	c.Assert(data, DeepEquals, plaintext)
+
+	_, err = NewKeyManagerFromKey("0102")
+	c.Assert(err, NotNil)
+	_, err = NewKeyManagerFromKey("not hex")
+	c.Assert(err, NotNil)
+}
+
+func (s *testEncryptionSuite) TestKeyManager(c *C) {
+	dir, err := ioutil.TempDir("", "encryption")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	file1, file2 := filepath.Join(dir, "key1"), filepath.Join(dir, "key2")
+	c.Assert(ioutil.WriteFile(file1, []byte(key1+"\n"), 0600), IsNil)
+	c.Assert(ioutil.WriteFile(file2, []byte(key2), 0600), IsNil)
+
+	m, err := NewKeyManager(&Config{})
+	c.Assert(err, IsNil)
+	c.Assert(m, IsNil)
+	_, err = NewKeyManager(&Config{MasterKeyFile: filepath.Join(dir, "missing")})
+	c.Assert(err, NotNil)
+	_, err = NewKeyManager(&Config{MasterKeyEnv: "PD_TEST_MISSING_MASTER_KEY"})
+	c.Assert(err, NotNil)
+
+	old, err := NewKeyManager(&Config{MasterKeyFile: file1})
+	c.Assert(err, IsNil)
+	data, err := old.Encrypt([]byte("region"), nil)
+	c.Assert(err, IsNil)
+
+	// Rotate the master key from the environment variable.
+	c.Assert(os.Setenv("PD_TEST_MASTER_KEY", key2), IsNil)
+	defer os.Unsetenv("PD_TEST_MASTER_KEY")
+	m, err = NewKeyManager(&Config{MasterKeyEnv: "PD_TEST_MASTER_KEY", PreviousMasterKeyFiles: []string{file1}})
+	c.Assert(err, IsNil)
+	c.Assert(m.IsCurrent(data), IsFalse)
+	decrypted, err := m.Decrypt(data, nil)
+	c.Assert(err, IsNil)
+	c.Assert(string(decrypted), Equals, "region")
+
+	// Only previous keys decrypt data to plaintext.
+	m, err = NewKeyManager(&Config{PreviousMasterKeyFiles: []string{file1, file2}})
+	c.Assert(err, IsNil)
+	c.Assert(m.IsCurrent(data), IsFalse)
+	c.Assert(m.IsCurrent([]byte("region")), IsTrue)
+	encrypted, err := m.Encrypt([]byte("region"), nil)
+	c.Assert(err, IsNil)
+	c.Assert(IsEncrypted(encrypted), IsFalse)
+}
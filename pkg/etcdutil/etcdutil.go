@@ -24,6 +24,7 @@ import (
 	"github.com/pkg/errors"
 	"go.etcd.io/etcd/clientv3"
 	"go.etcd.io/etcd/etcdserver"
+	"go.etcd.io/etcd/etcdserver/etcdserverpb"
 	"go.etcd.io/etcd/pkg/types"
 )
 
@@ -38,6 +39,10 @@ const (
 	// DefaultSlowRequestTime 1s for the threshold for normal request, for those
 	// longer then 1s, they are considered as slow requests.
 	DefaultSlowRequestTime = 1 * time.Second
+
+	// memberStatusTimeout is short, so that a dead member does not block the
+	// health check for long.
+	memberStatusTimeout = 3 * time.Second
 )
 
 // CheckClusterID checks Etcd's cluster ID, returns an error if mismatch.
@@ -80,6 +85,24 @@ func AddEtcdMember(client *clientv3.Client, urls []string) (*clientv3.MemberAddR
 	return addResp, errors.WithStack(err)
 }
 
+// AddEtcdLearner adds an etcd member as a learner, which does not vote until
+// it is promoted.
+func AddEtcdLearner(client *clientv3.Client, urls []string) (*clientv3.MemberAddResponse, error) {
+	ctx, cancel := context.WithTimeout(client.Ctx(), DefaultRequestTimeout)
+	addResp, err := client.MemberAddAsLearner(ctx, urls)
+	cancel()
+	return addResp, errors.WithStack(err)
+}
+
+// PromoteEtcdLearner promotes a learner to a voting member. It fails if the
+// learner has not caught up with the leader.
+func PromoteEtcdLearner(client *clientv3.Client, id uint64) (*clientv3.MemberPromoteResponse, error) {
+	ctx, cancel := context.WithTimeout(client.Ctx(), DefaultRequestTimeout)
+	promoteResp, err := client.MemberPromote(ctx, id)
+	cancel()
+	return promoteResp, errors.WithStack(err)
+}
+
 // ListEtcdMembers returns a list of internal etcd members.
 func ListEtcdMembers(client *clientv3.Client) (*clientv3.MemberListResponse, error) {
 	ctx, cancel := context.WithTimeout(client.Ctx(), DefaultRequestTimeout)
@@ -95,3 +118,20 @@ func RemoveEtcdMember(client *clientv3.Client, id uint64) (*clientv3.MemberRemov
 	cancel()
 	return rmResp, errors.WithStack(err)
 }
+
+// GetEtcdMemberStatus returns the raft status of the member. It tries the
+// client URLs of the member in turn, and returns the last error if none of
+// them responds.
+func GetEtcdMemberStatus(client *clientv3.Client, member *etcdserverpb.Member) (*clientv3.StatusResponse, error) {
+	err := errors.Errorf("member %s has no client url", member.GetName())
+	for _, url := range member.GetClientURLs() {
+		ctx, cancel := context.WithTimeout(client.Ctx(), memberStatusTimeout)
+		var resp *clientv3.StatusResponse
+		resp, err = client.Status(ctx, url)
+		cancel()
+		if err == nil {
+			return resp, nil
+		}
+	}
+	return nil, errors.WithStack(err)
+}
@@ -20,6 +20,7 @@ import (
 	"net/url"
 	"os"
 	"testing"
+	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/pd/pkg/tempurl"
@@ -140,3 +141,49 @@ func (s *testEtcdutilSuite) TestMemberHelpers(c *C) {
 	cleanConfig(cfg1)
 	cleanConfig(cfg2)
 }
+
+func (s *testEtcdutilSuite) TestLearnerHelpers(c *C) {
+	cfg1 := newTestSingleConfig()
+	etcd1, err := embed.StartEtcd(cfg1)
+	c.Assert(err, IsNil)
+	defer cleanConfig(cfg1)
+	defer etcd1.Close()
+	client1, err := clientv3.New(clientv3.Config{
+		Endpoints: []string{cfg1.LCUrls[0].String()},
+	})
+	c.Assert(err, IsNil)
+	<-etcd1.Server.ReadyNotify()
+
+	cfg2 := newTestSingleConfig()
+	cfg2.Name = "etcd2"
+	cfg2.InitialCluster = cfg1.InitialCluster + fmt.Sprintf(",%s=%s", cfg2.Name, &cfg2.LPUrls[0])
+	cfg2.ClusterState = embed.ClusterStateFlagExisting
+	addResp, err := AddEtcdLearner(client1, []string{cfg2.LPUrls[0].String()})
+	c.Assert(err, IsNil)
+	c.Assert(addResp.Member.IsLearner, IsTrue)
+
+	etcd2, err := embed.StartEtcd(cfg2)
+	c.Assert(err, IsNil)
+	defer cleanConfig(cfg2)
+	defer etcd2.Close()
+	<-etcd2.Server.ReadyNotify()
+	c.Assert(etcd2.Server.IsLearner(), IsTrue)
+
+	// The learner is promoted after it catches up.
+	for i := 0; ; i++ {
+		_, err = PromoteEtcdLearner(client1, addResp.Member.ID)
+		if err == nil || i >= 50 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	c.Assert(err, IsNil)
+	listResp, err := ListEtcdMembers(client1)
+	c.Assert(err, IsNil)
+	c.Assert(listResp.Members, HasLen, 2)
+	for _, m := range listResp.Members {
+		c.Assert(m.IsLearner, IsFalse)
+	}
+	_, err = PromoteEtcdLearner(client1, addResp.Member.ID)
+	c.Assert(err, NotNil)
+}
@@ -14,6 +14,7 @@
 package metricutil
 
 import (
+	"os"
 	"time"
 	"unicode"
 
@@ -61,11 +62,10 @@ func camelCaseToSnakeCase(str string) string {
 // prometheusPushClient pushs metrics to Prometheus Pushgateway.
 func prometheusPushClient(job, addr string, interval time.Duration) {
 	for {
-		err := push.FromGatherer(
-			job, push.HostnameGroupingKey(),
-			addr,
-			prometheus.DefaultGatherer,
-		)
+		err := push.New(addr, job).
+			Gatherer(prometheus.DefaultGatherer).
+			Grouping("instance", instanceName()).
+			Push()
 		if err != nil {
 			log.Error("could not push metrics to Prometheus Pushgateway", zap.Error(err))
 		}
@@ -74,6 +74,14 @@ func prometheusPushClient(job, addr string, interval time.Duration) {
 	}
 }
 
+func instanceName() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return hostname
+}
+
 // Push metircs in background.
 func Push(cfg *MetricConfig) {
 	if cfg.PushInterval.Duration == zeroDuration || len(cfg.PushAddress) == 0 {
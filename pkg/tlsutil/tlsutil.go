@@ -0,0 +1,252 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/pingcap/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// checkInterval limits how often the files are checked in handshakes.
+const checkInterval = time.Second
+
+var certExpiryGauge = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: "pd",
+		Subsystem: "tls",
+		Name:      "cert_expiry_timestamp_seconds",
+		Help:      "Expiry time of the loaded TLS certificates.",
+	}, []string{"cert"})
+
+func init() {
+	prometheus.MustRegister(certExpiryGauge)
+}
+
+// CertReloader keeps the key pair loaded from the files, and reloads it when
+// the files are modified. A key pair which fails to load is ignored, and the
+// old one is kept.
+type CertReloader struct {
+	certPath string
+	keyPath  string
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	certMod   time.Time
+	keyMod    time.Time
+	checkedAt time.Time
+}
+
+var (
+	reloadersMu sync.Mutex
+	reloaders   = make(map[[2]string]*CertReloader)
+	caReloaders = make(map[string]*CAReloader)
+)
+
+// GetCertReloader returns the reloader of the key pair, which is shared by
+// all TLS configs using the files.
+func GetCertReloader(certPath, keyPath string) (*CertReloader, error) {
+	reloadersMu.Lock()
+	defer reloadersMu.Unlock()
+	key := [2]string{certPath, keyPath}
+	if r, ok := reloaders[key]; ok {
+		return r, nil
+	}
+	r := &CertReloader{certPath: certPath, keyPath: keyPath}
+	if err := r.Check(); err != nil {
+		return nil, err
+	}
+	reloaders[key] = r
+	return r, nil
+}
+
+// CheckAll checks all reloaders, to reload the modified key pairs and update
+// the expiry metrics without waiting for new connections.
+func CheckAll() {
+	reloadersMu.Lock()
+	all := make([]*CertReloader, 0, len(reloaders))
+	for _, r := range reloaders {
+		all = append(all, r)
+	}
+	cas := make([]*CAReloader, 0, len(caReloaders))
+	for _, r := range caReloaders {
+		cas = append(cas, r)
+	}
+	reloadersMu.Unlock()
+	for _, r := range all {
+		if err := r.Check(); err != nil {
+			log.Warn("failed to reload certificate", zap.String("cert", r.certPath), zap.Error(err))
+		}
+	}
+	for _, r := range cas {
+		if err := r.Check(); err != nil {
+			log.Warn("failed to reload CA certificate", zap.String("ca", r.caPath), zap.Error(err))
+		}
+	}
+}
+
+// Check reloads the key pair if the files are modified.
+func (r *CertReloader) Check() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// A failed check also counts, so that the files are not checked in
+	// every handshake while they are missing.
+	r.checkedAt = time.Now()
+	certInfo, err := os.Stat(r.certPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	keyInfo, err := os.Stat(r.keyPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if r.cert != nil && certInfo.ModTime().Equal(r.certMod) && keyInfo.ModTime().Equal(r.keyMod) {
+		return nil
+	}
+	cert, err := tls.LoadX509KeyPair(r.certPath, r.keyPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+		return errors.WithStack(err)
+	}
+	if r.cert != nil {
+		log.Info("certificate is reloaded",
+			zap.String("cert", r.certPath),
+			zap.Time("not-after", cert.Leaf.NotAfter))
+	}
+	r.cert, r.certMod, r.keyMod = &cert, certInfo.ModTime(), keyInfo.ModTime()
+	certExpiryGauge.WithLabelValues(r.certPath).Set(float64(cert.Leaf.NotAfter.Unix()))
+	return nil
+}
+
+// Certificate returns the latest key pair.
+func (r *CertReloader) Certificate() *tls.Certificate {
+	r.mu.RLock()
+	cert, checkedAt := r.cert, r.checkedAt
+	r.mu.RUnlock()
+	if time.Since(checkedAt) < checkInterval {
+		return cert
+	}
+	if err := r.Check(); err != nil {
+		log.Warn("failed to reload certificate, use the old one", zap.String("cert", r.certPath), zap.Error(err))
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert
+}
+
+// GetCertificate is used as tls.Config.GetCertificate.
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.Certificate(), nil
+}
+
+// GetClientCertificate is used as tls.Config.GetClientCertificate.
+func (r *CertReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return r.Certificate(), nil
+}
+
+// Apply makes the config use the reloaded key pair.
+func (r *CertReloader) Apply(cfg *tls.Config) {
+	cfg.Certificates = nil
+	cfg.GetCertificate = r.GetCertificate
+	cfg.GetClientCertificate = r.GetClientCertificate
+}
+
+// CAReloader keeps the CA certificates loaded from the file, and reloads them
+// when the file is modified. CA certificates which fail to load are ignored,
+// and the old ones are kept.
+type CAReloader struct {
+	caPath string
+
+	mu        sync.RWMutex
+	pool      *x509.CertPool
+	caMod     time.Time
+	checkedAt time.Time
+}
+
+// GetCAReloader returns the reloader of the CA file, which is shared by all
+// TLS configs using the file.
+func GetCAReloader(caPath string) (*CAReloader, error) {
+	reloadersMu.Lock()
+	defer reloadersMu.Unlock()
+	if r, ok := caReloaders[caPath]; ok {
+		return r, nil
+	}
+	r := &CAReloader{caPath: caPath}
+	if err := r.Check(); err != nil {
+		return nil, err
+	}
+	caReloaders[caPath] = r
+	return r, nil
+}
+
+// Check reloads the CA certificates if the file is modified.
+func (r *CAReloader) Check() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checkedAt = time.Now()
+	info, err := os.Stat(r.caPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if r.pool != nil && info.ModTime().Equal(r.caMod) {
+		return nil
+	}
+	ca, err := ioutil.ReadFile(r.caPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return errors.New("failed to append ca certs")
+	}
+	if r.pool != nil {
+		log.Info("CA certificate is reloaded", zap.String("ca", r.caPath))
+	}
+	r.pool, r.caMod = pool, info.ModTime()
+	return nil
+}
+
+// Pool returns the latest CA certificates.
+func (r *CAReloader) Pool() *x509.CertPool {
+	r.mu.RLock()
+	pool, checkedAt := r.pool, r.checkedAt
+	r.mu.RUnlock()
+	if time.Since(checkedAt) < checkInterval {
+		return pool
+	}
+	if err := r.Check(); err != nil {
+		log.Warn("failed to reload CA certificate, use the old one", zap.String("ca", r.caPath), zap.Error(err))
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.pool
+}
+
+// Apply makes the config verify the peers by the latest CA certificates. The
+// default verification of crypto/tls, including the hostname check, is kept.
+// The CA certificates are taken when the config is built, so a config should
+// be built for new connections to use the reloaded CA certificates.
+func (r *CAReloader) Apply(cfg *tls.Config) {
+	cfg.RootCAs = r.Pool()
+}
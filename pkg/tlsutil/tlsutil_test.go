@@ -0,0 +1,149 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tlsutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testTLSUtilSuite{})
+
+type testTLSUtilSuite struct{}
+
+// writeKeyPair writes a self-signed key pair which expires at notAfter, and
+// sets the modification time of the files. The certificate can be used as a
+// CA certificate too.
+func writeKeyPair(c *C, certPath, keyPath string, notAfter, modTime time.Time) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	c.Assert(err, IsNil)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "pd"},
+		DNSNames:     []string{"pd"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	c.Assert(err, IsNil)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	c.Assert(err, IsNil)
+	c.Assert(ioutil.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600), IsNil)
+	c.Assert(ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600), IsNil)
+	c.Assert(os.Chtimes(certPath, modTime, modTime), IsNil)
+	c.Assert(os.Chtimes(keyPath, modTime, modTime), IsNil)
+	cert, err := x509.ParseCertificate(der)
+	c.Assert(err, IsNil)
+	return cert
+}
+
+func (s *testTLSUtilSuite) TestReload(c *C) {
+	dir, err := ioutil.TempDir("", "tlsutil")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	certPath, keyPath := filepath.Join(dir, "pd.pem"), filepath.Join(dir, "pd-key.pem")
+
+	_, err = GetCertReloader(certPath, keyPath)
+	c.Assert(err, NotNil)
+
+	now := time.Now().Truncate(time.Second)
+	writeKeyPair(c, certPath, keyPath, now.Add(time.Hour), now.Add(-time.Minute))
+	r, err := GetCertReloader(certPath, keyPath)
+	c.Assert(err, IsNil)
+	r2, err := GetCertReloader(certPath, keyPath)
+	c.Assert(err, IsNil)
+	c.Assert(r2, Equals, r)
+	c.Assert(r.Certificate().Leaf.NotAfter.Equal(now.Add(time.Hour)), IsTrue)
+
+	cfg := &tls.Config{}
+	r.Apply(cfg)
+	c.Assert(cfg.GetCertificate, NotNil)
+	c.Assert(cfg.GetClientCertificate, NotNil)
+
+	// The rotated key pair is reloaded.
+	writeKeyPair(c, certPath, keyPath, now.Add(24*time.Hour), now)
+	CheckAll()
+	cert, err := cfg.GetClientCertificate(nil)
+	c.Assert(err, IsNil)
+	c.Assert(cert.Leaf.NotAfter.Equal(now.Add(24*time.Hour)), IsTrue)
+
+	// A broken key pair is ignored.
+	c.Assert(ioutil.WriteFile(certPath, []byte("broken"), 0600), IsNil)
+	c.Assert(r.Check(), NotNil)
+	cert, err = cfg.GetCertificate(nil)
+	c.Assert(err, IsNil)
+	c.Assert(cert.Leaf.NotAfter.Equal(now.Add(24*time.Hour)), IsTrue)
+
+	// Missing files are not checked again in every handshake.
+	c.Assert(os.Remove(keyPath), IsNil)
+	r.checkedAt = time.Time{}
+	c.Assert(r.Certificate(), NotNil)
+	c.Assert(time.Since(r.checkedAt) < checkInterval, IsTrue)
+}
+
+func (s *testTLSUtilSuite) TestReloadCA(c *C) {
+	dir, err := ioutil.TempDir("", "tlsutil")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	caPath, keyPath := filepath.Join(dir, "ca.pem"), filepath.Join(dir, "ca-key.pem")
+
+	_, err = GetCAReloader(caPath)
+	c.Assert(err, NotNil)
+
+	now := time.Now().Truncate(time.Second)
+	oldCA := writeKeyPair(c, caPath, keyPath, now.Add(time.Hour), now.Add(-time.Minute))
+	r, err := GetCAReloader(caPath)
+	c.Assert(err, IsNil)
+	verify := func(cert *x509.Certificate, serverName string) error {
+		// The config is built for every connection.
+		cfg := &tls.Config{}
+		r.Apply(cfg)
+		_, err := cert.Verify(x509.VerifyOptions{DNSName: serverName, Roots: cfg.RootCAs})
+		return err
+	}
+	c.Assert(verify(oldCA, "pd"), IsNil)
+	c.Assert(verify(oldCA, "tikv"), NotNil)
+
+	// The rotated CA is reloaded.
+	newCA := writeKeyPair(c, caPath, keyPath, now.Add(time.Hour), now)
+	CheckAll()
+	c.Assert(verify(newCA, "pd"), IsNil)
+	c.Assert(verify(oldCA, "pd"), NotNil)
+
+	// A broken CA is ignored.
+	c.Assert(ioutil.WriteFile(caPath, []byte("broken"), 0600), IsNil)
+	c.Assert(r.Check(), NotNil)
+	c.Assert(verify(newCA, "pd"), IsNil)
+}
@@ -51,3 +51,22 @@ func (h *adminHandler) HandleDropCacheRegion(w http.ResponseWriter, r *http.Requ
 	cluster.DropCacheRegion(regionID)
 	h.rd.JSON(w, http.StatusOK, nil)
 }
+
+// GetAuditRecords lists the latest audit records of the mutating API calls,
+// the newest first.
+func (h *adminHandler) GetAuditRecords(w http.ResponseWriter, r *http.Request) {
+	var limit int
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		if limit, err = strconv.Atoi(limitStr); err != nil || limit < 0 {
+			h.rd.JSON(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+	}
+	records, err := h.svr.GetAuditRecords(limit)
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, records)
+}
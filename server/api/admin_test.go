@@ -14,6 +14,9 @@
 package api
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"net/http"
 
@@ -78,3 +81,47 @@ func (s *testAdminSuite) TestDropRegion(c *C) {
 	c.Assert(region.GetRegionEpoch().ConfVer, Equals, uint64(50))
 	c.Assert(region.GetRegionEpoch().Version, Equals, uint64(50))
 }
+
+func (s *testAdminSuite) TestAuditRecords(c *C) {
+	body := []byte(`{"max-snapshot-count": 8}`)
+	err := postJSON(s.urlPrefix+"/config/schedule", body)
+	c.Assert(err, IsNil)
+	err = doDelete(fmt.Sprintf("%s/admin/cache/region/%d", s.urlPrefix, 100))
+	c.Assert(err, IsNil)
+	// A GET call is not recorded.
+	var cfg map[string]interface{}
+	err = readJSONWithURL(s.urlPrefix+"/config/schedule", &cfg)
+	c.Assert(err, IsNil)
+
+	var records []*server.AuditRecord
+	err = readJSONWithURL(s.urlPrefix+"/admin/audit?limit=2", &records)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 2)
+	c.Assert(records[0].Seq, Equals, records[1].Seq+1)
+	c.Assert(records[0].Method, Equals, "DELETE")
+	c.Assert(records[0].Route, Equals, "/pd/api/v1/admin/cache/region/{id}")
+	c.Assert(records[0].Path, Equals, "/pd/api/v1/admin/cache/region/100")
+	c.Assert(records[0].Status, Equals, http.StatusOK)
+	c.Assert(records[0].Member, Equals, s.svr.Name())
+	c.Assert(records[0].RemoteAddr, Not(Equals), "")
+	digest := sha256.Sum256(body)
+	c.Assert(records[1].Method, Equals, "POST")
+	c.Assert(records[1].Route, Equals, "/pd/api/v1/config/schedule")
+	c.Assert(records[1].BodyDigest, Equals, hex.EncodeToString(digest[:]))
+
+	res, err := http.Get(s.urlPrefix + "/admin/audit?limit=-1")
+	c.Assert(err, IsNil)
+	res.Body.Close()
+	c.Assert(res.StatusCode, Equals, http.StatusBadRequest)
+
+	// The body larger than the limit is rejected and recorded.
+	res, err = http.Post(s.urlPrefix+"/config/schedule", "application/json", bytes.NewReader(make([]byte, maxAuditBodySize+1)))
+	c.Assert(err, IsNil)
+	res.Body.Close()
+	c.Assert(res.StatusCode, Equals, http.StatusRequestEntityTooLarge)
+	err = readJSONWithURL(s.urlPrefix+"/admin/audit?limit=1", &records)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 1)
+	c.Assert(records[0].Status, Equals, http.StatusRequestEntityTooLarge)
+	c.Assert(records[0].BodyDigest, Equals, "")
+}
@@ -0,0 +1,135 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	log "github.com/pingcap/log"
+	"github.com/pingcap/pd/server"
+	"github.com/pkg/errors"
+	"github.com/urfave/negroni"
+	"go.uber.org/zap"
+)
+
+// maxAuditBodySize is the max size of the body of a mutating API call, which
+// is read by the auditor to digest it.
+const maxAuditBodySize = 4 << 20
+
+// auditor records the mutating API calls served by the server. It runs before
+// the redirector, so that the calls rejected by a follower are recorded too,
+// while a call redirected to the leader is recorded by the leader only.
+type auditor struct {
+	s      *server.Server
+	router *mux.Router
+}
+
+func newAuditor(s *server.Server, router *mux.Router) *auditor {
+	return &auditor{s: s, router: router}
+}
+
+func (a *auditor) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		next(w, r)
+		return
+	}
+
+	// The caller is taken before the redirector overwrites the headers.
+	caller := getCaller(a.s, r)
+	record := &server.AuditRecord{
+		Time:         time.Now(),
+		RemoteAddr:   r.RemoteAddr,
+		RedirectedBy: caller.redirectedBy,
+		Method:       r.Method,
+		Route:        a.route(r),
+		Path:         r.URL.Path,
+	}
+	if caller.redirectedBy != "" {
+		record.ForwardedFor = caller.addr
+	}
+	if len(caller.identities) > 0 {
+		record.ClientCN = caller.identities[0]
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxAuditBodySize+1))
+	r.Body.Close()
+	if err != nil {
+		a.reject(w, record, http.StatusBadRequest, err)
+		return
+	}
+	if len(body) > maxAuditBodySize {
+		a.reject(w, record, http.StatusRequestEntityTooLarge, errors.Errorf("request body is larger than %d bytes", maxAuditBodySize))
+		return
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	digest := sha256.Sum256(body)
+	record.BodyDigest = hex.EncodeToString(digest[:])
+
+	redirected := new(bool)
+	r = r.WithContext(context.WithValue(r.Context(), redirectedKey{}, redirected))
+	rw := negroni.NewResponseWriter(w)
+	next(rw, r)
+	if *redirected {
+		return
+	}
+	record.Status = rw.Status()
+	if record.Status == 0 {
+		record.Status = http.StatusOK
+	}
+	a.save(record)
+}
+
+// reject records and responds a call whose body can not be read.
+func (a *auditor) reject(w http.ResponseWriter, record *server.AuditRecord, status int, err error) {
+	record.Status = status
+	a.save(record)
+	http.Error(w, err.Error(), status)
+}
+
+func (a *auditor) save(record *server.AuditRecord) {
+	if err := a.s.SaveAuditRecord(record); err != nil {
+		log.Error("failed to save audit record", zap.Reflect("record", record), zap.Error(err))
+	}
+}
+
+// route returns the path template of the matched route.
+func (a *auditor) route(r *http.Request) string {
+	var match mux.RouteMatch
+	if !a.router.Match(r, &match) || match.Route == nil {
+		return ""
+	}
+	template, err := match.Route.GetPathTemplate()
+	if err != nil {
+		return ""
+	}
+	return template
+}
+
+type redirectedKey struct{}
+
+// markRedirected marks that the leader has served the request redirected by
+// the member, so that the call is not recorded twice.
+func markRedirected(r *http.Request) {
+	if redirected, ok := r.Context().Value(redirectedKey{}).(*bool); ok {
+		*redirected = true
+	}
+}
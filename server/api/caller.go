@@ -0,0 +1,159 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	log "github.com/pingcap/log"
+	"github.com/pingcap/pd/server"
+	"go.uber.org/zap"
+)
+
+// caller is the client which sends a request.
+type caller struct {
+	// addr is the address of the client.
+	addr string
+	// identities are the common name and the SANs of the verified TLS client
+	// certificate.
+	identities []string
+	// redirectedBy is the name of the follower which redirects the request.
+	redirectedBy string
+}
+
+// getCaller returns the client of the request. The client of a request
+// redirected by a follower is taken from the headers set by the follower,
+// which are ignored unless the request comes from the member, as any client
+// can set them.
+func getCaller(s *server.Server, r *http.Request) *caller {
+	if redirectedByMember(s, r) {
+		return &caller{
+			addr:         r.Header.Get(forwardedForHeader),
+			identities:   r.Header[forwardedIdentitiesHeader],
+			redirectedBy: r.Header.Get(redirectorHeader),
+		}
+	}
+	return &caller{addr: r.RemoteAddr, identities: clientIdentities(r)}
+}
+
+// String returns the common name of the client certificate if there is one,
+// otherwise the client address.
+func (c *caller) String() string {
+	if len(c.identities) > 0 {
+		return c.identities[0]
+	}
+	return c.addr
+}
+
+// setForwardedHeaders sets the headers about the client before the request is
+// redirected to the leader. The headers sent by the client are overwritten.
+func setForwardedHeaders(s *server.Server, r *http.Request) {
+	r.Header.Set(redirectorHeader, s.Name())
+	r.Header.Set(forwardedForHeader, r.RemoteAddr)
+	r.Header.Del(forwardedIdentitiesHeader)
+	for _, identity := range clientIdentities(r) {
+		r.Header.Add(forwardedIdentitiesHeader, identity)
+	}
+}
+
+// redirectedByMember checks whether the request is redirected by another
+// member of the cluster. With TLS, the client certificate should be valid for
+// the host of the member, like its server certificate. Otherwise, the request
+// should come from the host of the member.
+func redirectedByMember(s *server.Server, r *http.Request) bool {
+	name := r.Header.Get(redirectorHeader)
+	if name == "" || name == s.Name() {
+		return false
+	}
+	members, err := s.GetCachedMembers()
+	if err != nil {
+		log.Warn("failed to get members to check the redirector", zap.String("redirector", name), zap.Error(err))
+		return false
+	}
+	for _, m := range members {
+		if m.GetName() != name {
+			continue
+		}
+		for _, u := range m.GetClientUrls() {
+			parsed, err := url.Parse(u)
+			if err != nil {
+				continue
+			}
+			if requestFromHost(s, r, parsed.Hostname()) {
+				return true
+			}
+		}
+	}
+	log.Warn("ignore the forwarded headers of a request not from the redirector",
+		zap.String("redirector", name), zap.String("remote-addr", r.RemoteAddr))
+	return false
+}
+
+func requestFromHost(s *server.Server, r *http.Request, host string) bool {
+	if s.GetSecurityConfig().CAPath != "" {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+			return false
+		}
+		return r.TLS.VerifiedChains[0][0].VerifyHostname(host) == nil
+	}
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	if remote == host {
+		return true
+	}
+	addrs, err := lookupHost(host)
+	if err != nil {
+		return false
+	}
+	return contains(addrs, remote)
+}
+
+// hostAddrsTTL is how long the addresses of a member host are reused.
+const hostAddrsTTL = 30 * time.Second
+
+type hostAddrs struct {
+	addrs   []string
+	updated time.Time
+}
+
+var (
+	hostAddrsLock  sync.Mutex
+	hostAddrsCache = make(map[string]hostAddrs)
+)
+
+// lookupHost looks up the addresses of a member host, which are cached for
+// hostAddrsTTL, so that checking the redirector of a request does not look
+// up DNS every time. Only the hosts of the members are cached.
+func lookupHost(host string) ([]string, error) {
+	hostAddrsLock.Lock()
+	cached, ok := hostAddrsCache[host]
+	hostAddrsLock.Unlock()
+	if ok && time.Since(cached.updated) < hostAddrsTTL {
+		return cached.addrs, nil
+	}
+	addrs, err := net.LookupHost(host)
+	if err != nil {
+		return nil, err
+	}
+	hostAddrsLock.Lock()
+	hostAddrsCache[host] = hostAddrs{addrs: addrs, updated: time.Now()}
+	hostAddrsLock.Unlock()
+	return addrs, nil
+}
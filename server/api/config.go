@@ -16,8 +16,12 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/pingcap/errcode"
@@ -43,6 +47,7 @@ func (h *confHandler) Get(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *confHandler) Post(w http.ResponseWriter, r *http.Request) {
+	old := h.svr.GetConfig()
 	config := h.svr.GetConfig()
 	data, err := ioutil.ReadAll(r.Body)
 	r.Body.Close()
@@ -50,31 +55,21 @@ func (h *confHandler) Post(w http.ResponseWriter, r *http.Request) {
 		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	if err := json.Unmarshal(data, &config.Schedule); err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
-		return
-	}
-	if err := json.Unmarshal(data, &config.Replication); err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+	if err := decodeConfigFields(data, &config.Schedule, &config.Replication, &config.PDServerCfg); err != nil {
+		errorResp(h.rd, w, err)
 		return
 	}
-	if err := json.Unmarshal(data, &config.PDServerCfg); err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
-		return
-	}
-	if err := h.svr.SetScheduleConfig(config.Schedule); err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+	if err := h.svr.UpdateConfigWithHistory(getCaller(h.svr, r).String(), "", &config.Schedule, &config.Replication, func() error {
+		return h.svr.SetScheduleOptionConfig(config.Schedule, config.Replication, config.PDServerCfg)
+	}); err != nil {
+		errorResp(h.rd, w, err)
 		return
 	}
-	if err := h.svr.SetReplicationConfig(config.Replication); err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
-		return
-	}
-	if err := h.svr.SetPDServerConfig(config.PDServerCfg); err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
-		return
-	}
-	h.rd.JSON(w, http.StatusOK, nil)
+	h.respondChanges(w,
+		configSection{"schedule", &old.Schedule, &config.Schedule},
+		configSection{"replication", &old.Replication, &config.Replication},
+		configSection{"pd-server", &old.PDServerCfg, &config.PDServerCfg},
+	)
 }
 
 func (h *confHandler) GetSchedule(w http.ResponseWriter, r *http.Request) {
@@ -82,16 +77,19 @@ func (h *confHandler) GetSchedule(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *confHandler) SetSchedule(w http.ResponseWriter, r *http.Request) {
+	old := h.svr.GetScheduleConfig()
 	config := h.svr.GetScheduleConfig()
-	if err := readJSONRespondError(h.rd, w, r.Body, &config); err != nil {
+	if err := readConfigFieldsRespondError(h.rd, w, r.Body, config); err != nil {
 		return
 	}
 
-	if err := h.svr.SetScheduleConfig(*config); err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+	if err := h.svr.UpdateConfigWithHistory(getCaller(h.svr, r).String(), "", config, h.svr.GetReplicationConfig(), func() error {
+		return h.svr.SetScheduleConfig(*config)
+	}); err != nil {
+		errorResp(h.rd, w, err)
 		return
 	}
-	h.rd.JSON(w, http.StatusOK, nil)
+	h.respondChanges(w, configSection{"schedule", old, config})
 }
 
 func (h *confHandler) GetReplication(w http.ResponseWriter, r *http.Request) {
@@ -99,16 +97,112 @@ func (h *confHandler) GetReplication(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *confHandler) SetReplication(w http.ResponseWriter, r *http.Request) {
+	old := h.svr.GetReplicationConfig()
 	config := h.svr.GetReplicationConfig()
-	if err := readJSONRespondError(h.rd, w, r.Body, &config); err != nil {
+	if err := readConfigFieldsRespondError(h.rd, w, r.Body, config); err != nil {
 		return
 	}
 
-	if err := h.svr.SetReplicationConfig(*config); err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+	if err := h.svr.UpdateConfigWithHistory(getCaller(h.svr, r).String(), "", h.svr.GetScheduleConfig(), config, func() error {
+		return h.svr.SetReplicationConfig(*config)
+	}); err != nil {
+		errorResp(h.rd, w, err)
 		return
 	}
-	h.rd.JSON(w, http.StatusOK, nil)
+	h.respondChanges(w, configSection{"replication", old, config})
+}
+
+// configSection is a config section before and after updating.
+type configSection struct {
+	name     string
+	old, new interface{}
+}
+
+// respondChanges responds with the changed fields of the sections.
+func (h *confHandler) respondChanges(w http.ResponseWriter, sections ...configSection) {
+	changes := []server.ConfigChange{}
+	for _, section := range sections {
+		c, err := server.DiffConfig(section.name, section.old, section.new)
+		if err != nil {
+			h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		changes = append(changes, c...)
+	}
+	h.rd.JSON(w, http.StatusOK, changes)
+}
+
+func readConfigFieldsRespondError(rd *render.Render, w http.ResponseWriter, body io.ReadCloser, sections ...interface{}) error {
+	data, err := ioutil.ReadAll(body)
+	body.Close()
+	if err != nil {
+		rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return err
+	}
+	if err := decodeConfigFields(data, sections...); err != nil {
+		errorResp(rd, w, err)
+		return err
+	}
+	return nil
+}
+
+// decodeConfigFields updates the fields of the config sections with the
+// JSON object. Every key of the object should be the JSON name of a field in
+// the sections, and the value should be valid for the field type. Bool fields
+// accept both JSON bools and "true" or "false".
+func decodeConfigFields(data []byte, sections ...interface{}) error {
+	items := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &items); err != nil {
+		return errcode.NewInvalidInputErr(errors.WithStack(err))
+	}
+	for key, value := range items {
+		fields := findConfigFields(key, sections)
+		if len(fields) == 0 {
+			return errcode.NewInvalidInputErr(errors.Errorf("unknown config item %q", key))
+		}
+		for _, field := range fields {
+			if err := decodeConfigField(field, value); err != nil {
+				return errcode.NewInvalidInputErr(errors.Errorf("invalid value %s for config item %q: %v", value, key, err))
+			}
+		}
+	}
+	return nil
+}
+
+// findConfigFields returns the fields named key in JSON of the sections.
+func findConfigFields(key string, sections []interface{}) []reflect.Value {
+	var fields []reflect.Value
+	for _, section := range sections {
+		v := reflect.ValueOf(section).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			name := strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0]
+			if name == key {
+				fields = append(fields, v.Field(i))
+			}
+		}
+	}
+	return fields
+}
+
+func decodeConfigField(field reflect.Value, value json.RawMessage) error {
+	if field.Kind() == reflect.Bool {
+		var s string
+		if json.Unmarshal(value, &s) == nil {
+			value = json.RawMessage(s)
+		}
+		var b bool
+		if err := json.Unmarshal(value, &b); err != nil {
+			return errors.New("should be true or false")
+		}
+		field.SetBool(b)
+		return nil
+	}
+	v := reflect.New(field.Type())
+	if err := json.Unmarshal(value, v.Interface()); err != nil {
+		return err
+	}
+	field.Set(v.Elem())
+	return nil
 }
 
 func (h *confHandler) GetNamespace(w http.ResponseWriter, r *http.Request) {
@@ -208,3 +302,25 @@ func (h *confHandler) SetClusterVersion(w http.ResponseWriter, r *http.Request)
 	}
 	h.rd.JSON(w, http.StatusOK, nil)
 }
+
+func (h *confHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	histories, err := h.svr.GetConfigHistory()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, histories)
+}
+
+func (h *confHandler) Rollback(w http.ResponseWriter, r *http.Request) {
+	version, err := strconv.ParseUint(mux.Vars(r)["version"], 10, 64)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.svr.RollbackConfig(version, getCaller(h.svr, r).String()); err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, nil)
+}
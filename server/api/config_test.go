@@ -14,12 +14,18 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"math/rand"
+	"net"
+	"net/http"
+	"strings"
 	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/pd/server"
+	"github.com/pkg/errors"
 )
 
 var _ = Suite(&testConfigSuite{})
@@ -32,6 +38,7 @@ type testConfigSuite struct {
 
 func (s *testConfigSuite) SetUpSuite(c *C) {
 	s.cfgs, s.servers, s.clean = mustNewCluster(c, 3)
+	mustBootstrapCluster(c, mustWaitLeader(c, s.servers))
 }
 
 func (s *testConfigSuite) TearDownSuite(c *C) {
@@ -69,6 +76,21 @@ func (s *testConfigSuite) TestConfigAll(c *C) {
 	cfg.Replication.LocationLabels = []string{"zone", "rack"}
 	cfg.Schedule.RegionScheduleLimit = 10
 	c.Assert(cfg, DeepEquals, newCfg)
+
+	// No section is changed if any one is invalid.
+	l = map[string]interface{}{
+		"location-labels":       "zone,rack,!host",
+		"region-schedule-limit": 20,
+		"use-region-storage":    !cfg.PDServerCfg.UseRegionStorage,
+	}
+	postData, err = json.Marshal(l)
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(addr, postData), NotNil)
+	resp, err = doGet(addr)
+	c.Assert(err, IsNil)
+	newCfg = &server.Config{}
+	c.Assert(readJSON(resp.Body, newCfg), IsNil)
+	c.Assert(cfg, DeepEquals, newCfg)
 }
 
 func (s *testConfigSuite) TestConfigSchedule(c *C) {
@@ -172,3 +194,252 @@ func (s *testConfigSuite) TestConfigLabelProperty(c *C) {
 	c.Assert(cfg, HasLen, 1)
 	c.Assert(cfg["foo"], DeepEquals, []server.StoreLabel{{Key: "zone", Value: "cn2"}})
 }
+
+func (s *testConfigSuite) TestConfigHistory(c *C) {
+	urlPrefix := s.cfgs[rand.Intn(len(s.cfgs))].ClientUrls + apiPrefix + "/api/v1/config"
+	sc := &server.ScheduleConfig{}
+	resp, err := doGet(urlPrefix + "/schedule")
+	c.Assert(err, IsNil)
+	c.Assert(readJSON(resp.Body, sc), IsNil)
+
+	var histories []*server.ConfigHistory
+	c.Assert(readJSONWithURL(urlPrefix+"/history", &histories), IsNil)
+	c.Assert(histories, Not(HasLen), 0)
+	version := histories[len(histories)-1].Version
+
+	postData, err := json.Marshal(map[string]interface{}{"region-schedule-limit": sc.RegionScheduleLimit + 1})
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(urlPrefix+"/schedule", postData), IsNil)
+
+	c.Assert(readJSONWithURL(urlPrefix+"/history", &histories), IsNil)
+	latest := histories[len(histories)-1]
+	c.Assert(latest.Version, Equals, version+1)
+	c.Assert(latest.Caller, Not(Equals), "")
+	c.Assert(latest.Changes, HasLen, 1)
+	c.Assert(latest.Changes[0].Field, Equals, "schedule.region-schedule-limit")
+	c.Assert(latest.Changes[0].New, Equals, float64(sc.RegionScheduleLimit+1))
+
+	// Rollback restores the config and saves a new version.
+	c.Assert(postJSON(fmt.Sprintf("%s/rollback/%d", urlPrefix, version), nil), IsNil)
+	sc1 := &server.ScheduleConfig{}
+	resp, err = doGet(urlPrefix + "/schedule")
+	c.Assert(err, IsNil)
+	c.Assert(readJSON(resp.Body, sc1), IsNil)
+	c.Assert(sc1.RegionScheduleLimit, Equals, sc.RegionScheduleLimit)
+	c.Assert(readJSONWithURL(urlPrefix+"/history", &histories), IsNil)
+	latest = histories[len(histories)-1]
+	c.Assert(latest.Version, Equals, version+2)
+	c.Assert(latest.Comment, Equals, fmt.Sprintf("rollback to version %d", version))
+
+	c.Assert(postJSON(urlPrefix+"/rollback/100000", nil), NotNil)
+
+	// Adding a scheduler is recorded, but it is not rolled back.
+	version = latest.Version
+	postData, err = json.Marshal(map[string]interface{}{"name": "grant-leader-scheduler", "store_id": 1})
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(s.cfgs[0].ClientUrls+apiPrefix+"/api/v1/schedulers", postData), IsNil)
+	c.Assert(readJSONWithURL(urlPrefix+"/history", &histories), IsNil)
+	latest = histories[len(histories)-1]
+	c.Assert(latest.Version, Equals, version+1)
+	c.Assert(latest.Changes, HasLen, 1)
+	c.Assert(latest.Changes[0].Field, Equals, "schedule.schedulers-v2")
+	c.Assert(postJSON(fmt.Sprintf("%s/rollback/%d", urlPrefix, version), nil), IsNil)
+	sc2 := &server.ScheduleConfig{}
+	resp, err = doGet(urlPrefix + "/schedule")
+	c.Assert(err, IsNil)
+	c.Assert(readJSON(resp.Body, sc2), IsNil)
+	c.Assert(sc2.Schedulers, HasLen, len(sc.Schedulers)+1)
+	c.Assert(doDelete(s.cfgs[0].ClientUrls+apiPrefix+"/api/v1/schedulers/grant-leader-scheduler-1"), IsNil)
+}
+
+func (s *testConfigSuite) TestConfigHistoryFailedUpdate(c *C) {
+	leader := mustWaitLeader(c, s.servers)
+	urlPrefix := leader.GetAddr() + apiPrefix + "/api/v1/config"
+	var histories []*server.ConfigHistory
+	c.Assert(readJSONWithURL(urlPrefix+"/history", &histories), IsNil)
+	version := histories[len(histories)-1].Version
+
+	// The version is saved before the config is applied, and removed if
+	// applying fails.
+	sc := leader.GetScheduleConfig()
+	sc.RegionScheduleLimit++
+	err := leader.UpdateConfigWithHistory("test", "", sc, leader.GetReplicationConfig(), func() error {
+		c.Assert(readJSONWithURL(urlPrefix+"/history", &histories), IsNil)
+		c.Assert(histories[len(histories)-1].Version, Equals, version+1)
+		return errors.New("failed to apply")
+	})
+	c.Assert(err, NotNil)
+	c.Assert(readJSONWithURL(urlPrefix+"/history", &histories), IsNil)
+	c.Assert(histories[len(histories)-1].Version, Equals, version)
+	c.Assert(leader.GetScheduleConfig().RegionScheduleLimit, Equals, sc.RegionScheduleLimit-1)
+
+	// An invalid config is neither saved nor applied.
+	postData, err := json.Marshal(map[string]interface{}{"high-space-ratio": 1.5})
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(urlPrefix+"/schedule", postData), NotNil)
+	c.Assert(readJSONWithURL(urlPrefix+"/history", &histories), IsNil)
+	c.Assert(histories[len(histories)-1].Version, Equals, version)
+
+	// The next version reuses the removed one.
+	postData, err = json.Marshal(map[string]interface{}{"region-schedule-limit": sc.RegionScheduleLimit})
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(urlPrefix+"/schedule", postData), IsNil)
+	c.Assert(readJSONWithURL(urlPrefix+"/history", &histories), IsNil)
+	latest := histories[len(histories)-1]
+	c.Assert(latest.Version, Equals, version+1)
+	c.Assert(latest.Changes, HasLen, 1)
+	c.Assert(latest.Changes[0].Field, Equals, "schedule.region-schedule-limit")
+	postData, err = json.Marshal(map[string]interface{}{"region-schedule-limit": sc.RegionScheduleLimit - 1})
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(urlPrefix+"/schedule", postData), IsNil)
+}
+
+func (s *testConfigSuite) TestConfigHistoryCaller(c *C) {
+	leader := mustWaitLeader(c, s.servers)
+	urlPrefix := leader.GetAddr() + apiPrefix + "/api/v1/config"
+	sc := &server.ScheduleConfig{}
+	c.Assert(readJSONWithURL(urlPrefix+"/schedule", sc), IsNil)
+
+	// The forwarded headers of a request not from a follower are ignored.
+	postData, err := json.Marshal(map[string]interface{}{"region-schedule-limit": sc.RegionScheduleLimit + 1})
+	c.Assert(err, IsNil)
+	req, err := http.NewRequest(http.MethodPost, urlPrefix+"/schedule", bytes.NewBuffer(postData))
+	c.Assert(err, IsNil)
+	req.Header.Set(redirectorHeader, leader.Name())
+	req.Header.Set(forwardedForHeader, "1.2.3.4:5")
+	req.Header.Set(forwardedIdentitiesHeader, "admin")
+	resp, err := server.DialClient.Do(req)
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+
+	var histories []*server.ConfigHistory
+	c.Assert(readJSONWithURL(urlPrefix+"/history", &histories), IsNil)
+	caller := histories[len(histories)-1].Caller
+	c.Assert(caller, Not(Equals), "1.2.3.4:5")
+	c.Assert(caller, Not(Equals), "admin")
+
+	// A request redirected by a follower is recorded with the address of
+	// the client instead of the follower.
+	var follower *server.Server
+	for _, svr := range s.servers {
+		if svr != leader {
+			follower = svr
+		}
+	}
+	postData, err = json.Marshal(map[string]interface{}{"region-schedule-limit": sc.RegionScheduleLimit})
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(follower.GetAddr()+apiPrefix+"/api/v1/config/schedule", postData), IsNil)
+	c.Assert(readJSONWithURL(urlPrefix+"/history", &histories), IsNil)
+	caller = histories[len(histories)-1].Caller
+	c.Assert(caller, Matches, `127\.0\.0\.1:\d+`)
+	_, followerPort, err := net.SplitHostPort(strings.TrimPrefix(follower.GetAddr(), "http://"))
+	c.Assert(err, IsNil)
+	c.Assert(strings.HasSuffix(caller, ":"+followerPort), IsFalse)
+}
+
+func (s *testConfigSuite) TestAuditCaller(c *C) {
+	leader := mustWaitLeader(c, s.servers)
+	var follower *server.Server
+	for _, svr := range s.servers {
+		if svr != leader {
+			follower = svr
+		}
+	}
+	auditURL := leader.GetAddr() + apiPrefix + "/api/v1/admin/audit?limit=1"
+	path := fmt.Sprintf("%s/api/v1/admin/cache/region/%d", apiPrefix, 100)
+
+	// A call redirected by a follower is recorded once by the leader, with
+	// the address of the client.
+	c.Assert(doDelete(follower.GetAddr()+path), IsNil)
+	var records []*server.AuditRecord
+	c.Assert(readJSONWithURL(auditURL, &records), IsNil)
+	c.Assert(records, HasLen, 1)
+	c.Assert(records[0].Member, Equals, leader.Name())
+	c.Assert(records[0].RedirectedBy, Equals, follower.Name())
+	c.Assert(records[0].ForwardedFor, Matches, `127\.0\.0\.1:\d+`)
+	c.Assert(records[0].Status, Equals, http.StatusOK)
+	seq := records[0].Seq
+
+	// A call rejected by a follower is recorded by the follower, and the
+	// forwarded headers not set by a member are ignored.
+	req, err := http.NewRequest(http.MethodDelete, follower.GetAddr()+path, nil)
+	c.Assert(err, IsNil)
+	req.Header.Set(redirectorHeader, "unknown")
+	req.Header.Set(forwardedForHeader, "1.2.3.4:5")
+	req.Header.Set(forwardedIdentitiesHeader, "admin")
+	resp, err := server.DialClient.Do(req)
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusInternalServerError)
+	records = nil
+	c.Assert(readJSONWithURL(auditURL, &records), IsNil)
+	c.Assert(records, HasLen, 1)
+	c.Assert(records[0].Seq, Equals, seq+1)
+	c.Assert(records[0].Member, Equals, follower.Name())
+	c.Assert(records[0].Status, Equals, http.StatusInternalServerError)
+	c.Assert(records[0].RedirectedBy, Equals, "")
+	c.Assert(records[0].ForwardedFor, Equals, "")
+	c.Assert(records[0].ClientCN, Equals, "")
+}
+
+func (s *testConfigSuite) TestConfigValidation(c *C) {
+	addr := s.cfgs[rand.Intn(len(s.cfgs))].ClientUrls + apiPrefix + "/api/v1/config"
+	sc := &server.ScheduleConfig{}
+	resp, err := doGet(addr + "/schedule")
+	c.Assert(err, IsNil)
+	c.Assert(readJSON(resp.Body, sc), IsNil)
+	rc := &server.ReplicationConfig{}
+	resp, err = doGet(addr + "/replicate")
+	c.Assert(err, IsNil)
+	c.Assert(readJSON(resp.Body, rc), IsNil)
+
+	for _, input := range []map[string]interface{}{
+		{"max-replica": 5},
+		{"leader-schedule-limit": -1},
+		{"low-space-ratio": 2},
+		{"disable-raft-learner": "yes"},
+		{"max-store-down-time": 30},
+	} {
+		postData, err := json.Marshal(input)
+		c.Assert(err, IsNil)
+		resp, err := server.DialClient.Post(addr, "application/json", bytes.NewBuffer(postData))
+		c.Assert(err, IsNil)
+		resp.Body.Close()
+		c.Assert(resp.StatusCode, Equals, http.StatusBadRequest)
+	}
+
+	postData, err := json.Marshal(map[string]interface{}{
+		"leader-schedule-limit": sc.LeaderScheduleLimit + 1,
+		"disable-raft-learner":  !sc.DisableLearner,
+		// Unchanged fields are not listed.
+		"max-replicas": rc.MaxReplicas,
+	})
+	c.Assert(err, IsNil)
+	resp, err = server.DialClient.Post(addr, "application/json", bytes.NewBuffer(postData))
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+	var changes []server.ConfigChange
+	c.Assert(readJSON(resp.Body, &changes), IsNil)
+	c.Assert(changes, HasLen, 2)
+	c.Assert(changes[0].Field, Equals, "schedule.disable-raft-learner")
+	c.Assert(changes[1].Field, Equals, "schedule.leader-schedule-limit")
+
+	// Out-of-range values are client errors in every section.
+	for _, input := range []struct {
+		section string
+		data    map[string]interface{}
+	}{
+		{"", map[string]interface{}{"location-labels": "zone,a b"}},
+		{"/schedule", map[string]interface{}{"low-space-ratio": 2}},
+		{"/schedule", map[string]interface{}{"high-space-ratio": 0.9, "low-space-ratio": 0.8}},
+		{"/replicate", map[string]interface{}{"location-labels": "zone,a b"}},
+	} {
+		postData, err := json.Marshal(input.data)
+		c.Assert(err, IsNil)
+		resp, err := server.DialClient.Post(addr+input.section, "application/json", bytes.NewBuffer(postData))
+		c.Assert(err, IsNil)
+		resp.Body.Close()
+		c.Assert(resp.StatusCode, Equals, http.StatusBadRequest, Commentf("%s %v", input.section, input.data))
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/core"
+	"github.com/unrolled/render"
+)
+
+// ServiceGCSafePoints contains the GC safe point and the safe points of all
+// services which hold it back.
+type ServiceGCSafePoints struct {
+	GCSafePoint         uint64                   `json:"gc_safe_point"`
+	ServiceGCSafePoints []*core.ServiceSafePoint `json:"service_gc_safe_points"`
+}
+
+// ServiceGCSafePointRequest is the request to update the safe point of a
+// service. TTL is in seconds, the service is removed if it is not positive.
+type ServiceGCSafePointRequest struct {
+	ServiceID string `json:"service_id"`
+	TTL       int64  `json:"ttl"`
+	SafePoint uint64 `json:"safe_point"`
+}
+
+type gcSafePointHandler struct {
+	svr *server.Server
+	rd  *render.Render
+}
+
+func newGCSafePointHandler(svr *server.Server, rd *render.Render) *gcSafePointHandler {
+	return &gcSafePointHandler{
+		svr: svr,
+		rd:  rd,
+	}
+}
+
+func (h *gcSafePointHandler) List(w http.ResponseWriter, r *http.Request) {
+	if h.svr.GetRaftCluster() == nil {
+		h.rd.JSON(w, http.StatusInternalServerError, server.ErrNotBootstrapped.Error())
+		return
+	}
+	gcSafePoint, err := h.svr.GetStorage().LoadGCSafePoint()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	ssps, err := h.svr.GetServiceGCSafePoints()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, &ServiceGCSafePoints{
+		GCSafePoint:         gcSafePoint,
+		ServiceGCSafePoints: ssps,
+	})
+}
+
+// Update responds with the minimal safe point of all services after updating.
+func (h *gcSafePointHandler) Update(w http.ResponseWriter, r *http.Request) {
+	if h.svr.GetRaftCluster() == nil {
+		h.rd.JSON(w, http.StatusInternalServerError, server.ErrNotBootstrapped.Error())
+		return
+	}
+	var req ServiceGCSafePointRequest
+	if err := readJSONRespondError(h.rd, w, r.Body, &req); err != nil {
+		return
+	}
+	min, err := h.svr.UpdateServiceGCSafePoint(req.ServiceID, req.TTL, req.SafePoint)
+	if err != nil {
+		errorResp(h.rd, w, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, min)
+}
+
+func (h *gcSafePointHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if h.svr.GetRaftCluster() == nil {
+		h.rd.JSON(w, http.StatusInternalServerError, server.ErrNotBootstrapped.Error())
+		return
+	}
+	serviceID := mux.Vars(r)["service_id"]
+	if _, err := h.svr.UpdateServiceGCSafePoint(serviceID, 0, 0); err != nil {
+		errorResp(h.rd, w, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, nil)
+}
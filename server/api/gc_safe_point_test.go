@@ -0,0 +1,108 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/pingcap/pd/server"
+)
+
+var _ = Suite(&testGCSafePointSuite{})
+
+type testGCSafePointSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testGCSafePointSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1/gc/safepoint", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+}
+
+func (s *testGCSafePointSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testGCSafePointSuite) updateServiceSafePoint(c *C, serviceID string, safePoint uint64) {
+	req := &ServiceGCSafePointRequest{ServiceID: serviceID, TTL: 100, SafePoint: safePoint}
+	data, err := json.Marshal(req)
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(s.urlPrefix+"/service", data), IsNil)
+}
+
+func (s *testGCSafePointSuite) TestServiceGCSafePoint(c *C) {
+	s.updateServiceSafePoint(c, "backup", 10)
+	s.updateServiceSafePoint(c, "cdc", 20)
+
+	list := &ServiceGCSafePoints{}
+	c.Assert(readJSONWithURL(s.urlPrefix, list), IsNil)
+	c.Assert(list.ServiceGCSafePoints, HasLen, 2)
+
+	// GC is held back by the minimal service safe point.
+	grpcPDClient := mustNewGrpcClient(c, s.svr.GetAddr())
+	resp, err := grpcPDClient.UpdateGCSafePoint(context.Background(), &pdpb.UpdateGCSafePointRequest{
+		Header:    newRequestHeader(s.svr.ClusterID()),
+		SafePoint: 30,
+	})
+	c.Assert(err, IsNil)
+	c.Assert(resp.GetNewSafePoint(), Equals, uint64(10))
+
+	// A service can not go back beyond the GC safe point, and the service id
+	// must be valid.
+	for _, req := range []*ServiceGCSafePointRequest{
+		{ServiceID: "br", TTL: 100, SafePoint: 5},
+		{ServiceID: "", TTL: 100, SafePoint: 40},
+		{ServiceID: "a/b", TTL: 100, SafePoint: 40},
+	} {
+		data, err := json.Marshal(req)
+		c.Assert(err, IsNil)
+		res, err := server.DialClient.Post(s.urlPrefix+"/service", "application/json", bytes.NewBuffer(data))
+		c.Assert(err, IsNil)
+		res.Body.Close()
+		c.Assert(res.StatusCode, Equals, http.StatusBadRequest)
+	}
+
+	c.Assert(doDelete(s.urlPrefix+"/service/backup"), IsNil)
+	resp, err = grpcPDClient.UpdateGCSafePoint(context.Background(), &pdpb.UpdateGCSafePointRequest{
+		Header:    newRequestHeader(s.svr.ClusterID()),
+		SafePoint: 30,
+	})
+	c.Assert(err, IsNil)
+	c.Assert(resp.GetNewSafePoint(), Equals, uint64(20))
+	c.Assert(readJSONWithURL(s.urlPrefix, list), IsNil)
+	c.Assert(list.GCSafePoint, Equals, uint64(20))
+	c.Assert(list.ServiceGCSafePoints, HasLen, 1)
+
+	// The expiration of a large ttl does not overflow.
+	data, err := json.Marshal(&ServiceGCSafePointRequest{ServiceID: "cdc", TTL: math.MaxInt64, SafePoint: 20})
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(s.urlPrefix+"/service", data), IsNil)
+	c.Assert(readJSONWithURL(s.urlPrefix, list), IsNil)
+	c.Assert(list.ServiceGCSafePoints, HasLen, 1)
+	c.Assert(list.ServiceGCSafePoints[0].ExpiredAt, Equals, int64(math.MaxInt64))
+}
@@ -41,13 +41,45 @@ func newMemberHandler(svr *server.Server, rd *render.Render) *memberHandler {
 	}
 }
 
+// memberInfo is a PD member with its labels.
+type memberInfo struct {
+	*pdpb.Member
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type membersInfo struct {
+	Header       *pdpb.ResponseHeader `json:"header,omitempty"`
+	Members      []*memberInfo        `json:"members,omitempty"`
+	Leader       *pdpb.Member         `json:"leader,omitempty"`
+	EtcdLeader   *pdpb.Member         `json:"etcd_leader,omitempty"`
+	LeaderPolicy *server.LeaderPolicy `json:"leader_policy,omitempty"`
+}
+
 func (h *memberHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
 	members, err := h.getMembers()
 	if err != nil {
 		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	h.rd.JSON(w, http.StatusOK, members)
+	info := &membersInfo{
+		Header:     members.GetHeader(),
+		Leader:     members.GetLeader(),
+		EtcdLeader: members.GetEtcdLeader(),
+	}
+	for _, m := range members.GetMembers() {
+		labels, err := h.svr.GetMemberLabels(m.GetMemberId())
+		if err != nil {
+			log.Error("failed to load member labels", zap.Uint64("member", m.GetMemberId()), zap.Error(err))
+		}
+		info.Members = append(info.Members, &memberInfo{Member: m, Labels: labels})
+	}
+	policy, err := h.svr.GetLeaderPolicy()
+	if err != nil {
+		log.Error("failed to load leader policy", zap.Error(err))
+	} else if !policy.IsEmpty() {
+		info.LeaderPolicy = policy
+	}
+	h.rd.JSON(w, http.StatusOK, info)
 }
 
 func (h *memberHandler) getMembers() (*pdpb.GetMembersResponse, error) {
@@ -100,6 +132,11 @@ func (h *memberHandler) DeleteByName(w http.ResponseWriter, r *http.Request) {
 		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	err = h.svr.DeleteMemberLabels(id)
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
 
 	// Remove member by id
 	_, err = etcdutil.RemoveEtcdMember(client, id)
@@ -124,6 +161,11 @@ func (h *memberHandler) DeleteByID(w http.ResponseWriter, r *http.Request) {
 		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	err = h.svr.DeleteMemberLabels(id)
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
 
 	client := h.svr.GetClient()
 	_, err = etcdutil.RemoveEtcdMember(client, id)
@@ -176,6 +218,70 @@ func (h *memberHandler) SetMemberPropertyByName(w http.ResponseWriter, r *http.R
 	h.rd.JSON(w, http.StatusOK, "success")
 }
 
+// ListMembersStatus lists the raft status of the members, including the apply
+// lag of each member.
+func (h *memberHandler) ListMembersStatus(w http.ResponseWriter, r *http.Request) {
+	members, err := h.svr.GetMembersStatus()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, members)
+}
+
+type replaceMemberInput struct {
+	Name     string   `json:"name"`
+	PeerURLs []string `json:"peer-urls"`
+}
+
+// ReplaceMember replaces an unhealthy member with a new member of the peer
+// URLs.
+func (h *memberHandler) ReplaceMember(w http.ResponseWriter, r *http.Request) {
+	var input replaceMemberInput
+	if err := readJSONRespondError(h.rd, w, r.Body, &input); err != nil {
+		return
+	}
+	member, err := h.svr.ReplaceMember(input.Name, input.PeerURLs)
+	if err != nil {
+		errorResp(h.rd, w, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, member)
+}
+
+// GetLeaderPolicy gets the leader policy.
+func (h *memberHandler) GetLeaderPolicy(w http.ResponseWriter, r *http.Request) {
+	policy, err := h.svr.GetLeaderPolicy()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, policy)
+}
+
+// SetLeaderPolicy sets the leader policy, the leader is transferred to a
+// member which matches the policy better.
+func (h *memberHandler) SetLeaderPolicy(w http.ResponseWriter, r *http.Request) {
+	var policy server.LeaderPolicy
+	if err := readJSONRespondError(h.rd, w, r.Body, &policy); err != nil {
+		return
+	}
+	if err := h.svr.SetLeaderPolicy(&policy); err != nil {
+		errorResp(h.rd, w, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "success")
+}
+
+// DeleteLeaderPolicy removes the leader policy.
+func (h *memberHandler) DeleteLeaderPolicy(w http.ResponseWriter, r *http.Request) {
+	if err := h.svr.SetLeaderPolicy(&server.LeaderPolicy{}); err != nil {
+		errorResp(h.rd, w, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "success")
+}
+
 type leaderHandler struct {
 	svr *server.Server
 	rd  *render.Render
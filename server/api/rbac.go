@@ -0,0 +1,143 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"crypto/x509"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	log "github.com/pingcap/log"
+	"github.com/pingcap/pd/server"
+	"go.uber.org/zap"
+)
+
+// operatorRoutes are the mutating routes which an operator can call, to tune
+// the scheduling. A GET route requires the read-only role, and other mutating
+// routes require the admin role.
+var operatorRoutes = map[string][]string{
+	"/api/v1/operators":             {"POST"},
+	"/api/v1/operators/{region_id}": {"DELETE"},
+	"/api/v1/schedulers":            {"POST"},
+	"/api/v1/schedulers/{name}":     {"DELETE"},
+	"/api/v1/config/schedule":       {"POST"},
+	"/api/v1/store/{id}/label":      {"POST"},
+	"/api/v1/store/{id}/weight":     {"POST"},
+}
+
+// authorizer checks the role of the client by the identity of its TLS client
+// certificate. It does nothing if no role is bound.
+type authorizer struct {
+	s      *server.Server
+	prefix string
+}
+
+func newAuthorizer(s *server.Server, prefix string) *authorizer {
+	return &authorizer{s: s, prefix: prefix}
+}
+
+// middleware checks the calls of the routes.
+func (a *authorizer) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.authorize(w, r, mux.CurrentRoute(r)) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// authorize returns true if the client can call the route, otherwise it
+// responds 403.
+func (a *authorizer) authorize(w http.ResponseWriter, r *http.Request, route *mux.Route) bool {
+	cfg := a.s.GetSecurityConfig()
+	if len(cfg.RoleBindings) == 0 {
+		return true
+	}
+	var template string
+	if route != nil {
+		template, _ = route.GetPathTemplate()
+	}
+	template = strings.TrimPrefix(template, a.prefix)
+	required := requiredRole(r.Method, template)
+	c := getCaller(a.s, r)
+	role := clientRole(cfg, c.identities)
+	if server.RoleAllows(role, required) {
+		return true
+	}
+	if role == "" {
+		role = "none"
+	}
+	deniedCounter.WithLabelValues(r.Method, template, role).Inc()
+	log.Warn("deny API call",
+		zap.String("method", r.Method),
+		zap.String("path", r.URL.Path),
+		zap.String("remote-addr", r.RemoteAddr),
+		zap.String("caller-addr", c.addr),
+		zap.Strings("identities", c.identities),
+		zap.String("role", role),
+		zap.String("required-role", required))
+	http.Error(w, "permission denied, "+required+" role is required", http.StatusForbidden)
+	return false
+}
+
+func requiredRole(method, route string) string {
+	if method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions {
+		return server.RoleReadOnly
+	}
+	for _, m := range operatorRoutes[route] {
+		if m == method {
+			return server.RoleOperator
+		}
+	}
+	return server.RoleAdmin
+}
+
+// clientRole returns the highest role bound to the identities of the client.
+func clientRole(cfg *server.SecurityConfig, identities []string) string {
+	role := ""
+	for _, identity := range identities {
+		if bound, ok := cfg.RoleBindings[identity]; ok && (role == "" || server.RoleAllows(bound, role)) {
+			role = bound
+		}
+	}
+	if role == "" {
+		return cfg.DefaultRole
+	}
+	return role
+}
+
+// clientIdentities returns the common name and the SANs of the verified
+// client certificate.
+func clientIdentities(r *http.Request) []string {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+		return nil
+	}
+	return certIdentities(r.TLS.VerifiedChains[0][0])
+}
+
+func certIdentities(cert *x509.Certificate) []string {
+	var identities []string
+	if cert.Subject.CommonName != "" {
+		identities = append(identities, cert.Subject.CommonName)
+	}
+	identities = append(identities, cert.DNSNames...)
+	identities = append(identities, cert.EmailAddresses...)
+	for _, ip := range cert.IPAddresses {
+		identities = append(identities, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		identities = append(identities, uri.String())
+	}
+	return identities
+}
@@ -0,0 +1,90 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/pd/server"
+)
+
+var _ = Suite(&testRBACSuite{})
+
+type testRBACSuite struct {
+	svr     *server.Server
+	cleanup cleanUpFunc
+	handler http.Handler
+}
+
+func (s *testRBACSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+	mustBootstrapCluster(c, s.svr)
+	s.handler = NewHandler(s.svr)
+}
+
+func (s *testRBACSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+// call calls the API as the client with the certificate, and returns the
+// status.
+func (s *testRBACSuite) call(cert *x509.Certificate, method, path string) int {
+	req := httptest.NewRequest(method, apiPrefix+"/api/v1"+path, bytes.NewBufferString("{}"))
+	if cert != nil {
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	}
+	w := httptest.NewRecorder()
+	s.handler.ServeHTTP(w, req)
+	return w.Code
+}
+
+func (s *testRBACSuite) TestRoles(c *C) {
+	cfg := s.svr.GetSecurityConfig()
+	defer func() { cfg.RoleBindings, cfg.DefaultRole = nil, "" }()
+
+	dashboard := &x509.Certificate{Subject: pkix.Name{CommonName: "dashboard"}}
+	operator := &x509.Certificate{Subject: pkix.Name{CommonName: "ops"}}
+	admin := &x509.Certificate{Subject: pkix.Name{CommonName: "anyone"}, DNSNames: []string{"ctl.pd"}}
+
+	// Everything is allowed without role bindings.
+	c.Assert(s.call(nil, "DELETE", "/admin/cache/region/1"), Equals, http.StatusOK)
+
+	cfg.RoleBindings = map[string]string{
+		"dashboard": server.RoleReadOnly,
+		"ops":       server.RoleOperator,
+		"ctl.pd":    server.RoleAdmin,
+	}
+	c.Assert(s.call(dashboard, "GET", "/stores"), Equals, http.StatusOK)
+	c.Assert(s.call(dashboard, "DELETE", "/store/1"), Equals, http.StatusForbidden)
+	c.Assert(s.call(dashboard, "POST", "/schedulers"), Equals, http.StatusForbidden)
+	c.Assert(s.call(operator, "POST", "/schedulers"), Not(Equals), http.StatusForbidden)
+	c.Assert(s.call(operator, "DELETE", "/store/1"), Equals, http.StatusForbidden)
+	c.Assert(s.call(operator, "POST", "/leader/transfer/pd2"), Equals, http.StatusForbidden)
+	c.Assert(s.call(operator, "GET", "/stores"), Equals, http.StatusOK)
+	c.Assert(s.call(admin, "DELETE", "/admin/cache/region/1"), Equals, http.StatusOK)
+	c.Assert(s.call(admin, "DELETE", "/store/100"), Not(Equals), http.StatusForbidden)
+
+	// Unbound clients get the default role.
+	c.Assert(s.call(nil, "GET", "/stores"), Equals, http.StatusForbidden)
+	cfg.DefaultRole = server.RoleReadOnly
+	c.Assert(s.call(nil, "GET", "/stores"), Equals, http.StatusOK)
+	c.Assert(s.call(nil, "DELETE", "/admin/cache/region/1"), Equals, http.StatusForbidden)
+}
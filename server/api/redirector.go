@@ -14,12 +14,15 @@
 package api
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strings"
 
+	"github.com/gorilla/mux"
 	log "github.com/pingcap/log"
 	"github.com/pingcap/pd/server"
 	"go.uber.org/zap"
@@ -27,19 +30,52 @@ import (
 
 const (
 	redirectorHeader = "PD-Redirector"
+	// forwardedForHeader is the address of the client whose request is
+	// redirected by a follower.
+	forwardedForHeader = "X-Forwarded-For"
+	// forwardedIdentitiesHeader is the identities of the TLS client
+	// certificate of the client whose request is redirected by a follower.
+	forwardedIdentitiesHeader = "PD-Forwarded-Identities"
+	// servedByHeader is the name of the follower which serves the request
+	// with the regions synced from the leader.
+	servedByHeader = "PD-Served-By"
+	// stalenessHeader is how long the synced regions fall behind the leader.
+	stalenessHeader = "PD-Region-Staleness"
 )
 
+// followerRoutes are the read-only routes which a follower may serve with the
+// regions synced from the leader.
+var followerRoutes = []string{
+	"/api/v1/region/id/{id}",
+	"/api/v1/region/key/{key}",
+	"/api/v1/regions",
+	"/api/v1/regions/key",
+	"/api/v1/regions/store/{id}",
+}
+
+// followerRegionsKey is the context key of the synced regions which a
+// follower serves a call with.
+type followerRegionsKey struct{}
+
 const (
 	errRedirectFailed      = "redirect failed"
 	errRedirectToNotLeader = "redirect to not leader"
 )
 
 type redirector struct {
-	s *server.Server
+	s            *server.Server
+	followerRead *mux.Router
+	// api is used to find the route of a redirected call for the authorizer.
+	api  *mux.Router
+	auth *authorizer
 }
 
-func newRedirector(s *server.Server) *redirector {
-	return &redirector{s: s}
+func newRedirector(s *server.Server, prefix string, api *mux.Router) *redirector {
+	router := mux.NewRouter()
+	for _, path := range followerRoutes {
+		router.Path(prefix + path).Methods("GET")
+	}
+	return &redirector{s: s, followerRead: router, api: api, auth: newAuthorizer(s, prefix)}
 }
 
 func (h *redirector) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
@@ -48,6 +84,17 @@ func (h *redirector) ServeHTTP(w http.ResponseWriter, r *http.Request, next http
 		return
 	}
 
+	if h.followerRead.Match(r, &mux.RouteMatch{}) {
+		if regions, lag := h.s.GetFollowerRegions(); regions != nil {
+			w.Header().Set(servedByHeader, h.s.Name())
+			w.Header().Set(stalenessHeader, lag.String())
+			// The handler serves the regions checked here, as they may be
+			// dropped if the lag grows before the handler runs.
+			next(w, r.WithContext(context.WithValue(r.Context(), followerRegionsKey{}, regions)))
+			return
+		}
+	}
+
 	// Prevent more than one redirection.
 	if name := r.Header.Get(redirectorHeader); len(name) != 0 {
 		log.Error("redirect but server is not leader", zap.String("from", name), zap.String("server", h.s.Name()))
@@ -55,7 +102,14 @@ func (h *redirector) ServeHTTP(w http.ResponseWriter, r *http.Request, next http
 		return
 	}
 
-	r.Header.Set(redirectorHeader, h.s.Name())
+	// The client is authorized before the call is redirected, and the leader
+	// authorizes it again by the forwarded identities.
+	var match mux.RouteMatch
+	if h.api.Match(r, &match) && !h.auth.authorize(w, r, match.Route) {
+		return
+	}
+
+	setForwardedHeaders(h.s, r)
 
 	leader := h.s.GetLeader()
 	if leader == nil {
@@ -98,6 +152,15 @@ func (p *customReverseProxies) ServeHTTP(w http.ResponseWriter, r *http.Request)
 			log.Error(fmt.Sprintf("%+v", err))
 			continue
 		}
+		markRedirected(r)
+
+		if resp.Header.Get("Content-Type") == watchContentType {
+			copyHeader(w.Header(), resp.Header)
+			w.WriteHeader(resp.StatusCode)
+			copyStream(w, resp.Body)
+			resp.Body.Close()
+			return
+		}
 
 		b, err := ioutil.ReadAll(resp.Body)
 		resp.Body.Close()
@@ -119,6 +182,27 @@ func (p *customReverseProxies) ServeHTTP(w http.ResponseWriter, r *http.Request)
 	http.Error(w, errRedirectFailed, http.StatusInternalServerError)
 }
 
+// copyStream copies a streaming response and flushes after every read, so
+// that watchers behind a follower receive events without delay.
+func copyStream(w http.ResponseWriter, body io.Reader) {
+	flusher, _ := w.(http.Flusher)
+	buf := make([]byte, 4096)
+	for {
+		n, err := body.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
 func copyHeader(dst, src http.Header) {
 	for k, vv := range src {
 		values := dst[k]
@@ -15,14 +15,19 @@ package api
 
 import (
 	"container/heap"
+	"encoding/hex"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/pd/server"
 	"github.com/pingcap/pd/server/core"
+	syncer "github.com/pingcap/pd/server/region_syncer"
+	"github.com/pkg/errors"
 	"github.com/unrolled/render"
 )
 
@@ -50,8 +55,8 @@ func NewRegionInfo(r *core.RegionInfo) *RegionInfo {
 	}
 	return &RegionInfo{
 		ID:              r.GetID(),
-		StartKey:        string(core.HexRegionKey(r.GetStartKey())),
-		EndKey:          string(core.HexRegionKey(r.GetEndKey())),
+		StartKey:        hexRegionKey(r.GetStartKey()),
+		EndKey:          hexRegionKey(r.GetEndKey()),
 		RegionEpoch:     r.GetRegionEpoch(),
 		Peers:           r.GetPeers(),
 		Leader:          r.GetLeader(),
@@ -64,12 +69,42 @@ func NewRegionInfo(r *core.RegionInfo) *RegionInfo {
 	}
 }
 
+// hexRegionKey converts a region key to hex format for the output, it returns
+// "?" if redact-region-api is enabled.
+func hexRegionKey(key []byte) string {
+	if core.IsRedactRegionAPI() {
+		return "?"
+	}
+	return strings.ToUpper(hex.EncodeToString(key))
+}
+
 // RegionsInfo contains some regions with the detailed region info.
 type RegionsInfo struct {
 	Count   int           `json:"count"`
 	Regions []*RegionInfo `json:"regions"`
 }
 
+// regionSource provides the regions for the read-only region APIs. It is the
+// RaftCluster on the leader, and the regions synced from the leader on a
+// follower which is allowed to serve them.
+type regionSource interface {
+	GetRegionInfoByID(regionID uint64) *core.RegionInfo
+	GetRegionInfoByKey(regionKey []byte) *core.RegionInfo
+	ScanRegionsByKey(startKey []byte, limit int) []*core.RegionInfo
+	GetRegions() []*core.RegionInfo
+	GetStoreRegions(storeID uint64) []*core.RegionInfo
+}
+
+func getRegionSource(svr *server.Server, r *http.Request) regionSource {
+	if regions, ok := r.Context().Value(followerRegionsKey{}).(*syncer.SyncedRegions); ok {
+		return regions
+	}
+	if cluster := svr.GetRaftCluster(); cluster != nil {
+		return cluster
+	}
+	return nil
+}
+
 type regionHandler struct {
 	svr *server.Server
 	rd  *render.Render
@@ -83,7 +118,7 @@ func newRegionHandler(svr *server.Server, rd *render.Render) *regionHandler {
 }
 
 func (h *regionHandler) GetRegionByID(w http.ResponseWriter, r *http.Request) {
-	cluster := h.svr.GetRaftCluster()
+	cluster := getRegionSource(h.svr, r)
 	if cluster == nil {
 		h.rd.JSON(w, http.StatusInternalServerError, server.ErrNotBootstrapped.Error())
 		return
@@ -102,7 +137,7 @@ func (h *regionHandler) GetRegionByID(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *regionHandler) GetRegionByKey(w http.ResponseWriter, r *http.Request) {
-	cluster := h.svr.GetRaftCluster()
+	cluster := getRegionSource(h.svr, r)
 	if cluster == nil {
 		h.rd.JSON(w, http.StatusInternalServerError, server.ErrNotBootstrapped.Error())
 		return
@@ -137,7 +172,7 @@ func convertToAPIRegions(regions []*core.RegionInfo) *RegionsInfo {
 }
 
 func (h *regionsHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	cluster := h.svr.GetRaftCluster()
+	cluster := getRegionSource(h.svr, r)
 	if cluster == nil {
 		h.rd.JSON(w, http.StatusInternalServerError, server.ErrNotBootstrapped.Error())
 		return
@@ -148,7 +183,7 @@ func (h *regionsHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *regionsHandler) ScanRegionsByKey(w http.ResponseWriter, r *http.Request) {
-	cluster := h.svr.GetRaftCluster()
+	cluster := getRegionSource(h.svr, r)
 	if cluster == nil {
 		h.rd.JSON(w, http.StatusInternalServerError, server.ErrNotBootstrapped.Error())
 		return
@@ -174,7 +209,7 @@ func (h *regionsHandler) ScanRegionsByKey(w http.ResponseWriter, r *http.Request
 }
 
 func (h *regionsHandler) GetStoreRegions(w http.ResponseWriter, r *http.Request) {
-	cluster := h.svr.GetRaftCluster()
+	cluster := getRegionSource(h.svr, r)
 	if cluster == nil {
 		h.rd.JSON(w, http.StatusInternalServerError, server.ErrNotBootstrapped.Error())
 		return
@@ -246,6 +281,74 @@ func (h *regionsHandler) GetIncorrectNamespaceRegions(w http.ResponseWriter, r *
 	h.rd.JSON(w, http.StatusOK, regionsInfo)
 }
 
+// defaultHealthSamples is the number of sample region IDs in each bucket of
+// the health summary.
+const defaultHealthSamples = 10
+
+// parseHealthSamples parses the number of sample region IDs in each bucket.
+func parseHealthSamples(query url.Values) (int, error) {
+	samples := defaultHealthSamples
+	if samplesStr := query.Get("samples"); samplesStr != "" {
+		var err error
+		samples, err = strconv.Atoi(samplesStr)
+		if err != nil || samples < 0 {
+			return 0, errors.New("samples should be a non-negative number")
+		}
+	}
+	if samples > maxRegionLimit {
+		samples = maxRegionLimit
+	}
+	return samples, nil
+}
+
+func (h *regionsHandler) GetRegionHealth(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	samples, err := parseHealthSamples(query)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	health, err := h.svr.GetHandler().GetRegionHealth([]byte(query.Get("start_key")), []byte(query.Get("end_key")), query.Get("namespace"), samples)
+	if err != nil {
+		errorResp(h.rd, w, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, health)
+}
+
+// parseLabelSelector parses a label selector like "zone=z1,rack=r1".
+func parseLabelSelector(s string) (map[string]string, error) {
+	selector := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return nil, errors.Errorf("invalid label %q, it should be like zone=z1", pair)
+		}
+		selector[kv[0]] = kv[1]
+	}
+	return selector, nil
+}
+
+func (h *regionsHandler) SimulateFailure(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	samples, err := parseHealthSamples(query)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	selector, err := parseLabelSelector(query.Get("label"))
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	impact, err := h.svr.GetHandler().SimulateFailure(selector, samples)
+	if err != nil {
+		errorResp(h.rd, w, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, impact)
+}
+
 func (h *regionsHandler) GetRegionSiblings(w http.ResponseWriter, r *http.Request) {
 	cluster := h.svr.GetRaftCluster()
 	if cluster == nil {
@@ -16,6 +16,7 @@ package api
 import (
 	"fmt"
 	"math/rand"
+	"net/http"
 	"net/url"
 	"sort"
 
@@ -83,6 +84,31 @@ func (s *testRegionSuite) TestRegion(c *C) {
 	err = readJSONWithURL(url, r2)
 	c.Assert(err, IsNil)
 	c.Assert(r2, DeepEquals, NewRegionInfo(r))
+
+	core.SetRedactRegionAPI(true)
+	defer core.SetRedactRegionAPI(false)
+	r3 := &RegionInfo{}
+	err = readJSONWithURL(url, r3)
+	c.Assert(err, IsNil)
+	c.Assert(r3.StartKey, Equals, "?")
+	c.Assert(r3.EndKey, Equals, "?")
+}
+
+func (s *testRegionSuite) TestRedactWatchEvent(c *C) {
+	region := &metapb.Region{Id: 1, StartKey: []byte("a"), EndKey: []byte("b")}
+	e := &server.WatchEvent{Type: server.WatchEventRegionPut, Region: region}
+	c.Assert(redactWatchEvent(e), Equals, e)
+
+	core.SetRedactRegionAPI(true)
+	defer core.SetRedactRegionAPI(false)
+	redacted := redactWatchEvent(e)
+	c.Assert(redacted.Region.GetId(), Equals, uint64(1))
+	c.Assert(redacted.Region.GetStartKey(), DeepEquals, []byte("?"))
+	c.Assert(redacted.Region.GetEndKey(), DeepEquals, []byte("?"))
+	// The shared event is not changed.
+	c.Assert(region.GetStartKey(), DeepEquals, []byte("a"))
+	store := &server.WatchEvent{Type: server.WatchEventStorePut, Store: &metapb.Store{Id: 1}}
+	c.Assert(redactWatchEvent(store), Equals, store)
 }
 
 func (s *testRegionSuite) TestRegionCheck(c *C) {
@@ -107,6 +133,27 @@ func (s *testRegionSuite) TestRegionCheck(c *C) {
 	err = readJSONWithURL(url, r3)
 	c.Assert(err, IsNil)
 	c.Assert(r3, DeepEquals, &RegionsInfo{Count: 1, Regions: []*RegionInfo{NewRegionInfo(r)}})
+
+	url = fmt.Sprintf("%s/regions/health?start_key=a&end_key=b", s.urlPrefix)
+	health := &server.RegionHealth{}
+	err = readJSONWithURL(url, health)
+	c.Assert(err, IsNil)
+	c.Assert(health.RegionCount, Equals, 1)
+	c.Assert(health.Categories["down-peer"], DeepEquals, &server.RegionHealthBucket{Count: 1, RegionIDs: []uint64{2}})
+	c.Assert(health.Categories["pending-peer"].Count, Equals, 1)
+	c.Assert(health.Categories["miss-peer"].Count, Equals, 1)
+
+	res, err := http.Get(fmt.Sprintf("%s/regions/health?namespace=unknown", s.urlPrefix))
+	c.Assert(err, IsNil)
+	res.Body.Close()
+	c.Assert(res.StatusCode, Equals, http.StatusBadRequest)
+
+	for _, label := range []string{"", "zone", "zone=", "zone=z1,rack", "zone=unknown"} {
+		res, err = http.Get(fmt.Sprintf("%s/regions/simulate-failure?label=%s", s.urlPrefix, label))
+		c.Assert(err, IsNil)
+		res.Body.Close()
+		c.Assert(res.StatusCode, Equals, http.StatusBadRequest)
+	}
 }
 
 func (s *testRegionSuite) TestRegions(c *C) {
@@ -30,6 +30,7 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	})
 
 	router := mux.NewRouter().PathPrefix(prefix).Subrouter()
+	router.Use(newAuthorizer(svr, prefix).middleware)
 	handler := svr.GetHandler()
 
 	operatorHandler := newOperatorHandler(handler, rd)
@@ -38,10 +39,12 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	router.HandleFunc("/api/v1/operators/{region_id}", operatorHandler.Get).Methods("GET")
 	router.HandleFunc("/api/v1/operators/{region_id}", operatorHandler.Delete).Methods("DELETE")
 
-	schedulerHandler := newSchedulerHandler(handler, rd)
+	schedulerHandler := newSchedulerHandler(svr, rd)
 	router.HandleFunc("/api/v1/schedulers", schedulerHandler.List).Methods("GET")
 	router.HandleFunc("/api/v1/schedulers", schedulerHandler.Post).Methods("POST")
 	router.HandleFunc("/api/v1/schedulers/{name}", schedulerHandler.Delete).Methods("DELETE")
+	router.HandleFunc("/api/v1/schedulers/{name}/trace", schedulerHandler.GetTrace).Methods("GET")
+	router.HandleFunc("/api/v1/schedulers/{name}/trace", schedulerHandler.SetTrace).Methods("POST")
 
 	router.Handle("/api/v1/cluster", newClusterHandler(svr, rd)).Methods("GET")
 	router.HandleFunc("/api/v1/cluster/status", newClusterHandler(svr, rd).GetClusterStatus).Methods("GET")
@@ -60,6 +63,8 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	router.HandleFunc("/api/v1/config/label-property", confHandler.SetLabelProperty).Methods("POST")
 	router.HandleFunc("/api/v1/config/cluster-version", confHandler.GetClusterVersion).Methods("GET")
 	router.HandleFunc("/api/v1/config/cluster-version", confHandler.SetClusterVersion).Methods("POST")
+	router.HandleFunc("/api/v1/config/history", confHandler.GetHistory).Methods("GET")
+	router.HandleFunc("/api/v1/config/rollback/{version}", confHandler.Rollback).Methods("POST")
 
 	storeHandler := newStoreHandler(svr, rd)
 	router.HandleFunc("/api/v1/store/{id}", storeHandler.Get).Methods("GET")
@@ -67,8 +72,13 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	router.HandleFunc("/api/v1/store/{id}/state", storeHandler.SetState).Methods("POST")
 	router.HandleFunc("/api/v1/store/{id}/label", storeHandler.SetLabels).Methods("POST")
 	router.HandleFunc("/api/v1/store/{id}/weight", storeHandler.SetWeight).Methods("POST")
+	router.HandleFunc("/api/v1/store/{id}/config", storeHandler.GetConfig).Methods("GET")
+	router.HandleFunc("/api/v1/store/{id}/config", storeHandler.SetConfig).Methods("POST")
+	router.HandleFunc("/api/v1/store/{id}/config", storeHandler.DeleteConfig).Methods("DELETE")
+	router.HandleFunc("/api/v1/store/{id}/score", storeHandler.GetScore).Methods("GET")
 	router.Handle("/api/v1/stores", newStoresHandler(svr, rd)).Methods("GET")
 	router.HandleFunc("/api/v1/stores/remove-tombstone", newStoresHandler(svr, rd).RemoveTombStone).Methods("DELETE")
+	router.HandleFunc("/api/v1/stores/scores", newStoresHandler(svr, rd).GetScores).Methods("GET")
 
 	labelsHandler := newLabelsHandler(svr, rd)
 	router.HandleFunc("/api/v1/labels", labelsHandler.Get).Methods("GET")
@@ -97,13 +107,24 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	router.HandleFunc("/api/v1/regions/check/pending-peer", regionsHandler.GetPendingPeerRegions).Methods("GET")
 	router.HandleFunc("/api/v1/regions/check/down-peer", regionsHandler.GetDownPeerRegions).Methods("GET")
 	router.HandleFunc("/api/v1/regions/sibling/{id}", regionsHandler.GetRegionSiblings).Methods("GET")
+	router.HandleFunc("/api/v1/regions/health", regionsHandler.GetRegionHealth).Methods("GET")
+	router.HandleFunc("/api/v1/regions/simulate-failure", regionsHandler.SimulateFailure).Methods("GET")
 	router.HandleFunc("/api/v1/regions/check/incorrect-ns", regionsHandler.GetIncorrectNamespaceRegions).Methods("GET")
 
+	watchHandler := newWatchHandler(svr, rd)
+	router.HandleFunc("/api/v1/watch/stores", watchHandler.WatchStores).Methods("GET")
+	router.HandleFunc("/api/v1/watch/regions", watchHandler.WatchRegions).Methods("GET")
+
 	router.Handle("/api/v1/version", newVersionHandler(rd)).Methods("GET")
 	router.Handle("/api/v1/status", newStatusHandler(rd)).Methods("GET")
 
 	memberHandler := newMemberHandler(svr, rd)
 	router.HandleFunc("/api/v1/members", memberHandler.ListMembers).Methods("GET")
+	router.HandleFunc("/api/v1/members/status", memberHandler.ListMembersStatus).Methods("GET")
+	router.HandleFunc("/api/v1/members/replace", memberHandler.ReplaceMember).Methods("POST")
+	router.HandleFunc("/api/v1/members/leader-policy", memberHandler.GetLeaderPolicy).Methods("GET")
+	router.HandleFunc("/api/v1/members/leader-policy", memberHandler.SetLeaderPolicy).Methods("POST")
+	router.HandleFunc("/api/v1/members/leader-policy", memberHandler.DeleteLeaderPolicy).Methods("DELETE")
 	router.HandleFunc("/api/v1/members/name/{name}", memberHandler.DeleteByName).Methods("DELETE")
 	router.HandleFunc("/api/v1/members/id/{id}", memberHandler.DeleteByID).Methods("DELETE")
 	router.HandleFunc("/api/v1/members/name/{name}", memberHandler.SetMemberPropertyByName).Methods("POST")
@@ -119,12 +140,19 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 
 	statsHandler := newStatsHandler(svr, rd)
 	router.HandleFunc("/api/v1/stats/region", statsHandler.Region).Methods("GET")
+	router.HandleFunc("/api/v1/stats/tables", statsHandler.Table).Methods("GET")
 
 	trendHandler := newTrendHandler(svr, rd)
 	router.HandleFunc("/api/v1/trend", trendHandler.Handle).Methods("GET")
 
+	gcSafePointHandler := newGCSafePointHandler(svr, rd)
+	router.HandleFunc("/api/v1/gc/safepoint", gcSafePointHandler.List).Methods("GET")
+	router.HandleFunc("/api/v1/gc/safepoint/service", gcSafePointHandler.Update).Methods("POST")
+	router.HandleFunc("/api/v1/gc/safepoint/service/{service_id}", gcSafePointHandler.Delete).Methods("DELETE")
+
 	adminHandler := newAdminHandler(svr, rd)
 	router.HandleFunc("/api/v1/admin/cache/region/{id}", adminHandler.HandleDropCacheRegion).Methods("DELETE")
+	router.HandleFunc("/api/v1/admin/audit", adminHandler.GetAuditRecords).Methods("GET")
 
 	logHanler := newlogHandler(svr, rd)
 	router.HandleFunc("/api/v1/admin/log", logHanler.Handle).Methods("POST")
@@ -23,12 +23,14 @@ import (
 
 type schedulerHandler struct {
 	*server.Handler
-	r *render.Render
+	svr *server.Server
+	r   *render.Render
 }
 
-func newSchedulerHandler(handler *server.Handler, r *render.Render) *schedulerHandler {
+func newSchedulerHandler(svr *server.Server, r *render.Render) *schedulerHandler {
 	return &schedulerHandler{
-		Handler: handler,
+		Handler: svr.GetHandler(),
+		svr:     svr,
 		r:       r,
 	}
 }
@@ -159,7 +161,7 @@ func (h *schedulerHandler) Post(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.r.JSON(w, http.StatusOK, nil)
+	h.saveConfigHistory(w, r)
 }
 
 func (h *schedulerHandler) Delete(w http.ResponseWriter, r *http.Request) {
@@ -170,5 +172,40 @@ func (h *schedulerHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.saveConfigHistory(w, r)
+}
+
+// saveConfigHistory records the changed schedulers in the config history.
+func (h *schedulerHandler) saveConfigHistory(w http.ResponseWriter, r *http.Request) {
+	if err := h.svr.SaveConfigHistory(getCaller(h.svr, r).String(), ""); err != nil {
+		h.r.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.r.JSON(w, http.StatusOK, nil)
+}
+
+func (h *schedulerHandler) GetTrace(w http.ResponseWriter, r *http.Request) {
+	trace, err := h.GetSchedulerTrace(mux.Vars(r)["name"])
+	if err != nil {
+		errorResp(h.r, w, err)
+		return
+	}
+	h.r.JSON(w, http.StatusOK, trace)
+}
+
+func (h *schedulerHandler) SetTrace(w http.ResponseWriter, r *http.Request) {
+	var input map[string]interface{}
+	if err := readJSONRespondError(h.r, w, r.Body, &input); err != nil {
+		return
+	}
+	enable, ok := input["enable"].(bool)
+	if !ok {
+		h.r.JSON(w, http.StatusBadRequest, "missing or invalid enable")
+		return
+	}
+	if err := h.SetSchedulerTrace(mux.Vars(r)["name"], enable); err != nil {
+		errorResp(h.r, w, err)
+		return
+	}
 	h.r.JSON(w, http.StatusOK, nil)
 }
@@ -16,6 +16,7 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
@@ -100,3 +101,36 @@ func (s *testScheduleSuite) testAddAndRemoveScheduler(name, createdName string,
 	err = doDelete(deleteURL)
 	c.Assert(err, IsNil)
 }
+
+func (s *testScheduleSuite) TestTrace(c *C) {
+	for _, name := range []string{"balance-leader-scheduler", "shuffle-leader-scheduler"} {
+		body, err := json.Marshal(map[string]interface{}{"name": name})
+		c.Assert(err, IsNil)
+		c.Assert(postJSON(s.urlPrefix, body), IsNil)
+	}
+	defer func() {
+		for _, name := range []string{"balance-leader-scheduler", "shuffle-leader-scheduler"} {
+			c.Assert(doDelete(fmt.Sprintf("%s/%s", s.urlPrefix, name)), IsNil)
+		}
+	}()
+
+	client := newHTTPClient()
+	url := fmt.Sprintf("%s/balance-leader-scheduler/trace", s.urlPrefix)
+	trace := &server.SchedulerTrace{}
+	c.Assert(readJSONWithURL(url, trace), IsNil)
+	c.Assert(trace.Name, Equals, "balance-leader-scheduler")
+	c.Assert(trace.Enabled, IsFalse)
+
+	c.Assert(postJSON(url, []byte(`{"enable": true}`)), IsNil)
+	c.Assert(readJSONWithURL(url, trace), IsNil)
+	c.Assert(trace.Enabled, IsTrue)
+	c.Assert(postJSON(url, []byte(`{"enable": false}`)), IsNil)
+	c.Assert(readJSONWithURL(url, trace), IsNil)
+	c.Assert(trace.Enabled, IsFalse)
+	c.Assert(postJSON(url, []byte(`{"enable": "yes"}`)), NotNil)
+
+	code, _ := requestStatusBody(c, client, "GET", fmt.Sprintf("%s/shuffle-leader-scheduler/trace", s.urlPrefix))
+	c.Assert(code, Equals, http.StatusBadRequest)
+	code, _ = requestStatusBody(c, client, "GET", fmt.Sprintf("%s/unknown-scheduler/trace", s.urlPrefix))
+	c.Assert(code, Equals, http.StatusNotFound)
+}
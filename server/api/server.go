@@ -31,9 +31,11 @@ func NewHandler(svr *server.Server) http.Handler {
 	engine.Use(recovery)
 
 	router := mux.NewRouter()
+	apiRouter := createRouter(apiPrefix, svr)
 	router.PathPrefix(apiPrefix).Handler(negroni.New(
-		newRedirector(svr),
-		negroni.Wrap(createRouter(apiPrefix, svr)),
+		newAuditor(svr, apiRouter),
+		newRedirector(svr, apiPrefix, apiRouter),
+		negroni.Wrap(apiRouter),
 	))
 
 	engine.UseHandler(router)
@@ -15,6 +15,7 @@ package api
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/pingcap/pd/server"
 	"github.com/unrolled/render"
@@ -42,3 +43,40 @@ func (h *statsHandler) Region(w http.ResponseWriter, r *http.Request) {
 	stats := cluster.GetRegionStats([]byte(startKey), []byte(endKey))
 	h.rd.JSON(w, http.StatusOK, stats)
 }
+
+const defaultTableStatsLimit = 100
+
+func (h *statsHandler) Table(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	opt := &server.TableStatsOption{
+		GroupBy: server.TableStatsGroupByTable,
+		SortBy:  "size",
+		Limit:   defaultTableStatsLimit,
+	}
+	if groupBy := query.Get("group_by"); groupBy != "" {
+		opt.GroupBy = groupBy
+	}
+	if sortBy := query.Get("sort_by"); sortBy != "" {
+		opt.SortBy = sortBy
+	}
+	var err error
+	if offset := query.Get("offset"); offset != "" {
+		if opt.Offset, err = strconv.Atoi(offset); err != nil {
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if limit := query.Get("limit"); limit != "" {
+		if opt.Limit, err = strconv.Atoi(limit); err != nil {
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	startKey, endKey := query.Get("start_key"), query.Get("end_key")
+	page, err := h.svr.GetHandler().GetTableStats([]byte(startKey), []byte(endKey), opt)
+	if err != nil {
+		errorResp(h.rd, w, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, page)
+}
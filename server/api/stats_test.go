@@ -23,6 +23,7 @@ import (
 	"github.com/pingcap/pd/pkg/apiutil"
 	"github.com/pingcap/pd/server"
 	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/table"
 )
 
 var _ = Suite(&testStatsSuite{})
@@ -166,3 +167,73 @@ func (s *testStatsSuite) TestRegionStats(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(stats, DeepEquals, stats23)
 }
+
+var _ = Suite(&testTableStatsSuite{})
+
+type testTableStatsSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testTableStatsSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1/stats/tables", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+}
+
+func (s *testTableStatsSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testTableStatsSuite) TestTableStats(c *C) {
+	// Regions:  [, t1) [t1, t1_i1) [t1_i1, t2) [t2, )
+	// Leaders:   1      2          2           1
+	keys := [][]byte{
+		nil,
+		table.EncodeBytes(table.GenerateTableKey(1)),
+		table.EncodeBytes(table.GenerateIndexKey(1, 1)),
+		table.EncodeBytes(table.GenerateTableKey(2)),
+		nil,
+	}
+	for i, leader := range []uint64{1, 2, 2, 1} {
+		id := uint64(i + 2)
+		peer := &metapb.Peer{Id: id * 10, StoreId: leader}
+		region := core.NewRegionInfo(&metapb.Region{
+			Id:          id,
+			StartKey:    keys[i],
+			EndKey:      keys[i+1],
+			Peers:       []*metapb.Peer{peer},
+			RegionEpoch: &metapb.RegionEpoch{ConfVer: 1, Version: 1},
+		}, peer, core.SetApproximateSize(int64(i+1)*10), core.SetApproximateKeys(int64(i+1)*100))
+		mustRegionHeartbeat(c, s.svr, region)
+	}
+
+	page := &server.TableStatsPage{}
+	c.Assert(readJSONWithURL(s.urlPrefix, page), IsNil)
+	c.Assert(page.Total, Equals, 3)
+	c.Assert(page.Tables, DeepEquals, []*server.TableStats{
+		{TableID: 1, RegionCount: 2, StorageSize: 50, StorageKeys: 500, StoreLeaderCount: map[uint64]int{2: 2}},
+		{TableID: 2, RegionCount: 1, StorageSize: 40, StorageKeys: 400, StoreLeaderCount: map[uint64]int{1: 1}},
+		{TableID: 0, RegionCount: 1, StorageSize: 10, StorageKeys: 100, StoreLeaderCount: map[uint64]int{1: 1}},
+	})
+
+	args := fmt.Sprintf("?group_by=index&sort_by=region_count&offset=1&limit=2&start_key=%s&end_key=%s",
+		url.QueryEscape(string(keys[1])), url.QueryEscape(string(keys[3])))
+	page = &server.TableStatsPage{}
+	c.Assert(readJSONWithURL(s.urlPrefix+args, page), IsNil)
+	c.Assert(page.Total, Equals, 2)
+	c.Assert(page.Tables, DeepEquals, []*server.TableStats{
+		{TableID: 1, IndexID: 1, RegionCount: 1, StorageSize: 30, StorageKeys: 300, StoreLeaderCount: map[uint64]int{2: 1}},
+	})
+
+	client := newHTTPClient()
+	for _, args := range []string{"?group_by=column", "?sort_by=name", "?limit=0", "?offset=-1", "?limit=a"} {
+		code, _ := requestStatusBody(c, client, "GET", s.urlPrefix+args)
+		c.Assert(code, Equals, http.StatusBadRequest)
+	}
+}
@@ -14,6 +14,9 @@
 package api
 
 import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -83,7 +86,7 @@ func newStoreInfo(opt *server.ScheduleConfig, store *core.StoreInfo) *StoreInfo
 			LeaderSize:         store.GetLeaderSize(),
 			RegionCount:        store.GetRegionCount(),
 			RegionWeight:       store.GetRegionWeight(),
-			RegionScore:        store.RegionScore(opt.HighSpaceRatio, opt.LowSpaceRatio, 0),
+			RegionScore:        store.RegionScore(store.GetConfig().GetHighSpaceRatio(opt.HighSpaceRatio), store.GetConfig().GetLowSpaceRatio(opt.LowSpaceRatio), 0),
 			RegionSize:         store.GetRegionSize(),
 			SendingSnapCount:   store.GetSendingSnapCount(),
 			ReceivingSnapCount: store.GetReceivingSnapCount(),
@@ -304,6 +307,133 @@ func (h *storeHandler) SetWeight(w http.ResponseWriter, r *http.Request) {
 	h.rd.JSON(w, http.StatusOK, nil)
 }
 
+func (h *storeHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	cluster := h.svr.GetRaftCluster()
+	if cluster == nil {
+		h.rd.JSON(w, http.StatusInternalServerError, server.ErrNotBootstrapped.Error())
+		return
+	}
+
+	vars := mux.Vars(r)
+	storeID, errParse := apiutil.ParseUint64VarsField(vars, "id")
+	if errParse != nil {
+		errorResp(h.rd, w, errcode.NewInvalidInputErr(errParse))
+		return
+	}
+
+	store, err := cluster.GetStore(storeID)
+	if err != nil {
+		errorResp(h.rd, w, err)
+		return
+	}
+	config := store.GetConfig()
+	if config == nil {
+		config = &core.StoreConfig{}
+	}
+	h.rd.JSON(w, http.StatusOK, config)
+}
+
+// SetConfig merges the input into the config overrides of the store. A field
+// with null value removes the override.
+func (h *storeHandler) SetConfig(w http.ResponseWriter, r *http.Request) {
+	cluster := h.svr.GetRaftCluster()
+	if cluster == nil {
+		h.rd.JSON(w, http.StatusInternalServerError, server.ErrNotBootstrapped.Error())
+		return
+	}
+
+	vars := mux.Vars(r)
+	storeID, errParse := apiutil.ParseUint64VarsField(vars, "id")
+	if errParse != nil {
+		errorResp(h.rd, w, errcode.NewInvalidInputErr(errParse))
+		return
+	}
+
+	defer r.Body.Close()
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		errorResp(h.rd, w, errors.WithStack(err))
+		return
+	}
+	// The input is decoded into a new config, so that the config in use is
+	// not changed before it is validated.
+	input := &core.StoreConfig{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(input); err != nil {
+		errorResp(h.rd, w, errcode.NewInvalidInputErr(err))
+		return
+	}
+	// A field with null value is decoded as nil too, the keys tell it from a
+	// field which is not in the input.
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(body, &fields); err != nil {
+		errorResp(h.rd, w, errcode.NewInvalidInputErr(err))
+		return
+	}
+	isSet := func(field string) bool {
+		_, ok := fields[field]
+		return ok
+	}
+
+	config, err := cluster.UpdateStoreConfig(storeID, func(config *core.StoreConfig) {
+		if isSet("max-snapshot-count") {
+			config.MaxSnapshotCount = input.MaxSnapshotCount
+		}
+		if isSet("max-pending-peer-count") {
+			config.MaxPendingPeerCount = input.MaxPendingPeerCount
+		}
+		if isSet("low-space-ratio") {
+			config.LowSpaceRatio = input.LowSpaceRatio
+		}
+		if isSet("high-space-ratio") {
+			config.HighSpaceRatio = input.HighSpaceRatio
+		}
+	})
+	if err != nil {
+		errorResp(h.rd, w, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, config)
+}
+
+func (h *storeHandler) DeleteConfig(w http.ResponseWriter, r *http.Request) {
+	cluster := h.svr.GetRaftCluster()
+	if cluster == nil {
+		h.rd.JSON(w, http.StatusInternalServerError, server.ErrNotBootstrapped.Error())
+		return
+	}
+
+	vars := mux.Vars(r)
+	storeID, errParse := apiutil.ParseUint64VarsField(vars, "id")
+	if errParse != nil {
+		errorResp(h.rd, w, errcode.NewInvalidInputErr(errParse))
+		return
+	}
+
+	if err := cluster.SetStoreConfig(storeID, nil); err != nil {
+		errorResp(h.rd, w, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, nil)
+}
+
+func (h *storeHandler) GetScore(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	storeID, errParse := apiutil.ParseUint64VarsField(vars, "id")
+	if errParse != nil {
+		errorResp(h.rd, w, errcode.NewInvalidInputErr(errParse))
+		return
+	}
+
+	score, err := h.svr.GetHandler().GetStoreScore(storeID)
+	if err != nil {
+		errorResp(h.rd, w, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, score)
+}
+
 type storesHandler struct {
 	svr *server.Server
 	rd  *render.Render
@@ -332,6 +462,15 @@ func (h *storesHandler) RemoveTombStone(w http.ResponseWriter, r *http.Request)
 	h.rd.JSON(w, http.StatusOK, nil)
 }
 
+func (h *storesHandler) GetScores(w http.ResponseWriter, r *http.Request) {
+	scores, err := h.svr.GetHandler().GetStoreScores()
+	if err != nil {
+		errorResp(h.rd, w, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, scores)
+}
+
 func (h *storesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	cluster := h.svr.GetRaftCluster()
 	if cluster == nil {
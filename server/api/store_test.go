@@ -19,6 +19,8 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
 	"time"
 
 	. "github.com/pingcap/check"
@@ -232,6 +234,86 @@ func (s *testStoreSuite) TestStoreSetState(c *C) {
 	c.Assert(info.Store.State, Equals, metapb.StoreState_Up)
 }
 
+func (s *testStoreSuite) TestStoreConfig(c *C) {
+	url := fmt.Sprintf("%s/store/1/config", s.urlPrefix)
+	config := &core.StoreConfig{}
+	c.Assert(readJSONWithURL(url, config), IsNil)
+	c.Assert(config.IsEmpty(), IsTrue)
+
+	c.Assert(postJSON(url, []byte(`{"low-space-ratio": 0.95, "max-snapshot-count": 5}`)), IsNil)
+	c.Assert(postJSON(url, []byte(`{"max-snapshot-count": null}`)), IsNil)
+	c.Assert(readJSONWithURL(url, config), IsNil)
+	c.Assert(*config.LowSpaceRatio, Equals, 0.95)
+	c.Assert(config.MaxSnapshotCount, IsNil)
+	store, err := s.svr.GetRaftCluster().GetStore(1)
+	c.Assert(err, IsNil)
+	c.Assert(store.GetConfig().GetLowSpaceRatio(0.8), Equals, 0.95)
+
+	// Unknown fields and invalid ratios are rejected.
+	c.Assert(postJSON(url, []byte(`{"low-space-ratio-x": 0.9}`)), NotNil)
+	c.Assert(postJSON(url, []byte(`{"high-space-ratio": 0.96}`)), NotNil)
+	// The rejected input does not change the config in use.
+	c.Assert(postJSON(url, []byte(`{"low-space-ratio": 0.5, "high-space-ratio": 0.6}`)), NotNil)
+	c.Assert(readJSONWithURL(url, config), IsNil)
+	c.Assert(*config.LowSpaceRatio, Equals, 0.95)
+	c.Assert(config.HighSpaceRatio, IsNil)
+	store, err = s.svr.GetRaftCluster().GetStore(1)
+	c.Assert(err, IsNil)
+	c.Assert(store.GetConfig().GetLowSpaceRatio(0.8), Equals, 0.95)
+	c.Assert(postJSON(fmt.Sprintf("%s/store/100/config", s.urlPrefix), []byte(`{"low-space-ratio": 0.9}`)), NotNil)
+
+	c.Assert(doDelete(url), IsNil)
+	config = &core.StoreConfig{}
+	c.Assert(readJSONWithURL(url, config), IsNil)
+	c.Assert(config.IsEmpty(), IsTrue)
+	store, err = s.svr.GetRaftCluster().GetStore(1)
+	c.Assert(err, IsNil)
+	c.Assert(store.GetConfig(), IsNil)
+
+	// Concurrent updates of different fields are all kept.
+	var wg sync.WaitGroup
+	for _, input := range []string{`{"max-snapshot-count": 5}`, `{"max-pending-peer-count": 10}`} {
+		wg.Add(1)
+		go func(input string) {
+			defer wg.Done()
+			c.Assert(postJSON(url, []byte(input)), IsNil)
+		}(input)
+	}
+	wg.Wait()
+	c.Assert(readJSONWithURL(url, config), IsNil)
+	c.Assert(*config.MaxSnapshotCount, Equals, uint64(5))
+	c.Assert(*config.MaxPendingPeerCount, Equals, uint64(10))
+	c.Assert(doDelete(url), IsNil)
+}
+
+func (s *testStoreSuite) TestStoreScore(c *C) {
+	url := fmt.Sprintf("%s/stores/scores", s.urlPrefix)
+	var scores []*server.StoreScore
+	c.Assert(readJSONWithURL(url, &scores), IsNil)
+	c.Assert(scores, HasLen, len(s.stores))
+	for i, score := range scores {
+		c.Assert(score.StoreID, Equals, s.stores[i].GetId())
+	}
+
+	url = fmt.Sprintf("%s/store/6/score", s.urlPrefix)
+	score := &server.StoreScore{}
+	c.Assert(readJSONWithURL(url, score), IsNil)
+	c.Assert(score.StateName, Equals, metapb.StoreState_Offline.String())
+	var offline bool
+	for _, f := range score.Region.TargetFilters {
+		if f.Filter == "state-filter" {
+			offline = strings.HasPrefix(f.Reason, "the store is offline")
+		}
+	}
+	c.Assert(offline, IsTrue)
+	c.Assert(score.Leader.TargetFilters, Not(HasLen), 0)
+	c.Assert(score.Leader.TargetFilters[0].Filter, Equals, "store-state-filter")
+	c.Assert(strings.HasPrefix(score.Leader.TargetFilters[0].Reason, "the store is offline"), IsTrue)
+
+	status, _ := requestStatusBody(c, &http.Client{}, http.MethodGet, fmt.Sprintf("%s/store/100/score", s.urlPrefix))
+	c.Assert(status, Equals, http.StatusNotFound)
+}
+
 func (s *testStoreSuite) TestUrlStoreFilter(c *C) {
 	table := []struct {
 		u    string
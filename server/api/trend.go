@@ -28,6 +28,9 @@ import (
 type Trend struct {
 	Stores  []trendStore  `json:"stores"`
 	History *trendHistory `json:"history"`
+	// Metrics is the metrics history of the stores in [from, to], one sample
+	// in each step. It is returned only if step is specified.
+	Metrics []*core.MetricsSample `json:"metrics,omitempty"`
 }
 
 type trendStore struct {
@@ -85,6 +88,28 @@ func (h *trendHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		}
 		from = time.Unix(fromInt, 0)
 	}
+	to := time.Now()
+	if toStr := r.URL.Query()["to"]; len(toStr) > 0 {
+		toInt, err := strconv.ParseInt(toStr[0], 10, 64)
+		if err != nil {
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		to = time.Unix(toInt, 0)
+	}
+	var step time.Duration
+	if stepStr := r.URL.Query()["step"]; len(stepStr) > 0 {
+		stepInt, err := strconv.ParseInt(stepStr[0], 10, 64)
+		if err != nil {
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if stepInt <= 0 {
+			h.rd.JSON(w, http.StatusBadRequest, "step should be positive")
+			return
+		}
+		step = time.Duration(stepInt) * time.Second
+	}
 
 	stores, err := h.getTrendStores()
 	if err != nil {
@@ -92,7 +117,7 @@ func (h *trendHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	history, err := h.getTrendHistory(from)
+	history, err := h.getTrendHistory(from, to)
 	if err != nil {
 		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
 		return
@@ -102,6 +127,13 @@ func (h *trendHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		Stores:  stores,
 		History: history,
 	}
+	if step > 0 {
+		trend.Metrics, err = h.GetMetricsHistory(from, to, step)
+		if err != nil {
+			h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
 	h.rd.JSON(w, http.StatusOK, trend)
 }
 
@@ -153,7 +185,7 @@ func (h *trendHandler) getStoreFlow(stats core.StoreHotRegionsStat, storeID uint
 	return
 }
 
-func (h *trendHandler) getTrendHistory(start time.Time) (*trendHistory, error) {
+func (h *trendHandler) getTrendHistory(start, end time.Time) (*trendHistory, error) {
 	operatorHistory, err := h.GetHistory(start)
 	if err != nil {
 		return nil, err
@@ -161,6 +193,9 @@ func (h *trendHandler) getTrendHistory(start time.Time) (*trendHistory, error) {
 	// Use a tmp map to merge same histories together.
 	historyMap := make(map[trendHistoryEntry]int)
 	for _, entry := range operatorHistory {
+		if entry.FinishTime.After(end) {
+			continue
+		}
 		historyMap[trendHistoryEntry{
 			From: entry.From,
 			To:   entry.To,
@@ -174,7 +209,7 @@ func (h *trendHandler) getTrendHistory(start time.Time) (*trendHistory, error) {
 	}
 	return &trendHistory{
 		StartTime: start.Unix(),
-		EndTime:   time.Now().Unix(),
+		EndTime:   end.Unix(),
 		Entries:   history,
 	}, nil
 }
@@ -15,6 +15,8 @@ package api
 
 import (
 	"fmt"
+	"net/http"
+	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
@@ -94,6 +96,27 @@ func (s *testTrendSuite) TestTrend(c *C) {
 	for _, history := range trend.History.Entries {
 		c.Assert(history.Count, Equals, expectHistory[trendHistoryEntry{From: history.From, To: history.To, Kind: history.Kind}])
 	}
+	c.Assert(trend.Metrics, IsNil)
+
+	// Check metrics history.
+	history := core.NewMetricsHistory(svr.GetStorage().GetRegionKV())
+	for _, t := range []int64{60, 90, 120, 180} {
+		sample := &core.MetricsSample{Time: t, Stores: []*core.StoreMetrics{{StoreID: 1, RegionCount: int(t)}}}
+		c.Assert(history.Save(sample, time.Hour), IsNil)
+	}
+	trend = Trend{}
+	err = readJSONWithURL(fmt.Sprintf("%s%s/api/v1/trend?from=60&to=150&step=60", svr.GetAddr(), apiPrefix), &trend)
+	c.Assert(err, IsNil)
+	c.Assert(trend.History.EndTime, Equals, int64(150))
+	c.Assert(trend.History.Entries, HasLen, 0)
+	c.Assert(trend.Metrics, HasLen, 2)
+	c.Assert(trend.Metrics[0].Time, Equals, int64(60))
+	c.Assert(trend.Metrics[1].Time, Equals, int64(120))
+	c.Assert(trend.Metrics[1].Stores[0].RegionCount, Equals, 120)
+
+	client := newHTTPClient()
+	status, _ := requestStatusBody(c, client, http.MethodGet, fmt.Sprintf("%s%s/api/v1/trend?step=0", svr.GetAddr(), apiPrefix))
+	c.Assert(status, Equals, http.StatusBadRequest)
 }
 
 func (s *testTrendSuite) newRegionInfo(id uint64, startKey, endKey string, confVer, ver uint64, voters []uint64, learners []uint64, leaderStore uint64) *core.RegionInfo {
@@ -0,0 +1,135 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	log "github.com/pingcap/log"
+	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/core"
+	"github.com/unrolled/render"
+	"go.uber.org/zap"
+)
+
+// watchContentType is the content type of watch streams. Every line of the
+// body is a JSON encoded server.WatchEvent, and the first one is always a
+// start event.
+const watchContentType = "application/x-ndjson"
+
+// watchCheckInterval is the interval to check whether the stream should be
+// closed because this server is no longer serving the cluster.
+var watchCheckInterval = 5 * time.Second
+
+type watchHandler struct {
+	svr *server.Server
+	rd  *render.Render
+}
+
+func newWatchHandler(svr *server.Server, rd *render.Render) *watchHandler {
+	return &watchHandler{
+		svr: svr,
+		rd:  rd,
+	}
+}
+
+func (h *watchHandler) WatchStores(w http.ResponseWriter, r *http.Request) {
+	h.watch(w, r, (*server.RaftCluster).GetStoreNotifier)
+}
+
+func (h *watchHandler) WatchRegions(w http.ResponseWriter, r *http.Request) {
+	h.watch(w, r, (*server.RaftCluster).GetRegionNotifier)
+}
+
+func (h *watchHandler) watch(w http.ResponseWriter, r *http.Request, getNotifier func(*server.RaftCluster) *server.ChangeNotifier) {
+	cluster := h.svr.GetRaftCluster()
+	if cluster == nil {
+		h.rd.JSON(w, http.StatusInternalServerError, server.ErrNotBootstrapped.Error())
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.rd.JSON(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+	notifier := getNotifier(cluster)
+
+	revision := notifier.Revision()
+	if value := r.URL.Query().Get("revision"); value != "" {
+		var err error
+		revision, err = strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", watchContentType)
+	w.WriteHeader(http.StatusOK)
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(&server.WatchEvent{Revision: revision, Type: server.WatchEventStart}); err != nil {
+		log.Info("watch stream is closed", zap.String("remote", r.RemoteAddr), zap.Error(err))
+		return
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(watchCheckInterval)
+	defer ticker.Stop()
+	for {
+		events, changed := notifier.Since(revision)
+		for _, e := range events {
+			if err := encoder.Encode(redactWatchEvent(e)); err != nil {
+				log.Info("watch stream is closed", zap.String("remote", r.RemoteAddr), zap.Error(err))
+				return
+			}
+			revision = e.Revision
+		}
+		if len(events) > 0 {
+			flusher.Flush()
+		}
+
+		select {
+		case <-changed:
+		case <-ticker.C:
+			// The cluster is recreated when the leader changes, the watcher
+			// has to reconnect to the new leader.
+			if !h.svr.IsLeader() {
+				return
+			}
+			if c := h.svr.GetRaftCluster(); c == nil || getNotifier(c) != notifier {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// redactWatchEvent replaces the keys of the region in the event with "?" if
+// redact-region-api is enabled. The event is shared by all watchers, so the
+// region is copied.
+func redactWatchEvent(e *server.WatchEvent) *server.WatchEvent {
+	if e.Region == nil || !core.IsRedactRegionAPI() {
+		return e
+	}
+	redacted := *e
+	redacted.Region = proto.Clone(e.Region).(*metapb.Region)
+	redacted.Region.StartKey, redacted.Region.EndKey = []byte("?"), []byte("?")
+	return &redacted
+}
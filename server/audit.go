@@ -0,0 +1,192 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/pingcap/log"
+	"github.com/pkg/errors"
+	"go.etcd.io/etcd/clientv3"
+	"go.uber.org/zap"
+	lumberjack "gopkg.in/natefinch/lumberjack.v2"
+)
+
+// auditSaveRetry is the number of retries when concurrent records take the
+// same slot of the ring.
+const auditSaveRetry = 5
+
+// AuditRecord is the record of a mutating API call.
+type AuditRecord struct {
+	// Seq is the sequence number of the record in the ring in etcd. It is
+	// not in the audit file, which is written before the ring.
+	Seq  uint64    `json:"seq,omitempty"`
+	Time time.Time `json:"time"`
+	// Member is the name of the PD server which serves the call.
+	Member     string `json:"member"`
+	RemoteAddr string `json:"remote_addr"`
+	// ForwardedFor is the address of the caller if the call is redirected by
+	// a follower, and RedirectedBy is the name of the follower. They are only
+	// recorded if the call does come from the follower.
+	ForwardedFor string `json:"forwarded_for,omitempty"`
+	RedirectedBy string `json:"redirected_by,omitempty"`
+	// ClientCN is the common name of the TLS client certificate of the caller,
+	// which is forwarded by the follower for a redirected call.
+	ClientCN string `json:"client_cn,omitempty"`
+	Method   string `json:"method"`
+	Route    string `json:"route"`
+	Path     string `json:"path"`
+	// BodyDigest is the hex encoded SHA-256 of the request body.
+	BodyDigest string `json:"body_digest"`
+	Status     int    `json:"status"`
+}
+
+// auditFile writes audit records to a rotated file as JSON lines.
+type auditFile struct {
+	sync.Mutex
+	w io.WriteCloser
+}
+
+func newAuditFile(cfg *AuditConfig) *auditFile {
+	if cfg.File.Filename == "" {
+		return nil
+	}
+	return &auditFile{
+		w: &lumberjack.Logger{
+			Filename:   cfg.File.Filename,
+			MaxSize:    cfg.File.MaxSize,
+			MaxBackups: cfg.File.MaxBackups,
+			MaxAge:     cfg.File.MaxDays,
+			LocalTime:  true,
+		},
+	}
+}
+
+func (f *auditFile) write(record *AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	f.Lock()
+	defer f.Unlock()
+	_, err = f.w.Write(append(data, '\n'))
+	return errors.WithStack(err)
+}
+
+func (f *auditFile) close() error {
+	f.Lock()
+	defer f.Unlock()
+	return f.w.Close()
+}
+
+func (s *Server) getAuditPath() string {
+	return path.Join(s.rootPath, "audit")
+}
+
+func (s *Server) getAuditNextPath() string {
+	return path.Join(s.getAuditPath(), "next")
+}
+
+func (s *Server) getAuditSlotPath(slot uint64) string {
+	return path.Join(s.getAuditPath(), "ring", fmt.Sprintf("%020d", slot))
+}
+
+// SaveAuditRecord writes the record to the audit file, and to the ring in etcd
+// which keeps the latest records. The file is written first, so that no record
+// is lost when etcd is unhealthy, while the ring is best-effort if there is an
+// audit file.
+func (s *Server) SaveAuditRecord(record *AuditRecord) error {
+	record.Member = s.Name()
+	if s.auditFile == nil {
+		return s.saveAuditRing(record)
+	}
+	if err := s.auditFile.write(record); err != nil {
+		return err
+	}
+	if err := s.saveAuditRing(record); err != nil {
+		log.Warn("failed to save audit record to the ring, it is kept in the audit file only", zap.Error(err))
+	}
+	return nil
+}
+
+// saveAuditRing saves the record to the next slot of the ring, and sets the
+// sequence number of the record.
+func (s *Server) saveAuditRing(record *AuditRecord) error {
+	size := s.cfg.Audit.RingSize
+	nextPath := s.getAuditNextPath()
+	for i := 0; i < auditSaveRetry; i++ {
+		resp, err := kvGet(s.client, nextPath)
+		if err != nil {
+			return err
+		}
+		var rev int64
+		record.Seq = 0
+		if len(resp.Kvs) > 0 {
+			rev = resp.Kvs[0].ModRevision
+			if record.Seq, err = strconv.ParseUint(string(resp.Kvs[0].Value), 10, 64); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		value, err := json.Marshal(record)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		res, err := s.txn().
+			If(clientv3.Compare(clientv3.ModRevision(nextPath), "=", rev)).
+			Then(clientv3.OpPut(nextPath, strconv.FormatUint(record.Seq+1, 10)),
+				clientv3.OpPut(s.getAuditSlotPath(record.Seq%size), string(value))).
+			Commit()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if res.Succeeded {
+			return nil
+		}
+	}
+	return errors.New("save audit record failed, too many concurrent records")
+}
+
+// GetAuditRecords returns the latest audit records in the ring, the newest
+// first. It returns all records in the ring if limit is 0.
+func (s *Server) GetAuditRecords(limit int) ([]*AuditRecord, error) {
+	prefix := path.Join(s.getAuditPath(), "ring") + "/"
+	resp, err := kvGet(s.client, prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	records := make([]*AuditRecord, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		record := &AuditRecord{}
+		if err := json.Unmarshal(kv.Value, record); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Seq > records[j].Seq })
+	// Slots beyond the ring size are left if the ring is shrunk.
+	if size := int(s.cfg.Audit.RingSize); len(records) > size {
+		records = records[:size]
+	}
+	if limit > 0 && len(records) > limit {
+		records = records[:limit]
+	}
+	return records, nil
+}
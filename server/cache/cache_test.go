@@ -31,6 +31,7 @@ type testRegionCacheSuite struct {
 
 func (s *testRegionCacheSuite) TestExpireRegionCache(c *C) {
 	cache := NewTTL(time.Second, 2*time.Second)
+	defer cache.Close()
 	cache.PutWithTTL(1, 1, 1*time.Second)
 	cache.PutWithTTL(2, "v2", 5*time.Second)
 	cache.PutWithTTL(3, 3.0, 5*time.Second)
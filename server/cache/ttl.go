@@ -33,6 +33,8 @@ type TTL struct {
 	items      map[uint64]ttlCacheItem
 	ttl        time.Duration
 	gcInterval time.Duration
+	closeOnce  sync.Once
+	closed     chan struct{}
 }
 
 // NewTTL returns a new TTL cache.
@@ -41,6 +43,7 @@ func NewTTL(gcInterval time.Duration, ttl time.Duration) *TTL {
 		items:      make(map[uint64]ttlCacheItem),
 		ttl:        ttl,
 		gcInterval: gcInterval,
+		closed:     make(chan struct{}),
 	}
 
 	go c.doGC()
@@ -106,12 +109,22 @@ func (c *TTL) Clear() {
 	}
 }
 
+// Close stops the GC of the cache. The cache can still be used, but the
+// expired items are not removed any more.
+func (c *TTL) Close() {
+	c.closeOnce.Do(func() { close(c.closed) })
+}
+
 func (c *TTL) doGC() {
 	ticker := time.NewTicker(c.gcInterval)
 	defer ticker.Stop()
 
 	for {
-		<-ticker.C
+		select {
+		case <-ticker.C:
+		case <-c.closed:
+			return
+		}
 		count := 0
 		now := time.Now()
 		c.Lock()
@@ -0,0 +1,126 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"sync"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+)
+
+// WatchEventType is the type of a change event.
+type WatchEventType string
+
+// Types of change events.
+const (
+	// WatchEventStorePut means a store is added or its meta is changed.
+	WatchEventStorePut WatchEventType = "store_put"
+	// WatchEventStoreDelete means a store is removed from the cluster.
+	WatchEventStoreDelete WatchEventType = "store_delete"
+	// WatchEventRegionPut means a region is added or its meta is changed.
+	WatchEventRegionPut WatchEventType = "region_put"
+	// WatchEventLeaderChange means the leader of a region is changed.
+	WatchEventLeaderChange WatchEventType = "leader_change"
+	// WatchEventReset means the events since the requested revision are no
+	// longer available. The watcher should reload the full state and resume
+	// from the revision carried by this event.
+	WatchEventReset WatchEventType = "reset"
+	// WatchEventStart is always the first event of a watch stream. It carries
+	// the revision the stream starts after, so that the watcher can resume
+	// from it even if the stream is broken before any change arrives.
+	WatchEventStart WatchEventType = "start"
+)
+
+// WatchEvent is a change of the cluster which can be watched.
+type WatchEvent struct {
+	Revision uint64         `json:"revision"`
+	Type     WatchEventType `json:"type"`
+	Store    *metapb.Store  `json:"store,omitempty"`
+	Region   *metapb.Region `json:"region,omitempty"`
+	Leader   *metapb.Peer   `json:"leader,omitempty"`
+}
+
+const (
+	defaultStoreChangeHistory  = 1024
+	defaultRegionChangeHistory = 10000
+)
+
+// ChangeNotifier keeps a bounded history of change events and wakes up
+// watchers when a new event arrives. Every event carries a revision. The high
+// 32 bits of a revision are the leader term the notifier is created in, so
+// that a watcher resuming from a revision of another leader is always asked
+// to reset, whatever the clocks of the leaders are.
+type ChangeNotifier struct {
+	sync.RWMutex
+	revision uint64
+	history  []*WatchEvent
+	head     int
+	count    int
+	changed  chan struct{}
+}
+
+// NewChangeNotifier creates a ChangeNotifier which keeps at most size events
+// in the leader term.
+func NewChangeNotifier(size int, term uint64) *ChangeNotifier {
+	if size < 1 {
+		size = 1
+	}
+	return &ChangeNotifier{
+		revision: uint64(uint32(term)) << 32,
+		history:  make([]*WatchEvent, size),
+		changed:  make(chan struct{}),
+	}
+}
+
+// Notify assigns a revision to the event, records it and wakes up watchers.
+func (n *ChangeNotifier) Notify(e *WatchEvent) {
+	n.Lock()
+	defer n.Unlock()
+
+	n.revision++
+	e.Revision = n.revision
+	n.history[(n.head+n.count)%len(n.history)] = e
+	if n.count < len(n.history) {
+		n.count++
+	} else {
+		n.head = (n.head + 1) % len(n.history)
+	}
+	close(n.changed)
+	n.changed = make(chan struct{})
+}
+
+// Revision returns the revision of the latest event.
+func (n *ChangeNotifier) Revision() uint64 {
+	n.RLock()
+	defer n.RUnlock()
+	return n.revision
+}
+
+// Since returns the events after the revision and a channel which is closed
+// when the next event arrives. If the events are no longer available, it
+// returns a single reset event carrying the current revision.
+func (n *ChangeNotifier) Since(revision uint64) ([]*WatchEvent, <-chan struct{}) {
+	n.RLock()
+	defer n.RUnlock()
+
+	if revision>>32 != n.revision>>32 || revision > n.revision || revision+uint64(n.count) < n.revision {
+		return []*WatchEvent{{Revision: n.revision, Type: WatchEventReset}}, n.changed
+	}
+	missed := int(n.revision - revision)
+	events := make([]*WatchEvent, 0, missed)
+	for i := n.count - missed; i < n.count; i++ {
+		events = append(events, n.history[(n.head+i)%len(n.history)])
+	}
+	return events, n.changed
+}
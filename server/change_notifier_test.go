@@ -0,0 +1,73 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+)
+
+var _ = Suite(&testChangeNotifierSuite{})
+
+type testChangeNotifierSuite struct{}
+
+func (s *testChangeNotifierSuite) TestSince(c *C) {
+	n := NewChangeNotifier(3, 7)
+	start := n.Revision()
+
+	events, changed := n.Since(start)
+	c.Assert(events, HasLen, 0)
+	for i := uint64(1); i <= 2; i++ {
+		n.Notify(&WatchEvent{Type: WatchEventStorePut, Store: &metapb.Store{Id: i}})
+	}
+	select {
+	case <-changed:
+	default:
+		c.Fatal("watcher is not notified")
+	}
+
+	events, _ = n.Since(start)
+	c.Assert(events, HasLen, 2)
+	c.Assert(events[0].Revision, Equals, start+1)
+	c.Assert(events[1].Store.GetId(), Equals, uint64(2))
+	events, _ = n.Since(start + 1)
+	c.Assert(events, HasLen, 1)
+	c.Assert(events[0].Revision, Equals, start+2)
+
+	// The oldest event is dropped from the history.
+	for i := uint64(3); i <= 4; i++ {
+		n.Notify(&WatchEvent{Type: WatchEventStorePut, Store: &metapb.Store{Id: i}})
+	}
+	events, _ = n.Since(start + 1)
+	c.Assert(events, HasLen, 3)
+	c.Assert(events[0].Store.GetId(), Equals, uint64(2))
+	events, _ = n.Since(start)
+	c.Assert(events, HasLen, 1)
+	c.Assert(events[0].Type, Equals, WatchEventReset)
+	c.Assert(events[0].Revision, Equals, start+4)
+
+	// A revision from the future also requires a reset.
+	events, _ = n.Since(start + 10)
+	c.Assert(events, HasLen, 1)
+	c.Assert(events[0].Type, Equals, WatchEventReset)
+
+	// A revision of another leader always requires a reset, even if the
+	// events of the same sequence are in the history.
+	c.Assert(start, Equals, uint64(7)<<32)
+	for _, term := range []uint64{6, 8} {
+		events, _ = n.Since(term<<32 + 3)
+		c.Assert(events, HasLen, 1)
+		c.Assert(events[0].Type, Equals, WatchEventReset)
+	}
+}
@@ -76,6 +76,13 @@ func newRaftCluster(s *Server, clusterID uint64) *RaftCluster {
 	}
 }
 
+// GetRegionSyncer returns the region syncer.
+func (c *RaftCluster) GetRegionSyncer() *syncer.RegionSyncer {
+	c.RLock()
+	defer c.RUnlock()
+	return c.regionSyncer
+}
+
 func (c *RaftCluster) loadClusterStatus() (*ClusterStatus, error) {
 	data, err := c.s.kv.Load((c.s.kv.ClusterStatePath("raft_bootstrap_time")))
 	if err != nil {
@@ -100,7 +107,7 @@ func (c *RaftCluster) start() error {
 		return nil
 	}
 
-	cluster, err := loadClusterInfo(c.s.idAlloc, c.s.kv, c.s.scheduleOpt)
+	cluster, err := loadClusterInfo(c.s.idAlloc, c.s.kv, c.s.scheduleOpt, c.s.getLeaderTerm())
 	if err != nil {
 		return err
 	}
@@ -310,6 +317,20 @@ func (c *RaftCluster) DropCacheRegion(id uint64) {
 	c.cachedCluster.dropRegion(id)
 }
 
+// GetStoreNotifier returns the notifier of store changes.
+func (c *RaftCluster) GetStoreNotifier() *ChangeNotifier {
+	c.RLock()
+	defer c.RUnlock()
+	return c.cachedCluster.storeNotifier
+}
+
+// GetRegionNotifier returns the notifier of region meta and leader changes.
+func (c *RaftCluster) GetRegionNotifier() *ChangeNotifier {
+	c.RLock()
+	defer c.RUnlock()
+	return c.cachedCluster.regionNotifier
+}
+
 // GetStores gets stores from cluster.
 func (c *RaftCluster) GetStores() []*metapb.Store {
 	c.RLock()
@@ -514,6 +535,64 @@ func (c *RaftCluster) SetStoreWeight(storeID uint64, leaderWeight, regionWeight
 	return c.cachedCluster.putStore(newStore)
 }
 
+// SetStoreConfig sets up the schedule config overrides of a store. An empty
+// config removes all overrides.
+func (c *RaftCluster) SetStoreConfig(storeID uint64, config *core.StoreConfig) error {
+	c.Lock()
+	defer c.Unlock()
+
+	store := c.cachedCluster.GetStore(storeID)
+	if store == nil {
+		return core.NewStoreNotFoundErr(storeID)
+	}
+	return c.setStoreConfigLocked(store, config)
+}
+
+// UpdateStoreConfig applies the update to a copy of the schedule config
+// overrides of a store, and sets the result. The update runs under the lock,
+// so that concurrent updates of different fields are all kept. It returns the
+// new config.
+func (c *RaftCluster) UpdateStoreConfig(storeID uint64, update func(config *core.StoreConfig)) (*core.StoreConfig, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	store := c.cachedCluster.GetStore(storeID)
+	if store == nil {
+		return nil, core.NewStoreNotFoundErr(storeID)
+	}
+	config := store.GetConfig().Clone()
+	if config == nil {
+		config = &core.StoreConfig{}
+	}
+	update(config)
+	if err := c.setStoreConfigLocked(store, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func (c *RaftCluster) setStoreConfigLocked(store *core.StoreInfo, config *core.StoreConfig) error {
+	storeID := store.GetID()
+	lowSpaceRatio := config.GetLowSpaceRatio(c.cachedCluster.GetLowSpaceRatio())
+	highSpaceRatio := config.GetHighSpaceRatio(c.cachedCluster.GetHighSpaceRatio())
+	if lowSpaceRatio < 0 || lowSpaceRatio > 1 {
+		return errcode.NewInvalidInputErr(errors.New("low-space-ratio should between 0 and 1"))
+	}
+	if highSpaceRatio < 0 || highSpaceRatio > 1 {
+		return errcode.NewInvalidInputErr(errors.New("high-space-ratio should between 0 and 1"))
+	}
+	if lowSpaceRatio <= highSpaceRatio {
+		return errcode.NewInvalidInputErr(errors.New("low-space-ratio should be larger than high-space-ratio"))
+	}
+
+	if err := c.s.kv.SaveStoreConfig(storeID, config); err != nil {
+		return err
+	}
+	log.Info("store config is updated", zap.Uint64("store-id", storeID), zap.Reflect("config", config))
+
+	return c.cachedCluster.putStore(store.Clone(core.SetStoreConfig(config)))
+}
+
 func (c *RaftCluster) checkStores() {
 	var offlineStores []*metapb.Store
 	var upStoreCount int
@@ -527,7 +606,7 @@ func (c *RaftCluster) checkStores() {
 		}
 
 		if store.IsUp() {
-			if !store.IsLowSpace(cluster.GetLowSpaceRatio()) {
+			if !store.IsLowSpace(cluster.GetStoreLowSpaceRatio(store.GetID())) {
 				upStoreCount++
 			}
 			continue
@@ -648,6 +727,7 @@ func (c *RaftCluster) runBackgroundJobs(interval time.Duration) {
 			c.checkOperators()
 			c.checkStores()
 			c.collectMetrics()
+			c.recordMetricsHistory(time.Now())
 			c.coordinator.opController.PruneHistory()
 		}
 	}
@@ -40,6 +40,8 @@ type clusterInfo struct {
 	labelLevelStats *labelLevelStatistics
 	prepareChecker  *prepareChecker
 	changedRegions  chan *core.RegionInfo
+	storeNotifier   *ChangeNotifier
+	regionNotifier  *ChangeNotifier
 }
 
 var defaultChangedRegionsLimit = 10000
@@ -53,12 +55,16 @@ func newClusterInfo(id core.IDAllocator, opt *scheduleOption, kv *core.KV) *clus
 		labelLevelStats: newLabelLevelStatistics(),
 		prepareChecker:  newPrepareChecker(),
 		changedRegions:  make(chan *core.RegionInfo, defaultChangedRegionsLimit),
+		storeNotifier:   NewChangeNotifier(defaultStoreChangeHistory, 0),
+		regionNotifier:  NewChangeNotifier(defaultRegionChangeHistory, 0),
 	}
 }
 
 // Return nil if cluster is not bootstrapped.
-func loadClusterInfo(id core.IDAllocator, kv *core.KV, opt *scheduleOption) (*clusterInfo, error) {
+func loadClusterInfo(id core.IDAllocator, kv *core.KV, opt *scheduleOption, term uint64) (*clusterInfo, error) {
 	c := newClusterInfo(id, opt, kv)
+	c.storeNotifier = NewChangeNotifier(defaultStoreChangeHistory, term)
+	c.regionNotifier = NewChangeNotifier(defaultRegionChangeHistory, term)
 
 	c.meta = &metapb.Cluster{}
 	ok, err := kv.LoadMeta(c.meta)
@@ -200,6 +206,7 @@ func (c *clusterInfo) putStoreLocked(store *core.StoreInfo) error {
 		}
 	}
 	c.core.PutStore(store)
+	c.storeNotifier.Notify(&WatchEvent{Type: WatchEventStorePut, Store: proto.Clone(store.GetMeta()).(*metapb.Store)})
 	return nil
 }
 
@@ -216,6 +223,7 @@ func (c *clusterInfo) deleteStoreLocked(store *core.StoreInfo) error {
 		}
 	}
 	c.core.DeleteStore(store)
+	c.storeNotifier.Notify(&WatchEvent{Type: WatchEventStoreDelete, Store: proto.Clone(store.GetMeta()).(*metapb.Store)})
 	return nil
 }
 
@@ -505,7 +513,7 @@ func (c *clusterInfo) handleRegionHeartbeat(region *core.RegionInfo) error {
 	// Save to KV if meta is updated.
 	// Save to cache if meta or leader is updated, or contains any down/pending peer.
 	// Mark isNew if the region in cache does not have leader.
-	var saveKV, saveCache, isNew bool
+	var saveKV, saveCache, isNew, leaderChanged bool
 	if origin == nil {
 		log.Debug("insert new region",
 			zap.Uint64("region-id", region.GetID()),
@@ -547,7 +555,7 @@ func (c *clusterInfo) handleRegionHeartbeat(region *core.RegionInfo) error {
 					zap.Uint64("to", region.GetLeader().GetStoreId()),
 				)
 			}
-			saveCache = true
+			saveCache, leaderChanged = true, true
 		}
 		if len(region.GetDownPeers()) > 0 || len(region.GetPendingPeers()) > 0 {
 			saveCache = true
@@ -580,6 +588,11 @@ func (c *clusterInfo) handleRegionHeartbeat(region *core.RegionInfo) error {
 		default:
 		}
 	}
+	if saveKV {
+		c.regionNotifier.Notify(&WatchEvent{Type: WatchEventRegionPut, Region: region.GetMeta(), Leader: region.GetLeader()})
+	} else if leaderChanged {
+		c.regionNotifier.Notify(&WatchEvent{Type: WatchEventLeaderChange, Region: region.GetMeta(), Leader: region.GetLeader()})
+	}
 	if !isWriteUpdate && !isReadUpdate && !saveCache && !isNew {
 		return nil
 	}
@@ -707,6 +720,30 @@ func (c *clusterInfo) GetMaxPendingPeerCount() uint64 {
 	return c.opt.GetMaxPendingPeerCount()
 }
 
+func (c *clusterInfo) GetStoreMaxSnapshotCount(storeID uint64) uint64 {
+	return c.getStoreConfig(storeID).GetMaxSnapshotCount(c.opt.GetMaxSnapshotCount())
+}
+
+func (c *clusterInfo) GetStoreMaxPendingPeerCount(storeID uint64) uint64 {
+	return c.getStoreConfig(storeID).GetMaxPendingPeerCount(c.opt.GetMaxPendingPeerCount())
+}
+
+func (c *clusterInfo) GetStoreLowSpaceRatio(storeID uint64) float64 {
+	return c.getStoreConfig(storeID).GetLowSpaceRatio(c.opt.GetLowSpaceRatio())
+}
+
+func (c *clusterInfo) GetStoreHighSpaceRatio(storeID uint64) float64 {
+	return c.getStoreConfig(storeID).GetHighSpaceRatio(c.opt.GetHighSpaceRatio())
+}
+
+func (c *clusterInfo) getStoreConfig(storeID uint64) *core.StoreConfig {
+	store := c.GetStore(storeID)
+	if store == nil {
+		return nil
+	}
+	return store.GetConfig()
+}
+
 func (c *clusterInfo) GetMaxMergeRegionSize() uint64 {
 	return c.opt.GetMaxMergeRegionSize()
 }
@@ -271,7 +271,7 @@ func (s *testClusterInfoSuite) TestLoadClusterInfo(c *C) {
 	c.Assert(err, IsNil)
 
 	// Cluster is not bootstrapped.
-	cluster, err := loadClusterInfo(server.idAlloc, kv, opt)
+	cluster, err := loadClusterInfo(server.idAlloc, kv, opt, 0)
 	c.Assert(err, IsNil)
 	c.Assert(cluster, IsNil)
 
@@ -282,7 +282,7 @@ func (s *testClusterInfoSuite) TestLoadClusterInfo(c *C) {
 	stores := mustSaveStores(c, kv, n)
 	regions := mustSaveRegions(c, kv, n)
 
-	cluster, err = loadClusterInfo(server.idAlloc, kv, opt)
+	cluster, err = loadClusterInfo(server.idAlloc, kv, opt, 0)
 	c.Assert(err, IsNil)
 	c.Assert(cluster, NotNil)
 
@@ -27,8 +27,11 @@ import (
 	"github.com/BurntSushi/toml"
 	"github.com/coreos/go-semver/semver"
 	"github.com/pingcap/log"
+	"github.com/pingcap/pd/pkg/encryption"
 	"github.com/pingcap/pd/pkg/metricutil"
+	"github.com/pingcap/pd/pkg/tlsutil"
 	"github.com/pingcap/pd/pkg/typeutil"
+	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/server/namespace"
 	"github.com/pingcap/pd/server/schedule"
 	"github.com/pkg/errors"
@@ -58,6 +61,10 @@ type Config struct {
 	// Join to an existing pd cluster, a string of endpoints.
 	Join string `toml:"join" json:"join"`
 
+	// Labels are the labels of the member, such as zone and dc. The PD leader
+	// is placed on members by their labels if a leader policy is set.
+	Labels map[string]string `toml:"labels" json:"labels"`
+
 	// LeaderLease time, if leader doesn't update its TTL
 	// in etcd after lease time, etcd will expire the leader key
 	// and other servers can campaign the leader again.
@@ -84,6 +91,12 @@ type Config struct {
 
 	PDServerCfg PDServerConfig `toml:"pd-server" json:"pd-server"`
 
+	// RegionStorageEngine is the storage engine of the independent region
+	// storage, which is either "leveldb" or "bbolt". It is read only when PD
+	// starts, use pd-region-migrate to move the regions and the metrics
+	// history to the new engine before changing it.
+	RegionStorageEngine string `toml:"region-storage-engine" json:"region-storage-engine"`
+
 	ClusterVersion semver.Version `json:"cluster-version"`
 
 	// QuotaBackendBytes Raise alarms when backend size exceeds the given quota. 0 means use the default quota.
@@ -113,6 +126,8 @@ type Config struct {
 
 	LabelProperty LabelPropertyConfig `toml:"label-property" json:"label-property"`
 
+	Audit AuditConfig `toml:"audit" json:"audit"`
+
 	configFile string
 
 	// For all warnings during parsing.
@@ -195,6 +210,11 @@ const (
 	defaultHeartbeatStreamRebindInterval = time.Minute
 
 	defaultLeaderPriorityCheckInterval = time.Minute
+
+	defaultFollowerRegionMaxLag    = 10 * time.Second
+	defaultRegionStorageEngine     = core.LevelDBEngine
+	defaultMetricsHistoryRetention = 7 * 24 * time.Hour
+	defaultAuditRingSize           = 1000
 )
 
 func adjustString(v *string, defValue string) {
@@ -295,6 +315,17 @@ func (c *Config) validate() error {
 	if !strings.HasPrefix(rel, "..") {
 		return errors.New("log directory shouldn't be the subdirectory of data directory")
 	}
+	for k, v := range c.Labels {
+		if err := ValidateLabelString(k); err != nil {
+			return err
+		}
+		if err := ValidateLabelString(v); err != nil {
+			return err
+		}
+	}
+	if err := c.Security.validate(); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -403,6 +434,11 @@ func (c *Config) Adjust(meta *toml.MetaData) error {
 
 	adjustString(&c.NamespaceClassifier, "table")
 
+	adjustString(&c.RegionStorageEngine, defaultRegionStorageEngine)
+	if !core.IsKVEngineSupported(c.RegionStorageEngine) {
+		return errors.Errorf("region-storage-engine %q is not supported", c.RegionStorageEngine)
+	}
+
 	adjustString(&c.Metric.PushJob, c.Name)
 
 	if err := c.Schedule.adjust(configMetaData.Child("schedule")); err != nil {
@@ -412,6 +448,12 @@ func (c *Config) Adjust(meta *toml.MetaData) error {
 		return err
 	}
 
+	if err := c.PDServerCfg.adjust(configMetaData.Child("pd-server")); err != nil {
+		return err
+	}
+
+	adjustUint64(&c.Audit.RingSize, defaultAuditRingSize)
+
 	adjustDuration(&c.heartbeatStreamBindInterval, defaultHeartbeatStreamRebindInterval)
 
 	adjustDuration(&c.LeaderPriorityCheckInterval, defaultLeaderPriorityCheckInterval)
@@ -442,6 +484,33 @@ func (c *Config) configFromFile(path string) (*toml.MetaData, error) {
 	return &meta, errors.WithStack(err)
 }
 
+// IsConfigFileSpecified returns true if the config is loaded from a file.
+func (c *Config) IsConfigFileSpecified() bool {
+	return c.configFile != ""
+}
+
+// reloadFromFile parses the config file again with the command line flags
+// which are used to parse the config, and returns the new config.
+func (c *Config) reloadFromFile() (*Config, error) {
+	if c.configFile == "" {
+		return nil, errors.New("no config file is specified")
+	}
+	var arguments []string
+	if c.FlagSet != nil {
+		c.FlagSet.Visit(func(f *flag.Flag) {
+			if f.Name != "config" {
+				arguments = append(arguments, fmt.Sprintf("-%s=%s", f.Name, f.Value.String()))
+			}
+		})
+	}
+	arguments = append(arguments, "-config="+c.configFile)
+	cfg := NewConfig()
+	if err := cfg.Parse(arguments); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
 // ScheduleConfig is the schedule configuration.
 type ScheduleConfig struct {
 	// If the snapshot count of one store is greater than this value,
@@ -728,6 +797,71 @@ type SecurityConfig struct {
 	CertPath string `toml:"cert-path" json:"cert-path"`
 	// KeyPath is the path of file that contains X509 key in PEM format.
 	KeyPath string `toml:"key-path" json:"key-path"`
+	// RoleBindings maps the identities of TLS client certificates to roles.
+	// An identity is the common name or a SAN of a certificate. The access
+	// control of the HTTP API is enabled if it is not empty.
+	RoleBindings map[string]string `toml:"role-bindings" json:"role-bindings"`
+	// DefaultRole is the role of the clients whose identities are not bound,
+	// they are denied if it is empty.
+	DefaultRole string `toml:"default-role" json:"default-role"`
+	// RedactInfoLog replaces user keys with "?" in logs, as they may contain
+	// user data.
+	RedactInfoLog bool `toml:"redact-info-log" json:"redact-info-log"`
+	// RedactRegionAPI also replaces the keys of regions with "?" in the
+	// output of the region and watch APIs.
+	RedactRegionAPI bool `toml:"redact-region-api" json:"redact-region-api"`
+	// Encryption is the master key to encrypt the local region storage.
+	Encryption encryption.Config `toml:"encryption" json:"encryption"`
+}
+
+// Roles of the HTTP API clients, a role can call the APIs of the roles before
+// it.
+const (
+	RoleReadOnly = "read-only"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+)
+
+var roles = []string{RoleReadOnly, RoleOperator, RoleAdmin}
+
+func roleLevel(role string) int {
+	for i, r := range roles {
+		if r == role {
+			return i
+		}
+	}
+	return -1
+}
+
+// RoleAllows returns true if the role can call the APIs of the required role.
+func RoleAllows(role, required string) bool {
+	level := roleLevel(role)
+	return level >= 0 && level >= roleLevel(required)
+}
+
+func (s SecurityConfig) validate() error {
+	if s.Encryption.MasterKeyFile != "" && s.Encryption.MasterKeyEnv != "" {
+		return errors.New("master-key-file and master-key-env can not be both set")
+	}
+	if len(s.RoleBindings) == 0 {
+		return nil
+	}
+	if len(s.CAPath) == 0 {
+		return errors.New("role-bindings requires cacert-path to verify client certificates")
+	}
+	for identity, role := range s.RoleBindings {
+		if !isValidRole(role) {
+			return errors.Errorf("invalid role %q of %q", role, identity)
+		}
+	}
+	if s.DefaultRole != "" && !isValidRole(s.DefaultRole) {
+		return errors.Errorf("invalid default-role %q", s.DefaultRole)
+	}
+	return nil
+}
+
+func isValidRole(role string) bool {
+	return roleLevel(role) >= 0
 }
 
 // ToTLSConfig generatres tls config.
@@ -744,13 +878,58 @@ func (s SecurityConfig) ToTLSConfig() (*tls.Config, error) {
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
+	// The embedded etcd reloads the server certificate in every handshake,
+	// and clients reload the certificate and the CA when the files are
+	// modified. The CA is taken when the config is built, so the config
+	// should be built for new connections.
+	reloader, err := tlsutil.GetCertReloader(s.CertPath, s.KeyPath)
+	if err != nil {
+		return nil, err
+	}
+	reloader.Apply(tlsConfig)
+	if len(s.CAPath) != 0 {
+		caReloader, err := tlsutil.GetCAReloader(s.CAPath)
+		if err != nil {
+			return nil, err
+		}
+		caReloader.Apply(tlsConfig)
+	}
 	return tlsConfig, nil
 }
 
+// AuditConfig is the configuration for the audit log of the mutating API
+// calls.
+type AuditConfig struct {
+	// File is the file audit records are written to, they are not written to
+	// a file if the filename is empty.
+	File log.FileLogConfig `toml:"file" json:"file"`
+	// RingSize is the number of the latest audit records kept in etcd.
+	RingSize uint64 `toml:"ring-size" json:"ring-size"`
+}
+
 // PDServerConfig is the configuration for pd server.
 type PDServerConfig struct {
 	// UseRegionStorage enables the independent region storage.
 	UseRegionStorage bool `toml:"use-region-storage" json:"use-region-storage,string"`
+	// FollowerRegionMaxLag is the max lag of the synced regions with which a
+	// follower serves the read-only region APIs itself instead of redirecting
+	// them to the leader. It works only if UseRegionStorage is enabled, and
+	// 0 means followers always redirect.
+	FollowerRegionMaxLag typeutil.Duration `toml:"follower-region-max-lag" json:"follower-region-max-lag"`
+	// MetricsHistoryRetention is how long the leader keeps the metrics of
+	// stores sampled every minute in the region storage, 0 means not to keep
+	// them.
+	MetricsHistoryRetention typeutil.Duration `toml:"metrics-history-retention" json:"metrics-history-retention"`
+}
+
+func (c *PDServerConfig) adjust(meta *configMetaData) error {
+	if !meta.IsDefined("follower-region-max-lag") {
+		adjustDuration(&c.FollowerRegionMaxLag, defaultFollowerRegionMaxLag)
+	}
+	if !meta.IsDefined("metrics-history-retention") {
+		adjustDuration(&c.MetricsHistoryRetention, defaultMetricsHistoryRetention)
+	}
+	return nil
 }
 
 // StoreLabel is the config item of LabelPropertyConfig.
@@ -0,0 +1,282 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/pingcap/errcode"
+	log "github.com/pingcap/log"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// maxConfigHistoryCount is the number of the latest versions kept in history.
+const maxConfigHistoryCount = 1000
+
+// ConfigHistoryEntry is a version of the schedule and replication config.
+type ConfigHistoryEntry struct {
+	Version uint64    `json:"version"`
+	Time    time.Time `json:"time"`
+	// Member is the name of the PD server which saves the version.
+	Member string `json:"member"`
+	// Caller is the identity of the client which changes the config.
+	Caller      string            `json:"caller"`
+	Comment     string            `json:"comment,omitempty"`
+	Schedule    ScheduleConfig    `json:"schedule"`
+	Replication ReplicationConfig `json:"replication"`
+}
+
+// ConfigChange is the change of a config field.
+type ConfigChange struct {
+	// Field is the config section and the field name, such as
+	// "schedule.region-schedule-limit".
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// ConfigHistory is a version of config with the changes against the former
+// version.
+type ConfigHistory struct {
+	Version uint64         `json:"version"`
+	Time    time.Time      `json:"time"`
+	Member  string         `json:"member"`
+	Caller  string         `json:"caller"`
+	Comment string         `json:"comment,omitempty"`
+	Changes []ConfigChange `json:"changes"`
+}
+
+// DiffConfig returns the changed fields between two configs of the same
+// section. The fields are named by their JSON keys, prefixed with the section
+// if it is not empty.
+func DiffConfig(section string, old, new interface{}) ([]ConfigChange, error) {
+	oldFields, err := toJSONFields(old)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := toJSONFields(new)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(newFields))
+	for k := range newFields {
+		keys = append(keys, k)
+	}
+	for k := range oldFields {
+		if _, ok := newFields[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var changes []ConfigChange
+	for _, k := range keys {
+		if !reflect.DeepEqual(oldFields[k], newFields[k]) {
+			field := k
+			if section != "" {
+				field = section + "." + k
+			}
+			changes = append(changes, ConfigChange{
+				Field: field,
+				Old:   oldFields[k],
+				New:   newFields[k],
+			})
+		}
+	}
+	return changes, nil
+}
+
+func toJSONFields(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return fields, nil
+}
+
+// SaveConfigHistory saves the current schedule and replication config as a
+// new version. The caller is the identity of the client which changes the
+// config. Nothing is saved if the config is not changed since the latest
+// version.
+func (s *Server) SaveConfigHistory(caller, comment string) error {
+	s.configHistoryLock.Lock()
+	defer s.configHistoryLock.Unlock()
+
+	version, err := s.saveConfigHistoryLocked(caller, comment, s.GetScheduleConfig(), s.GetReplicationConfig())
+	if err != nil {
+		return err
+	}
+	s.pruneConfigHistoryLocked(version)
+	return nil
+}
+
+// UpdateConfigWithHistory saves the schedule and replication config as a new
+// version, then calls update to apply them. The version is saved before the
+// config takes effect, so that every applied change has a version to roll
+// back with. If update fails, the saved version is removed.
+func (s *Server) UpdateConfigWithHistory(caller, comment string, schedule *ScheduleConfig, replication *ReplicationConfig, update func() error) error {
+	if err := schedule.validate(); err != nil {
+		return errcode.NewInvalidInputErr(err)
+	}
+	if err := replication.validate(); err != nil {
+		return errcode.NewInvalidInputErr(err)
+	}
+
+	s.configHistoryLock.Lock()
+	defer s.configHistoryLock.Unlock()
+
+	version, err := s.saveConfigHistoryLocked(caller, comment, schedule, replication)
+	if err != nil {
+		return err
+	}
+	if err := update(); err != nil {
+		if version > 0 {
+			if err := s.kv.RemoveConfigHistory(version); err != nil {
+				log.Error("failed to remove config history of the failed update", zap.Uint64("version", version), zap.Error(err))
+			}
+		}
+		return err
+	}
+	s.pruneConfigHistoryLocked(version)
+	return nil
+}
+
+// saveConfigHistoryLocked saves the config as a new version and returns the
+// version. It returns 0 if the config is not changed since the latest
+// version.
+func (s *Server) saveConfigHistoryLocked(caller, comment string, schedule *ScheduleConfig, replication *ReplicationConfig) (uint64, error) {
+	version, err := s.kv.LoadConfigHistoryVersion()
+	if err != nil {
+		return 0, err
+	}
+	entry := &ConfigHistoryEntry{
+		Version:     version + 1,
+		Time:        time.Now(),
+		Member:      s.Name(),
+		Caller:      caller,
+		Comment:     comment,
+		Schedule:    *schedule,
+		Replication: *replication,
+	}
+	if version > 0 {
+		latest := &ConfigHistoryEntry{}
+		ok, err := s.kv.LoadConfigHistory(version, latest)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			changes, err := diffConfigHistoryEntry(latest, entry)
+			if err != nil {
+				return 0, err
+			}
+			if len(changes) == 0 {
+				return 0, nil
+			}
+		}
+	}
+	if err := s.kv.SaveConfigHistory(entry.Version, entry); err != nil {
+		return 0, err
+	}
+	log.Info("config history is saved", zap.Uint64("version", entry.Version), zap.String("caller", caller))
+	return entry.Version, nil
+}
+
+// pruneConfigHistoryLocked deletes the version which is outdated by the new
+// version.
+func (s *Server) pruneConfigHistoryLocked(version uint64) {
+	if version > maxConfigHistoryCount {
+		if err := s.kv.DeleteConfigHistory(version - maxConfigHistoryCount); err != nil {
+			log.Warn("failed to delete outdated config history", zap.Error(err))
+		}
+	}
+}
+
+// GetConfigHistory returns all versions of config in history, with the
+// changes against their former versions.
+func (s *Server) GetConfigHistory() ([]*ConfigHistory, error) {
+	var (
+		histories []*ConfigHistory
+		last      *ConfigHistoryEntry
+		next      uint64
+	)
+	for {
+		res, err := s.kv.LoadConfigHistories(next, maxConfigHistoryCount)
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range res {
+			entry := &ConfigHistoryEntry{}
+			if err := json.Unmarshal([]byte(value), entry); err != nil {
+				return nil, errors.WithStack(err)
+			}
+			history := &ConfigHistory{
+				Version: entry.Version,
+				Time:    entry.Time,
+				Member:  entry.Member,
+				Caller:  entry.Caller,
+				Comment: entry.Comment,
+			}
+			if last != nil {
+				if history.Changes, err = diffConfigHistoryEntry(last, entry); err != nil {
+					return nil, err
+				}
+			}
+			histories = append(histories, history)
+			last, next = entry, entry.Version+1
+		}
+		if len(res) < maxConfigHistoryCount {
+			return histories, nil
+		}
+	}
+}
+
+func diffConfigHistoryEntry(old, new *ConfigHistoryEntry) ([]ConfigChange, error) {
+	changes, err := DiffConfig("schedule", &old.Schedule, &new.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	replicationChanges, err := DiffConfig("replication", &old.Replication, &new.Replication)
+	if err != nil {
+		return nil, err
+	}
+	return append(changes, replicationChanges...), nil
+}
+
+// RollbackConfig restores the schedule and replication config of the
+// version, and saves it as a new version. The schedulers are not restored,
+// since they are added and removed by the scheduler API, which also updates
+// the running schedulers.
+func (s *Server) RollbackConfig(version uint64, caller string) error {
+	entry := &ConfigHistoryEntry{}
+	ok, err := s.kv.LoadConfigHistory(version, entry)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.Errorf("config version %d not found", version)
+	}
+	entry.Schedule.Schedulers = s.GetScheduleConfig().Schedulers
+	return s.UpdateConfigWithHistory(caller, fmt.Sprintf("rollback to version %d", version), &entry.Schedule, &entry.Replication, func() error {
+		return s.SetScheduleOptionConfig(entry.Schedule, entry.Replication, *s.scheduleOpt.loadPDServerConfig())
+	})
+}
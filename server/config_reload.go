@@ -0,0 +1,143 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+
+	"github.com/coreos/go-semver/semver"
+	log "github.com/pingcap/log"
+	"go.uber.org/zap"
+)
+
+// configFileCaller is the caller recorded in config history when the config
+// is changed by reloading the config file.
+const configFileCaller = "config-file"
+
+// ReloadConfigFile parses the config file again. The dynamic items, which are
+// the schedule, replication and label-property sections, log.level and the
+// redact items of the security section, take effect at once. The schedule, replication and label-property sections are
+// only applied by the leader, since they are persisted and shared by all
+// members. The schedulers are managed by the scheduler API, so the schedulers
+// in the config file are ignored. The changes of other items need a restart,
+// they are reported as warnings in WarningMsgs of the returned config, once
+// after the reload which changes them.
+func (s *Server) ReloadConfigFile() (*Config, error) {
+	s.fileConfigLock.Lock()
+	defer s.fileConfigLock.Unlock()
+	cfg, err := s.cfg.reloadFromFile()
+	if err != nil {
+		return nil, err
+	}
+	last := s.fileConfig
+	if last == nil {
+		last = s.cfg
+	}
+	changes, err := diffStaticConfig(last, cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, change := range changes {
+		msg := fmt.Sprintf("%s in %s is changed, it takes effect after restart", change.Field, s.cfg.configFile)
+		cfg.WarningMsgs = append(cfg.WarningMsgs, msg)
+	}
+
+	if cfg.Log.Level != s.cfg.Log.Level {
+		s.SetLogLevel(cfg.Log.Level)
+	}
+	if cfg.Security.RedactInfoLog != s.cfg.Security.RedactInfoLog ||
+		cfg.Security.RedactRegionAPI != s.cfg.Security.RedactRegionAPI {
+		s.SetRedactConfig(cfg.Security.RedactInfoLog, cfg.Security.RedactRegionAPI)
+	}
+
+	if !s.IsLeader() {
+		log.Info("config file is reloaded, the persisted config is left to the leader", zap.String("file", s.cfg.configFile))
+		s.fileConfig = cfg
+		return cfg, nil
+	}
+	if err := s.applyDynamicConfig(cfg); err != nil {
+		return nil, err
+	}
+	log.Info("config file is reloaded", zap.String("file", s.cfg.configFile))
+	s.fileConfig = cfg
+	return cfg, nil
+}
+
+func (s *Server) applyDynamicConfig(cfg *Config) error {
+	// The schedule section is validated when parsing, validate the others
+	// before applying any section.
+	if err := cfg.Replication.validate(); err != nil {
+		return err
+	}
+
+	oldSchedule := s.GetScheduleConfig()
+	schedule := cfg.Schedule
+	schedule.Schedulers = oldSchedule.Schedulers
+	scheduleChanges, err := DiffConfig("schedule", oldSchedule, &schedule)
+	if err != nil {
+		return err
+	}
+	if len(scheduleChanges) > 0 {
+		if err := s.SetScheduleConfig(schedule); err != nil {
+			return err
+		}
+	}
+
+	replicationChanges, err := DiffConfig("replication", s.GetReplicationConfig(), &cfg.Replication)
+	if err != nil {
+		return err
+	}
+	if len(replicationChanges) > 0 {
+		if err := s.SetReplicationConfig(cfg.Replication); err != nil {
+			return err
+		}
+	}
+
+	labelPropertyChanges, err := DiffConfig("label-property", s.GetLabelProperty(), cfg.LabelProperty)
+	if err != nil {
+		return err
+	}
+	if len(labelPropertyChanges) > 0 {
+		if err := s.SetLabelPropertyConfig(cfg.LabelProperty); err != nil {
+			return err
+		}
+	}
+
+	if len(scheduleChanges)+len(replicationChanges) == 0 {
+		return nil
+	}
+	return s.SaveConfigHistory(configFileCaller, fmt.Sprintf("reload config file %s", s.cfg.configFile))
+}
+
+// diffStaticConfig returns the changed items which need a restart to take
+// effect.
+func diffStaticConfig(old, new *Config) ([]ConfigChange, error) {
+	return DiffConfig("", staticConfig(old), staticConfig(new))
+}
+
+// staticConfig returns a copy of the config without the dynamic items and the
+// items which are not from the config file.
+func staticConfig(cfg *Config) *Config {
+	c := cfg.clone()
+	c.Schedule = ScheduleConfig{}
+	c.Replication = ReplicationConfig{}
+	c.LabelProperty = nil
+	c.Log.Level = ""
+	c.Security.RedactInfoLog = false
+	c.Security.RedactRegionAPI = false
+	c.Namespace = nil
+	c.ClusterVersion = semver.Version{}
+	c.WarningMsgs = nil
+	return c
+}
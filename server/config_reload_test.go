@@ -0,0 +1,97 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"io/ioutil"
+	"path"
+	"strings"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/schedule"
+)
+
+var _ = Suite(&testConfigReloadSuite{})
+
+type testConfigReloadSuite struct{}
+
+func (s *testConfigReloadSuite) TestReloadConfigFile(c *C) {
+	svr, cleanup := mustRunTestServer(c)
+	defer cleanup()
+
+	_, err := svr.ReloadConfigFile()
+	c.Assert(err, NotNil)
+
+	cfgData := `
+lease = 3
+
+[schedule]
+leader-schedule-limit = 8
+
+[replication]
+max-replicas = 5
+
+[security]
+redact-info-log = true
+redact-region-api = true
+
+[[label-property.reject-leader]]
+key = "zone"
+value = "cn"
+`
+	svr.cfg.configFile = path.Join(svr.cfg.DataDir, "pd.toml")
+	c.Assert(ioutil.WriteFile(svr.cfg.configFile, []byte(cfgData), 0644), IsNil)
+	cfg, err := svr.ReloadConfigFile()
+	c.Assert(err, IsNil)
+
+	c.Assert(svr.GetScheduleConfig().LeaderScheduleLimit, Equals, uint64(8))
+	c.Assert(svr.GetScheduleConfig().Schedulers, DeepEquals, svr.scheduleOpt.GetSchedulers())
+	c.Assert(svr.GetReplicationConfig().MaxReplicas, Equals, uint64(5))
+	c.Assert(svr.GetLabelProperty()[schedule.RejectLeader], DeepEquals, []StoreLabel{{Key: "zone", Value: "cn"}})
+	var leaseChanged bool
+	for _, msg := range cfg.WarningMsgs {
+		leaseChanged = leaseChanged || strings.HasPrefix(msg, "lease ")
+	}
+	c.Assert(leaseChanged, IsTrue)
+	c.Assert(svr.cfg.LeaderLease, Equals, int64(1))
+	for _, msg := range cfg.WarningMsgs {
+		c.Assert(strings.HasPrefix(msg, "security."), IsFalse)
+	}
+	c.Assert(core.IsRedactRegionAPI(), IsTrue)
+	c.Assert(string(core.HexRegionKey([]byte("a"))), Equals, "?")
+	svr.SetRedactConfig(false, false)
+	c.Assert(core.IsRedactRegionAPI(), IsFalse)
+
+	histories, err := svr.GetConfigHistory()
+	c.Assert(err, IsNil)
+	c.Assert(histories[len(histories)-1].Caller, Equals, configFileCaller)
+
+	// The changed static items are reported once.
+	cfg, err = svr.ReloadConfigFile()
+	c.Assert(err, IsNil)
+	c.Assert(cfg.WarningMsgs, HasLen, 0)
+	c.Assert(svr.cfg.LeaderLease, Equals, int64(1))
+
+	// An invalid config file is not applied.
+	cfgData = `
+[schedule]
+leader-schedule-limit = 16
+low-space-ratio = 2.0
+`
+	c.Assert(ioutil.WriteFile(svr.cfg.configFile, []byte(cfgData), 0644), IsNil)
+	_, err = svr.ReloadConfigFile()
+	c.Assert(err, NotNil)
+	c.Assert(svr.GetScheduleConfig().LeaderScheduleLimit, Equals, uint64(8))
+}
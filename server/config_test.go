@@ -89,6 +89,33 @@ func (s *testConfigSuite) TestValidation(c *C) {
 	c.Assert(cfg.Schedule.validate(), IsNil)
 	cfg.Schedule.TolerantSizeRatio = -0.6
 	c.Assert(cfg.Schedule.validate(), NotNil)
+
+	// check role bindings
+	cfg.Security.RoleBindings = map[string]string{"dashboard": RoleReadOnly}
+	c.Assert(cfg.Security.validate(), NotNil)
+	cfg.Security.CAPath = "ca.pem"
+	c.Assert(cfg.Security.validate(), IsNil)
+	cfg.Security.RoleBindings["ctl"] = "root"
+	c.Assert(cfg.Security.validate(), NotNil)
+	delete(cfg.Security.RoleBindings, "ctl")
+	cfg.Security.DefaultRole = "guest"
+	c.Assert(cfg.Security.validate(), NotNil)
+	cfg.Security.DefaultRole = ""
+
+	// check master keys
+	cfg.Security.Encryption.MasterKeyFile = "master.key"
+	c.Assert(cfg.Security.validate(), IsNil)
+	cfg.Security.Encryption.MasterKeyEnv = "PD_MASTER_KEY"
+	c.Assert(cfg.Security.validate(), NotNil)
+}
+
+func (s *testConfigSuite) TestRoleAllows(c *C) {
+	c.Assert(RoleAllows(RoleAdmin, RoleOperator), IsTrue)
+	c.Assert(RoleAllows(RoleOperator, RoleOperator), IsTrue)
+	c.Assert(RoleAllows(RoleOperator, RoleAdmin), IsFalse)
+	c.Assert(RoleAllows(RoleReadOnly, RoleOperator), IsFalse)
+	c.Assert(RoleAllows("", RoleReadOnly), IsFalse)
+	c.Assert(RoleAllows("root", RoleReadOnly), IsFalse)
 }
 
 func (s *testConfigSuite) TestAdjust(c *C) {
@@ -175,3 +202,26 @@ address = "localhost:9090"
 	c.Assert(cfg.Metric.PushInterval.Duration, Equals, 35*time.Second)
 	c.Assert(cfg.Metric.PushAddress, Equals, "localhost:9090")
 }
+
+func (s *testConfigSuite) TestRegionStorageEngine(c *C) {
+	cfg := NewConfig()
+	c.Assert(cfg.Adjust(nil), IsNil)
+	c.Assert(cfg.RegionStorageEngine, Equals, core.LevelDBEngine)
+
+	cfgData := `
+region-storage-engine = "bbolt"
+`
+	cfg = NewConfig()
+	meta, err := toml.Decode(cfgData, &cfg)
+	c.Assert(err, IsNil)
+	c.Assert(cfg.Adjust(&meta), IsNil)
+	c.Assert(cfg.RegionStorageEngine, Equals, core.BoltEngine)
+
+	cfgData = `
+region-storage-engine = "rocksdb"
+`
+	cfg = NewConfig()
+	meta, err = toml.Decode(cfgData, &cfg)
+	c.Assert(err, IsNil)
+	c.Assert(cfg.Adjust(&meta), NotNil)
+}
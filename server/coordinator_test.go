@@ -983,7 +983,7 @@ func getHeartBeatStreams(c *C, tc *testClusterInfo) *heartbeatStreams {
 	c.Assert(err, IsNil)
 	kvBase := newEtcdKVBase(svr)
 	path := filepath.Join(svr.cfg.DataDir, "region-meta")
-	regionKV, err := core.NewRegionKV(path)
+	regionKV, err := core.NewRegionKV(core.LevelDBEngine, path, nil)
 	c.Assert(err, IsNil)
 	svr.kv = core.NewKV(kvBase).SetRegionKV(regionKV)
 	cluster := newRaftCluster(svr, tc.getClusterID())
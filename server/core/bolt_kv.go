@@ -0,0 +1,192 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	boltFileName = "pd.db"
+	// boltOpenTimeout is the time to wait for the file lock, which is held
+	// by another process opening the database.
+	boltOpenTimeout = 3 * time.Second
+	// boltIteratorBatch is the number of pairs an iterator reads in one
+	// transaction, so that a long iteration does not hold a transaction.
+	boltIteratorBatch = 1024
+)
+
+var boltBucket = []byte("pd")
+
+// errBoltNotFound is returned by Load if the key is not found, as goleveldb
+// does.
+var errBoltNotFound = errors.New("bbolt: not found")
+
+type boltKV struct {
+	db *bolt.DB
+}
+
+// newBoltKV opens the bbolt database in the directory.
+func newBoltKV(path string) (*boltKV, error) {
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	db, err := bolt.Open(filepath.Join(path, boltFileName), 0600, &bolt.Options{Timeout: boltOpenTimeout})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, errors.WithStack(err)
+	}
+	return &boltKV{db: db}, nil
+}
+
+func (kv *boltKV) Load(key string) (string, error) {
+	var value string
+	err := kv.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltBucket).Get([]byte(key))
+		if v == nil {
+			return errBoltNotFound
+		}
+		value = string(v)
+		return nil
+	})
+	return value, errors.WithStack(err)
+}
+
+func (kv *boltKV) LoadRange(startKey, endKey string, limit int) ([]string, error) {
+	iter := kv.NewIterator(startKey, endKey)
+	defer iter.Release()
+	var values []string
+	for len(values) < limit && iter.Next() {
+		values = append(values, iter.Value())
+	}
+	return values, iter.Error()
+}
+
+func (kv *boltKV) Save(key, value string) error {
+	return errors.WithStack(kv.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put([]byte(key), []byte(value))
+	}))
+}
+
+func (kv *boltKV) Delete(key string) error {
+	return errors.WithStack(kv.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Delete([]byte(key))
+	}))
+}
+
+func (kv *boltKV) Write(batch *KVBatch) error {
+	return errors.WithStack(kv.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltBucket)
+		for _, op := range batch.ops {
+			var err error
+			if op.delete {
+				err = b.Delete([]byte(op.key))
+			} else {
+				err = b.Put([]byte(op.key), []byte(op.value))
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+func (kv *boltKV) NewIterator(startKey, endKey string) KVIterator {
+	return &boltIterator{
+		db:     kv.db,
+		next:   []byte(startKey),
+		endKey: []byte(endKey),
+	}
+}
+
+func (kv *boltKV) Close() error {
+	return errors.WithStack(kv.db.Close())
+}
+
+// boltIterator reads the pairs in batches. A bbolt read transaction blocks
+// the remapping of the database file, so it is not held across batches.
+type boltIterator struct {
+	db     *bolt.DB
+	next   []byte
+	endKey []byte
+	done   bool
+
+	keys   []string
+	values []string
+	pos    int
+	err    error
+}
+
+func (it *boltIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	it.pos++
+	if it.pos < len(it.keys) {
+		return true
+	}
+	if it.done {
+		return false
+	}
+	it.keys, it.values, it.pos = it.keys[:0], it.values[:0], 0
+	it.err = errors.WithStack(it.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltBucket).Cursor()
+		k, v := c.Seek(it.next)
+		for ; k != nil && len(it.keys) < boltIteratorBatch; k, v = c.Next() {
+			if len(it.endKey) > 0 && bytes.Compare(k, it.endKey) >= 0 {
+				break
+			}
+			it.keys = append(it.keys, string(k))
+			it.values = append(it.values, string(v))
+		}
+		if k == nil || (len(it.endKey) > 0 && bytes.Compare(k, it.endKey) >= 0) {
+			it.done = true
+		} else {
+			it.next = append([]byte(nil), k...)
+		}
+		return nil
+	}))
+	return it.err == nil && len(it.keys) > 0
+}
+
+func (it *boltIterator) Key() string {
+	return it.keys[it.pos]
+}
+
+func (it *boltIterator) Value() string {
+	return it.values[it.pos]
+}
+
+func (it *boltIterator) Error() error {
+	return it.err
+}
+
+func (it *boltIterator) Release() {
+	it.keys, it.values = nil, nil
+	it.done = true
+}
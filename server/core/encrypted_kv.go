@@ -0,0 +1,190 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"sync"
+
+	"github.com/pingcap/pd/pkg/encryption"
+)
+
+// encryptedKV encrypts the values of a storage engine with the master key.
+// Values are bound to their keys, so they can not be swapped. Plaintext values
+// are still readable, so the encryption can be enabled on an existing storage.
+type encryptedKV struct {
+	KVEngine
+	keys *encryption.KeyManager
+
+	// mu serializes writes with the re-encryption, so that a value written
+	// during it is not overwritten by the old one.
+	mu sync.Mutex
+	// cursor is the key the re-encryption continues from.
+	cursor string
+}
+
+// NewEncryptedKVEngine wraps the engine to encrypt values with the master key.
+// Values encrypted by any master key of the manager can be read, and a nil
+// manager reports an error on encrypted values.
+func NewEncryptedKVEngine(engine KVEngine, keys *encryption.KeyManager) KVEngine {
+	return newEncryptedKV(engine, keys)
+}
+
+func newEncryptedKV(engine KVEngine, keys *encryption.KeyManager) *encryptedKV {
+	return &encryptedKV{KVEngine: engine, keys: keys}
+}
+
+func (kv *encryptedKV) encrypt(key, value string) (string, error) {
+	v, err := kv.keys.Encrypt([]byte(value), []byte(key))
+	return string(v), err
+}
+
+func (kv *encryptedKV) decrypt(key, value string) (string, error) {
+	v, err := kv.keys.Decrypt([]byte(value), []byte(key))
+	return string(v), err
+}
+
+func (kv *encryptedKV) Load(key string) (string, error) {
+	value, err := kv.KVEngine.Load(key)
+	if err != nil || value == "" {
+		return value, err
+	}
+	return kv.decrypt(key, value)
+}
+
+func (kv *encryptedKV) LoadRange(startKey, endKey string, limit int) ([]string, error) {
+	iter := kv.NewIterator(startKey, endKey)
+	defer iter.Release()
+	var values []string
+	for len(values) < limit && iter.Next() {
+		values = append(values, iter.Value())
+	}
+	return values, iter.Error()
+}
+
+func (kv *encryptedKV) Save(key, value string) error {
+	value, err := kv.encrypt(key, value)
+	if err != nil {
+		return err
+	}
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	return kv.KVEngine.Save(key, value)
+}
+
+func (kv *encryptedKV) Delete(key string) error {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	return kv.KVEngine.Delete(key)
+}
+
+func (kv *encryptedKV) Write(batch *KVBatch) error {
+	encrypted := &KVBatch{ops: make([]kvOp, 0, len(batch.ops))}
+	for _, op := range batch.ops {
+		if !op.delete {
+			value, err := kv.encrypt(op.key, op.value)
+			if err != nil {
+				return err
+			}
+			op.value = value
+		}
+		encrypted.ops = append(encrypted.ops, op)
+	}
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	return kv.KVEngine.Write(encrypted)
+}
+
+func (kv *encryptedKV) NewIterator(startKey, endKey string) KVIterator {
+	return &encryptedIterator{KVIterator: kv.KVEngine.NewIterator(startKey, endKey), kv: kv}
+}
+
+// reencrypt scans at most limit pairs from the cursor, and re-encrypts the
+// values which are not encrypted by the current master key. It returns true
+// when the end of the storage is reached, and the number of re-encrypted
+// values.
+func (kv *encryptedKV) reencrypt(limit int) (bool, int, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	batch := &KVBatch{}
+	// The pairs are collected before writing, as an engine may not allow
+	// writes while an iterator is open.
+	iter := kv.KVEngine.NewIterator(kv.cursor, "")
+	cursor, done, scanned := kv.cursor, true, 0
+	for iter.Next() {
+		if scanned >= limit {
+			done = false
+			break
+		}
+		scanned++
+		key, value := iter.Key(), iter.Value()
+		cursor = key + "\x00"
+		if kv.keys.IsCurrent([]byte(value)) {
+			continue
+		}
+		plaintext, err := kv.decrypt(key, value)
+		if err != nil {
+			iter.Release()
+			return false, 0, err
+		}
+		encrypted, err := kv.encrypt(key, plaintext)
+		if err != nil {
+			iter.Release()
+			return false, 0, err
+		}
+		batch.Put(key, encrypted)
+	}
+	err := iter.Error()
+	iter.Release()
+	if err != nil {
+		return false, 0, err
+	}
+	if batch.Len() > 0 {
+		if err := kv.KVEngine.Write(batch); err != nil {
+			return false, 0, err
+		}
+	}
+	if done {
+		cursor = ""
+	}
+	kv.cursor = cursor
+	return done, batch.Len(), nil
+}
+
+// encryptedIterator decrypts the values of the iterator. It stops at the
+// first value which can not be decrypted, and reports the error.
+type encryptedIterator struct {
+	KVIterator
+	kv    *encryptedKV
+	value string
+	err   error
+}
+
+func (it *encryptedIterator) Next() bool {
+	if it.err != nil || !it.KVIterator.Next() {
+		return false
+	}
+	it.value, it.err = it.kv.decrypt(it.KVIterator.Key(), it.KVIterator.Value())
+	return it.err == nil
+}
+
+func (it *encryptedIterator) Value() string {
+	return it.value
+}
+
+func (it *encryptedIterator) Error() error {
+	if it.err != nil {
+		return it.err
+	}
+	return it.KVIterator.Error()
+}
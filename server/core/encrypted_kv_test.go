@@ -0,0 +1,140 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/pkg/encryption"
+)
+
+var _ = Suite(&testEncryptedKVSuite{})
+
+type testEncryptedKVSuite struct{}
+
+func mustNewKeyManager(c *C, key string) *encryption.KeyManager {
+	m, err := encryption.NewKeyManagerFromKey(strings.Repeat(key, 32))
+	c.Assert(err, IsNil)
+	return m
+}
+
+func (s *testEncryptedKVSuite) TestEncryptedKV(c *C) {
+	for _, name := range []string{LevelDBEngine, BoltEngine} {
+		dir, err := ioutil.TempDir("/tmp", "test_encrypted_kv")
+		c.Assert(err, IsNil)
+		engine, err := NewKVEngine(name, RegionStoragePath(dir, name))
+		c.Assert(err, IsNil)
+		// The encrypted engine behaves the same as a plain one.
+		(&testKVEngineSuite{}).testEngine(c, NewEncryptedKVEngine(engine, mustNewKeyManager(c, "01")))
+
+		value, err := engine.Load("k000001")
+		c.Assert(err, IsNil)
+		c.Assert(encryption.IsEncrypted([]byte(value)), IsTrue)
+		// It can not be read without the master key.
+		_, err = NewEncryptedKVEngine(engine, nil).Load("k000001")
+		c.Assert(err, NotNil)
+		_, err = NewEncryptedKVEngine(engine, nil).LoadRange("k", "", 10)
+		c.Assert(err, NotNil)
+		c.Assert(engine.Close(), IsNil)
+		os.RemoveAll(dir)
+	}
+}
+
+func (s *testEncryptedKVSuite) TestReencrypt(c *C) {
+	dir, err := ioutil.TempDir("/tmp", "test_encrypted_kv")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	engine, err := NewKVEngine(LevelDBEngine, dir)
+	c.Assert(err, IsNil)
+	defer engine.Close()
+
+	// Half of the values are plaintext, and the others are encrypted by the
+	// old key.
+	old := NewEncryptedKVEngine(engine, mustNewKeyManager(c, "01"))
+	for i := 0; i < 10; i++ {
+		kv := engine
+		if i%2 == 0 {
+			kv = old
+		}
+		c.Assert(kv.Save(fmt.Sprintf("k%d", i), fmt.Sprintf("v%d", i)), IsNil)
+	}
+
+	// Rotate to the new key, the old key is kept to decrypt values.
+	current := mustNewKeyManager(c, "02")
+	_, err = NewEncryptedKVEngine(engine, current).Load("k0")
+	c.Assert(err, NotNil)
+	c.Assert(os.Setenv("PD_TEST_MASTER_KEY", strings.Repeat("02", 32)), IsNil)
+	defer os.Unsetenv("PD_TEST_MASTER_KEY")
+	oldFile := dir + ".key"
+	c.Assert(ioutil.WriteFile(oldFile, []byte(strings.Repeat("01", 32)), 0600), IsNil)
+	defer os.Remove(oldFile)
+	keys, err := encryption.NewKeyManager(&encryption.Config{MasterKeyEnv: "PD_TEST_MASTER_KEY", PreviousMasterKeyFiles: []string{oldFile}})
+	c.Assert(err, IsNil)
+
+	kv := newEncryptedKV(engine, keys)
+	done, count, err := kv.reencrypt(4)
+	c.Assert(err, IsNil)
+	c.Assert(done, IsFalse)
+	c.Assert(count, Equals, 4)
+	for !done {
+		done, _, err = kv.reencrypt(4)
+		c.Assert(err, IsNil)
+	}
+	c.Assert(kv.cursor, Equals, "")
+	for i := 0; i < 10; i++ {
+		value, err := engine.Load(fmt.Sprintf("k%d", i))
+		c.Assert(err, IsNil)
+		c.Assert(current.IsCurrent([]byte(value)), IsTrue)
+		value, err = NewEncryptedKVEngine(engine, current).Load(fmt.Sprintf("k%d", i))
+		c.Assert(err, IsNil)
+		c.Assert(value, Equals, fmt.Sprintf("v%d", i))
+	}
+	done, count, err = kv.reencrypt(100)
+	c.Assert(err, IsNil)
+	c.Assert(done, IsTrue)
+	c.Assert(count, Equals, 0)
+}
+
+func (s *testEncryptedKVSuite) TestRegionKV(c *C) {
+	dir, err := ioutil.TempDir("/tmp", "test_region_kv")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	keys := mustNewKeyManager(c, "01")
+	kv, err := NewRegionKV(LevelDBEngine, dir, keys)
+	c.Assert(err, IsNil)
+	region := &metapb.Region{Id: 1, StartKey: []byte("user-key"), EndKey: []byte("user-key-end")}
+	c.Assert(kv.SaveRegion(region), IsNil)
+	c.Assert(kv.FlushRegion(), IsNil)
+	c.Assert(kv.Close(), IsNil)
+
+	// The region boundaries are not written in plaintext.
+	engine, err := NewKVEngine(LevelDBEngine, dir)
+	c.Assert(err, IsNil)
+	value, err := engine.Load(regionPath(1))
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(value, "user-key"), IsFalse)
+	c.Assert(engine.Close(), IsNil)
+
+	kv, err = NewRegionKV(LevelDBEngine, dir, keys)
+	c.Assert(err, IsNil)
+	defer kv.Close()
+	regions := NewRegionsInfo()
+	c.Assert(loadRegions(kv, regions), IsNil)
+	c.Assert(regions.GetRegion(1).GetMeta(), DeepEquals, region)
+}
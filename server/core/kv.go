@@ -20,6 +20,7 @@ import (
 	"path"
 	"strconv"
 	"sync/atomic"
+	"time"
 
 	"github.com/gogo/protobuf/proto"
 	"github.com/pingcap/kvproto/pkg/metapb"
@@ -27,10 +28,11 @@ import (
 )
 
 const (
-	clusterPath  = "raft"
-	configPath   = "config"
-	schedulePath = "schedule"
-	gcPath       = "gc"
+	clusterPath       = "raft"
+	configPath        = "config"
+	configHistoryPath = "config_history"
+	schedulePath      = "schedule"
+	gcPath            = "gc"
 )
 
 const (
@@ -94,6 +96,10 @@ func (kv *KV) storeRegionWeightPath(storeID uint64) string {
 	return path.Join(schedulePath, "store_weight", fmt.Sprintf("%020d", storeID), "region")
 }
 
+func (kv *KV) storeConfigPath(storeID uint64) string {
+	return path.Join(schedulePath, "store_config", fmt.Sprintf("%020d", storeID))
+}
+
 // LoadMeta loads cluster meta from KV store.
 func (kv *KV) LoadMeta(meta *metapb.Cluster) (bool, error) {
 	return loadProto(kv.KVBase, clusterPath, meta)
@@ -176,6 +182,70 @@ func (kv *KV) LoadConfig(cfg interface{}) (bool, error) {
 	return true, nil
 }
 
+func configHistoryEntryPath(version uint64) string {
+	return path.Join(configHistoryPath, "entry", fmt.Sprintf("%020d", version))
+}
+
+// SaveConfigHistory saves a marshalable config history entry of the version,
+// and records the version as the latest one.
+func (kv *KV) SaveConfigHistory(version uint64, entry interface{}) error {
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := kv.Save(configHistoryEntryPath(version), string(value)); err != nil {
+		return err
+	}
+	return kv.Save(path.Join(configHistoryPath, "version"), strconv.FormatUint(version, 10))
+}
+
+// LoadConfigHistory loads the config history entry of the version then
+// unmarshal it to entry.
+func (kv *KV) LoadConfigHistory(version uint64, entry interface{}) (bool, error) {
+	value, err := kv.Load(configHistoryEntryPath(version))
+	if err != nil {
+		return false, err
+	}
+	if value == "" {
+		return false, nil
+	}
+	if err := json.Unmarshal([]byte(value), entry); err != nil {
+		return false, errors.WithStack(err)
+	}
+	return true, nil
+}
+
+// LoadConfigHistoryVersion loads the latest version of config history. It
+// returns 0 if there is no history.
+func (kv *KV) LoadConfigHistoryVersion() (uint64, error) {
+	value, err := kv.Load(path.Join(configHistoryPath, "version"))
+	if err != nil || value == "" {
+		return 0, err
+	}
+	version, err := strconv.ParseUint(value, 10, 64)
+	return version, errors.WithStack(err)
+}
+
+// LoadConfigHistories loads the JSON encoded config history entries from
+// the start version, until the number of entries reaches the limit.
+func (kv *KV) LoadConfigHistories(startVersion uint64, limit int) ([]string, error) {
+	return kv.LoadRange(configHistoryEntryPath(startVersion), configHistoryEntryPath(math.MaxUint64), limit)
+}
+
+// RemoveConfigHistory removes the latest config history entry of the
+// version, and records the former version as the latest one.
+func (kv *KV) RemoveConfigHistory(version uint64) error {
+	if err := kv.Save(path.Join(configHistoryPath, "version"), strconv.FormatUint(version-1, 10)); err != nil {
+		return err
+	}
+	return kv.DeleteConfigHistory(version)
+}
+
+// DeleteConfigHistory deletes the config history entry of the version.
+func (kv *KV) DeleteConfigHistory(version uint64) error {
+	return kv.Delete(configHistoryEntryPath(version))
+}
+
 // LoadStores loads all stores from KV to StoresInfo.
 func (kv *KV) LoadStores(stores *StoresInfo) error {
 	nextID := uint64(0)
@@ -199,7 +269,11 @@ func (kv *KV) LoadStores(stores *StoresInfo) error {
 			if err != nil {
 				return err
 			}
-			newStoreInfo := NewStoreInfo(store, SetLeaderWeight(leaderWeight), SetRegionWeight(regionWeight))
+			config, err := kv.loadStoreConfig(store.GetId())
+			if err != nil {
+				return err
+			}
+			newStoreInfo := NewStoreInfo(store, SetLeaderWeight(leaderWeight), SetRegionWeight(regionWeight), SetStoreConfig(config))
 
 			nextID = store.GetId() + 1
 			stores.SetStore(newStoreInfo)
@@ -220,6 +294,31 @@ func (kv *KV) SaveStoreWeight(storeID uint64, leader, region float64) error {
 	return kv.Save(kv.storeRegionWeightPath(storeID), regionValue)
 }
 
+// SaveStoreConfig saves a store's schedule config overrides to KV. An empty
+// config is removed.
+func (kv *KV) SaveStoreConfig(storeID uint64, config *StoreConfig) error {
+	if config.IsEmpty() {
+		return kv.Delete(kv.storeConfigPath(storeID))
+	}
+	value, err := json.Marshal(config)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return kv.Save(kv.storeConfigPath(storeID), string(value))
+}
+
+func (kv *KV) loadStoreConfig(storeID uint64) (*StoreConfig, error) {
+	value, err := kv.Load(kv.storeConfigPath(storeID))
+	if err != nil || value == "" {
+		return nil, err
+	}
+	config := &StoreConfig{}
+	if err := json.Unmarshal([]byte(value), config); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return config, nil
+}
+
 func (kv *KV) loadFloatWithDefaultValue(path string, def float64) (float64, error) {
 	res, err := kv.Load(path)
 	if err != nil {
@@ -275,6 +374,97 @@ func (kv *KV) LoadGCSafePoint() (uint64, error) {
 	return safePoint, nil
 }
 
+// ServiceSafePoint is the safe point of a service, such as backup. GC can not
+// go beyond the minimal safe point of all services until they expire.
+type ServiceSafePoint struct {
+	ServiceID string `json:"service_id"`
+	ExpiredAt int64  `json:"expired_at"`
+	SafePoint uint64 `json:"safe_point"`
+}
+
+func serviceGCSafePointPath(serviceID string) string {
+	return path.Join(gcPath, "safe_point", "service", serviceID)
+}
+
+// SaveServiceGCSafePoint saves the safe point of a service to KV.
+func (kv *KV) SaveServiceGCSafePoint(ssp *ServiceSafePoint) error {
+	if ssp.ServiceID == "" {
+		return errors.New("service id of safe point cannot be empty")
+	}
+	value, err := json.Marshal(ssp)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return kv.Save(serviceGCSafePointPath(ssp.ServiceID), string(value))
+}
+
+// RemoveServiceGCSafePoint removes the safe point of a service from KV.
+func (kv *KV) RemoveServiceGCSafePoint(serviceID string) error {
+	return kv.Delete(serviceGCSafePointPath(serviceID))
+}
+
+// LoadAllServiceGCSafePoints loads the safe points of all services from KV.
+func (kv *KV) LoadAllServiceGCSafePoints() ([]*ServiceSafePoint, error) {
+	// The range covers every key under the service prefix, since '0' is next
+	// to '/'.
+	key := serviceGCSafePointPath("")
+	endKey := key + "0"
+	var ssps []*ServiceSafePoint
+	for {
+		res, err := kv.LoadRange(key, endKey, minKVRangeLimit)
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range res {
+			ssp := &ServiceSafePoint{}
+			if err := json.Unmarshal([]byte(value), ssp); err != nil {
+				return nil, errors.WithStack(err)
+			}
+			ssps = append(ssps, ssp)
+			key = serviceGCSafePointPath(ssp.ServiceID) + "\x00"
+		}
+		if len(res) < minKVRangeLimit {
+			return ssps, nil
+		}
+	}
+}
+
+// LoadMinServiceGCSafePoint returns the minimal safe point of the services
+// which are not expired at now. It returns nil if there is no such service.
+func (kv *KV) LoadMinServiceGCSafePoint(now time.Time) (*ServiceSafePoint, error) {
+	ssps, err := kv.LoadAllServiceGCSafePoints()
+	if err != nil {
+		return nil, err
+	}
+	var min *ServiceSafePoint
+	for _, ssp := range ssps {
+		if ssp.ExpiredAt < now.Unix() {
+			continue
+		}
+		if min == nil || ssp.SafePoint < min.SafePoint {
+			min = ssp
+		}
+	}
+	return min, nil
+}
+
+// RemoveExpiredServiceGCSafePoints removes the safe points of the services
+// which are expired at now.
+func (kv *KV) RemoveExpiredServiceGCSafePoints(now time.Time) error {
+	ssps, err := kv.LoadAllServiceGCSafePoints()
+	if err != nil {
+		return err
+	}
+	for _, ssp := range ssps {
+		if ssp.ExpiredAt < now.Unix() {
+			if err := kv.RemoveServiceGCSafePoint(ssp.ServiceID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func loadProto(kv KVBase, key string, msg proto.Message) (bool, error) {
 	value, err := kv.Load(key)
 	if err != nil {
@@ -0,0 +1,109 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// LevelDBEngine is the local storage engine based on goleveldb.
+	LevelDBEngine = "leveldb"
+	// BoltEngine is the local storage engine based on bbolt.
+	BoltEngine = "bbolt"
+)
+
+// KVEngine is a local storage engine, which supports batch writes and range
+// scans with iterators besides the KVBase operations.
+type KVEngine interface {
+	// KVBase of an engine returns an error from Load if the key is not found.
+	KVBase
+	// Write applies all operations of the batch atomically.
+	Write(batch *KVBatch) error
+	// NewIterator returns an iterator over the keys in [startKey, endKey). An
+	// empty endKey means no upper bound.
+	NewIterator(startKey, endKey string) KVIterator
+	Close() error
+}
+
+// KVIterator iterates over key-value pairs in key order. It must be released
+// after use.
+type KVIterator interface {
+	// Next moves to the next pair, it returns false if there is no more pair
+	// or an error occurs.
+	Next() bool
+	Key() string
+	Value() string
+	Error() error
+	Release()
+}
+
+type kvOp struct {
+	key    string
+	value  string
+	delete bool
+}
+
+// KVBatch collects the write operations to apply to a KVEngine.
+type KVBatch struct {
+	ops []kvOp
+}
+
+// Put adds an operation to save the value of the key.
+func (b *KVBatch) Put(key, value string) {
+	b.ops = append(b.ops, kvOp{key: key, value: value})
+}
+
+// Delete adds an operation to delete the key.
+func (b *KVBatch) Delete(key string) {
+	b.ops = append(b.ops, kvOp{key: key, delete: true})
+}
+
+// Len returns the number of operations in the batch.
+func (b *KVBatch) Len() int {
+	return len(b.ops)
+}
+
+// Reset clears the batch.
+func (b *KVBatch) Reset() {
+	b.ops = b.ops[:0]
+}
+
+// NewKVEngine opens the storage engine of the name at the path.
+func NewKVEngine(name, path string) (KVEngine, error) {
+	switch name {
+	case LevelDBEngine:
+		return newLeveldbKV(path)
+	case BoltEngine:
+		return newBoltKV(path)
+	}
+	return nil, errors.Errorf("unknown storage engine %q", name)
+}
+
+// IsKVEngineSupported returns true if the storage engine of the name exists.
+func IsKVEngineSupported(name string) bool {
+	return name == LevelDBEngine || name == BoltEngine
+}
+
+// RegionStoragePath returns the path of the region storage in the data
+// directory. Each engine uses its own path, so that regions can be migrated
+// between them.
+func RegionStoragePath(dataDir, engine string) string {
+	if engine == LevelDBEngine {
+		return filepath.Join(dataDir, "region-meta")
+	}
+	return filepath.Join(dataDir, "region-meta-"+engine)
+}
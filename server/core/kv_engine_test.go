@@ -0,0 +1,88 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	. "github.com/pingcap/check"
+)
+
+var _ = Suite(&testKVEngineSuite{})
+
+type testKVEngineSuite struct{}
+
+func (s *testKVEngineSuite) TestEngines(c *C) {
+	for _, name := range []string{LevelDBEngine, BoltEngine} {
+		dir, err := ioutil.TempDir("/tmp", "test_kv_engine")
+		c.Assert(err, IsNil)
+		engine, err := NewKVEngine(name, RegionStoragePath(dir, name))
+		c.Assert(err, IsNil)
+		s.testEngine(c, engine)
+		c.Assert(engine.Close(), IsNil)
+		os.RemoveAll(dir)
+	}
+	_, err := NewKVEngine("rocksdb", "")
+	c.Assert(err, NotNil)
+}
+
+func (s *testKVEngineSuite) testEngine(c *C, engine KVEngine) {
+	_, err := engine.Load("a")
+	c.Assert(err, NotNil)
+	c.Assert(engine.Save("a", "1"), IsNil)
+	v, err := engine.Load("a")
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, "1")
+	c.Assert(engine.Delete("a"), IsNil)
+	_, err = engine.Load("a")
+	c.Assert(err, NotNil)
+
+	// Write more pairs than an iterator batch of bbolt.
+	n := boltIteratorBatch*2 + 10
+	batch := &KVBatch{}
+	for i := 0; i < n; i++ {
+		batch.Put(fmt.Sprintf("k%06d", i), fmt.Sprintf("v%d", i))
+	}
+	batch.Delete("k000000")
+	c.Assert(batch.Len(), Equals, n+1)
+	c.Assert(engine.Write(batch), IsNil)
+
+	iter := engine.NewIterator("k", "")
+	count := 0
+	for iter.Next() {
+		count++
+		c.Assert(iter.Key(), Equals, fmt.Sprintf("k%06d", count))
+		c.Assert(iter.Value(), Equals, fmt.Sprintf("v%d", count))
+	}
+	c.Assert(iter.Error(), IsNil)
+	iter.Release()
+	c.Assert(count, Equals, n-1)
+
+	iter = engine.NewIterator("k000010", "k000020")
+	count = 0
+	for iter.Next() {
+		count++
+	}
+	iter.Release()
+	c.Assert(count, Equals, 10)
+
+	values, err := engine.LoadRange("k000100", "k999999", 5)
+	c.Assert(err, IsNil)
+	c.Assert(values, DeepEquals, []string{"v100", "v101", "v102", "v103", "v104"})
+	values, err = engine.LoadRange("k", "k000003", minKVRangeLimit)
+	c.Assert(err, IsNil)
+	c.Assert(values, DeepEquals, []string{"v1", "v2"})
+}
@@ -16,6 +16,7 @@ package core
 import (
 	"fmt"
 	"math"
+	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
@@ -117,6 +118,28 @@ func (s *testKVSuite) TestStoreWeight(c *C) {
 	}
 }
 
+func (s *testKVSuite) TestStoreConfig(c *C) {
+	kv := NewKV(NewMemoryKV())
+	const n = 3
+
+	mustSaveStores(c, kv, n)
+	lowSpaceRatio, maxSnapshotCount := 0.9, uint64(5)
+	c.Assert(kv.SaveStoreConfig(1, &StoreConfig{LowSpaceRatio: &lowSpaceRatio}), IsNil)
+	c.Assert(kv.SaveStoreConfig(2, &StoreConfig{MaxSnapshotCount: &maxSnapshotCount}), IsNil)
+	cache := NewStoresInfo()
+	c.Assert(kv.LoadStores(cache), IsNil)
+	c.Assert(cache.GetStore(0).GetConfig(), IsNil)
+	c.Assert(*cache.GetStore(1).GetConfig().LowSpaceRatio, Equals, lowSpaceRatio)
+	c.Assert(cache.GetStore(1).GetConfig().MaxSnapshotCount, IsNil)
+	c.Assert(*cache.GetStore(2).GetConfig().MaxSnapshotCount, Equals, maxSnapshotCount)
+
+	// Saving an empty config removes the overrides.
+	c.Assert(kv.SaveStoreConfig(1, &StoreConfig{}), IsNil)
+	cache = NewStoresInfo()
+	c.Assert(kv.LoadStores(cache), IsNil)
+	c.Assert(cache.GetStore(1).GetConfig(), IsNil)
+}
+
 func mustSaveRegions(c *C, kv *KV, n int) []*metapb.Region {
 	regions := make([]*metapb.Region, 0, n)
 	for i := 0; i < n; i++ {
@@ -174,6 +197,40 @@ func (s *testKVSuite) TestLoadGCSafePoint(c *C) {
 	}
 }
 
+func (s *testKVSuite) TestServiceGCSafePoint(c *C) {
+	kv := NewKV(NewMemoryKV())
+	now := time.Now()
+	ssps := []*ServiceSafePoint{
+		{ServiceID: "a", ExpiredAt: now.Unix() + 10, SafePoint: 3},
+		{ServiceID: "b", ExpiredAt: now.Unix() - 10, SafePoint: 1},
+		{ServiceID: "c", ExpiredAt: now.Unix() + 10, SafePoint: 2},
+	}
+	for _, ssp := range ssps {
+		c.Assert(kv.SaveServiceGCSafePoint(ssp), IsNil)
+	}
+	c.Assert(kv.SaveServiceGCSafePoint(&ServiceSafePoint{}), NotNil)
+	loaded, err := kv.LoadAllServiceGCSafePoints()
+	c.Assert(err, IsNil)
+	c.Assert(loaded, DeepEquals, ssps)
+
+	// The expired safe point of b is ignored, but not removed by reading.
+	min, err := kv.LoadMinServiceGCSafePoint(now)
+	c.Assert(err, IsNil)
+	c.Assert(min, DeepEquals, ssps[2])
+	loaded, err = kv.LoadAllServiceGCSafePoints()
+	c.Assert(err, IsNil)
+	c.Assert(loaded, HasLen, 3)
+	c.Assert(kv.RemoveExpiredServiceGCSafePoints(now), IsNil)
+	loaded, err = kv.LoadAllServiceGCSafePoints()
+	c.Assert(err, IsNil)
+	c.Assert(loaded, DeepEquals, []*ServiceSafePoint{ssps[0], ssps[2]})
+
+	c.Assert(kv.RemoveServiceGCSafePoint("c"), IsNil)
+	min, err = kv.LoadMinServiceGCSafePoint(now)
+	c.Assert(err, IsNil)
+	c.Assert(min, DeepEquals, ssps[0])
+}
+
 type KVWithMaxRangeLimit struct {
 	KVBase
 	rangeLimit int
@@ -14,10 +14,9 @@
 package core
 
 import (
-	"github.com/gogo/protobuf/proto"
-	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pkg/errors"
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
@@ -43,18 +42,13 @@ func (kv *leveldbKV) Load(key string) (string, error) {
 }
 
 func (kv *leveldbKV) LoadRange(startKey, endKey string, limit int) ([]string, error) {
-	iter := kv.db.NewIterator(&util.Range{Start: []byte(startKey), Limit: []byte(endKey)}, nil)
-	values := make([]string, 0, limit)
-	count := 0
-	for iter.Next() {
-		if count >= limit {
-			break
-		}
-		values = append(values, string(iter.Value()))
-		count++
+	iter := kv.NewIterator(startKey, endKey)
+	defer iter.Release()
+	var values []string
+	for len(values) < limit && iter.Next() {
+		values = append(values, iter.Value())
 	}
-	iter.Release()
-	return values, nil
+	return values, iter.Error()
 }
 
 func (kv *leveldbKV) Save(key, value string) error {
@@ -65,19 +59,50 @@ func (kv *leveldbKV) Delete(key string) error {
 	return errors.WithStack(kv.db.Delete([]byte(key), nil))
 }
 
-func (kv *leveldbKV) SaveRegions(regions map[string]*metapb.Region) error {
-	batch := new(leveldb.Batch)
-
-	for key, r := range regions {
-		value, err := proto.Marshal(r)
-		if err != nil {
-			return errors.WithStack(err)
+func (kv *leveldbKV) Write(batch *KVBatch) error {
+	b := new(leveldb.Batch)
+	for _, op := range batch.ops {
+		if op.delete {
+			b.Delete([]byte(op.key))
+		} else {
+			b.Put([]byte(op.key), []byte(op.value))
 		}
-		batch.Put([]byte(key), value)
 	}
-	return errors.WithStack(kv.db.Write(batch, nil))
+	return errors.WithStack(kv.db.Write(b, nil))
+}
+
+func (kv *leveldbKV) NewIterator(startKey, endKey string) KVIterator {
+	r := &util.Range{Start: []byte(startKey)}
+	if endKey != "" {
+		r.Limit = []byte(endKey)
+	}
+	return &leveldbIterator{iter: kv.db.NewIterator(r, nil)}
 }
 
 func (kv *leveldbKV) Close() error {
 	return errors.WithStack(kv.db.Close())
 }
+
+type leveldbIterator struct {
+	iter iterator.Iterator
+}
+
+func (it *leveldbIterator) Next() bool {
+	return it.iter.Next()
+}
+
+func (it *leveldbIterator) Key() string {
+	return string(it.iter.Key())
+}
+
+func (it *leveldbIterator) Value() string {
+	return string(it.iter.Value())
+}
+
+func (it *leveldbIterator) Error() error {
+	return errors.WithStack(it.iter.Error())
+}
+
+func (it *leveldbIterator) Release() {
+	it.iter.Release()
+}
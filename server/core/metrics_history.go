@@ -0,0 +1,124 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// MetricsHistoryPrefix is the prefix of the keys of the metrics history in
+// the storage engine.
+const MetricsHistoryPrefix = "metrics_history"
+
+// StoreMetrics is the metrics of a store at a time.
+type StoreMetrics struct {
+	StoreID     uint64  `json:"store_id"`
+	Capacity    uint64  `json:"capacity"`
+	Available   uint64  `json:"available"`
+	RegionCount int     `json:"region_count"`
+	LeaderCount int     `json:"leader_count"`
+	RegionSize  int64   `json:"region_size"`
+	LeaderSize  int64   `json:"leader_size"`
+	RegionScore float64 `json:"region_score"`
+	LeaderScore float64 `json:"leader_score"`
+	// BytesWritten and BytesRead are reported by the last store heartbeat.
+	BytesWritten uint64 `json:"bytes_written"`
+	BytesRead    uint64 `json:"bytes_read"`
+	// HotWriteFlow and HotReadFlow are the total flow bytes of the hot
+	// regions on the store.
+	HotWriteFlow uint64 `json:"hot_write_flow"`
+	HotReadFlow  uint64 `json:"hot_read_flow"`
+}
+
+// MetricsSample is the metrics of all stores at a time.
+type MetricsSample struct {
+	// Time is the unix time in seconds.
+	Time   int64           `json:"time"`
+	Stores []*StoreMetrics `json:"stores"`
+}
+
+func metricsHistoryPath(t int64) string {
+	return path.Join(MetricsHistoryPrefix, fmt.Sprintf("%020d", t))
+}
+
+// MetricsHistory keeps the metrics samples in a storage engine, and removes
+// the ones older than the retention, so that the storage is bounded like a
+// ring.
+type MetricsHistory struct {
+	kv KVEngine
+}
+
+// NewMetricsHistory creates a MetricsHistory in the storage engine, which can
+// be shared with other data as the keys have their own prefix.
+func NewMetricsHistory(kv KVEngine) *MetricsHistory {
+	return &MetricsHistory{kv: kv}
+}
+
+// Save saves the sample, and removes the samples older than the retention
+// before the time of the sample.
+func (h *MetricsHistory) Save(sample *MetricsSample, retention time.Duration) error {
+	value, err := json.Marshal(sample)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	batch := &KVBatch{}
+	expired := h.kv.NewIterator(metricsHistoryPath(0), metricsHistoryPath(sample.Time-int64(retention/time.Second)))
+	for expired.Next() {
+		batch.Delete(expired.Key())
+	}
+	err = expired.Error()
+	expired.Release()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	batch.Put(metricsHistoryPath(sample.Time), string(value))
+	return h.kv.Write(batch)
+}
+
+// Load loads the samples in [from, to] from the oldest. If step is larger
+// than 0, only the first sample in each step from the from time is returned.
+func (h *MetricsHistory) Load(from, to time.Time, step time.Duration) ([]*MetricsSample, error) {
+	iter := h.kv.NewIterator(metricsHistoryPath(from.Unix()), metricsHistoryPath(to.Unix()+1))
+	defer iter.Release()
+	samples := []*MetricsSample{}
+	next := from.Unix()
+	for iter.Next() {
+		t, err := strconv.ParseInt(path.Base(iter.Key()), 10, 64)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if t < next {
+			continue
+		}
+		sample := &MetricsSample{}
+		if err := json.Unmarshal([]byte(iter.Value()), sample); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		samples = append(samples, sample)
+		if s := int64(step / time.Second); s > 0 {
+			// Move to the step after the one of the sample.
+			next = t - (t-from.Unix())%s + s
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return samples, nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"io/ioutil"
+	"os"
+	"time"
+
+	. "github.com/pingcap/check"
+)
+
+var _ = Suite(&testMetricsHistorySuite{})
+
+type testMetricsHistorySuite struct{}
+
+func (s *testMetricsHistorySuite) TestMetricsHistory(c *C) {
+	dir, err := ioutil.TempDir("/tmp", "test_metrics_history")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	engine, err := NewKVEngine(LevelDBEngine, RegionStoragePath(dir, LevelDBEngine))
+	c.Assert(err, IsNil)
+	defer engine.Close()
+	c.Assert(engine.Save(regionPath(1), "region"), IsNil)
+
+	history := NewMetricsHistory(engine)
+	start := time.Unix(1555574400, 0)
+	for i := 0; i < 10; i++ {
+		sample := &MetricsSample{
+			Time:   start.Unix() + int64(i)*60,
+			Stores: []*StoreMetrics{{StoreID: 1, RegionCount: i}},
+		}
+		c.Assert(history.Save(sample, 5*time.Minute), IsNil)
+	}
+	// The samples before 5 minutes ago are removed.
+	samples, err := history.Load(start, start.Add(time.Hour), 0)
+	c.Assert(err, IsNil)
+	c.Assert(samples, HasLen, 6)
+	c.Assert(samples[0].Time, Equals, start.Unix()+4*60)
+	c.Assert(samples[5].Stores, DeepEquals, []*StoreMetrics{{StoreID: 1, RegionCount: 9}})
+
+	// Both ends are included.
+	samples, err = history.Load(start.Add(5*time.Minute), start.Add(7*time.Minute), 0)
+	c.Assert(err, IsNil)
+	c.Assert(samples, HasLen, 3)
+
+	// One sample every 2 minutes from 4m30s, which are the samples at 5m,
+	// 7m and 9m.
+	samples, err = history.Load(start.Add(4*time.Minute+30*time.Second), start.Add(time.Hour), 2*time.Minute)
+	c.Assert(err, IsNil)
+	c.Assert(samples, HasLen, 3)
+	for i, sample := range samples {
+		c.Assert(sample.Time, Equals, start.Unix()+int64(5+i*2)*60)
+	}
+
+	samples, err = history.Load(start.Add(time.Hour), start.Add(2*time.Hour), 0)
+	c.Assert(err, IsNil)
+	c.Assert(samples, HasLen, 0)
+
+	// Other data in the storage is kept.
+	v, err := engine.Load(regionPath(1))
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, "region")
+}
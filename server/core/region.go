@@ -20,6 +20,7 @@ import (
 	"math/rand"
 	"reflect"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gogo/protobuf/proto"
@@ -899,9 +900,44 @@ func DiffRegionKeyInfo(origin *RegionInfo, other *RegionInfo) string {
 	return strings.Join(ret, ", ")
 }
 
+// redactInfoLog is 1 if user keys are hidden in logs.
+var redactInfoLog int32
+
+// SetRedactInfoLog sets whether to replace user keys with "?" in logs, as they
+// may contain user data.
+func SetRedactInfoLog(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&redactInfoLog, v)
+}
+
+// redactRegionAPI is 1 if the keys of regions are hidden in the API output.
+var redactRegionAPI int32
+
+// SetRedactRegionAPI sets whether to replace the keys of regions with "?" in
+// the API output.
+func SetRedactRegionAPI(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&redactRegionAPI, v)
+}
+
+// IsRedactRegionAPI returns whether the keys of regions are hidden in the API
+// output.
+func IsRedactRegionAPI() bool {
+	return atomic.LoadInt32(&redactRegionAPI) == 1
+}
+
 // HexRegionKey converts region key to hex format. Used for formating region in
-// logs.
+// logs. It returns "?" if redact-info-log is enabled.
 func HexRegionKey(key []byte) []byte {
+	if atomic.LoadInt32(&redactInfoLog) == 1 {
+		return []byte("?")
+	}
 	return []byte(strings.ToUpper(hex.EncodeToString(key)))
 }
 
@@ -19,8 +19,10 @@ import (
 	"sync"
 	"time"
 
+	"github.com/gogo/protobuf/proto"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	log "github.com/pingcap/log"
+	"github.com/pingcap/pd/pkg/encryption"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 )
@@ -29,13 +31,15 @@ var dirtyFlushTick = time.Second
 
 // RegionKV is used to save regions.
 type RegionKV struct {
-	*leveldbKV
+	KVEngine
 	mu           sync.RWMutex
 	batchRegions map[string]*metapb.Region
 	batchSize    int
 	cacheSize    int
 	flushRate    time.Duration
 	flushTime    time.Time
+	encrypted    *encryptedKV
+	reencrypting bool
 	ctx          context.Context
 	cancel       context.CancelFunc
 }
@@ -45,17 +49,26 @@ const (
 	defaultFlushRegionRate = 3 * time.Second
 	//DefaultBatchSize is the batch size to save the regions to kv storage.
 	defaultBatchSize = 100
+	// reencryptBatchSize is the number of pairs scanned by the re-encryption
+	// in one flush tick.
+	reencryptBatchSize = 1000
 )
 
-// NewRegionKV returns a kv storage that is used to save regions.
-func NewRegionKV(path string) (*RegionKV, error) {
-	levelDB, err := newLeveldbKV(path)
+// NewRegionKV returns a kv storage that is used to save regions, with the
+// storage engine of the name at the path. Values are encrypted with the
+// current master key of keys if it is not nil, and the values which are not
+// encrypted by it are re-encrypted in the background.
+func NewRegionKV(engine, path string, keys *encryption.KeyManager) (*RegionKV, error) {
+	kvEngine, err := NewKVEngine(engine, path)
 	if err != nil {
 		return nil, err
 	}
+	encrypted := newEncryptedKV(kvEngine, keys)
 	ctx, cancel := context.WithCancel(context.Background())
 	kv := &RegionKV{
-		leveldbKV:    levelDB,
+		KVEngine:     encrypted,
+		encrypted:    encrypted,
+		reencrypting: keys != nil,
 		batchSize:    defaultBatchSize,
 		flushRate:    defaultFlushRegionRate,
 		batchRegions: make(map[string]*metapb.Region, defaultBatchSize),
@@ -78,6 +91,9 @@ func (kv *RegionKV) backgroundFlush() {
 		for {
 			select {
 			case <-ticker.C:
+				if kv.reencrypting {
+					kv.reencrypt()
+				}
 				kv.mu.RLock()
 				isFlush = kv.flushTime.Before(time.Now())
 				kv.mu.RUnlock()
@@ -94,6 +110,25 @@ func (kv *RegionKV) backgroundFlush() {
 	}()
 }
 
+// reencrypt re-encrypts a batch of values with the current master key. It
+// stops after a full pass of the storage, or an error which usually means a
+// value is encrypted by an unknown master key.
+func (kv *RegionKV) reencrypt() {
+	done, count, err := kv.encrypted.reencrypt(reencryptBatchSize)
+	if err != nil {
+		kv.reencrypting = false
+		log.Error("re-encrypt region storage meet error", zap.Error(err))
+		return
+	}
+	if count > 0 {
+		log.Info("re-encrypt region storage", zap.Int("count", count))
+	}
+	if done {
+		kv.reencrypting = false
+		log.Info("re-encrypt region storage finished")
+	}
+}
+
 // SaveRegion saves one region to KV.
 func (kv *RegionKV) SaveRegion(region *metapb.Region) error {
 	kv.mu.Lock()
@@ -164,6 +199,19 @@ func (kv *RegionKV) FlushRegion() error {
 	return kv.flush()
 }
 
+// SaveRegions saves the regions to the storage in one batch.
+func (kv *RegionKV) SaveRegions(regions map[string]*metapb.Region) error {
+	batch := &KVBatch{}
+	for key, r := range regions {
+		value, err := proto.Marshal(r)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		batch.Put(key, string(value))
+	}
+	return kv.Write(batch)
+}
+
 func (kv *RegionKV) flush() error {
 	if err := kv.SaveRegions(kv.batchRegions); err != nil {
 		return err
@@ -180,5 +228,5 @@ func (kv *RegionKV) Close() error {
 		log.Error("meet error before close the region storage", zap.Error(err))
 	}
 	kv.cancel()
-	return kv.db.Close()
+	return kv.KVEngine.Close()
 }
@@ -136,3 +136,18 @@ func (*testRegionKey) TestRegionKey(c *C) {
 		c.Assert(strings.Contains(s, t.expect), IsTrue)
 	}
 }
+
+func (*testRegionKey) TestRedactRegionKey(c *C) {
+	SetRedactInfoLog(true)
+	defer SetRedactInfoLog(false)
+	key := []byte("t\x80\x00\x00\x00\x00\x00\x00\xff")
+	c.Assert(HexRegionKey(key), DeepEquals, []byte("?"))
+	s := fmt.Sprintln(HexRegionMeta(&metapb.Region{StartKey: key, EndKey: key}))
+	c.Assert(strings.Contains(s, "7480"), IsFalse)
+
+	origin := NewRegionInfo(&metapb.Region{EndKey: key}, nil)
+	region := NewRegionInfo(&metapb.Region{StartKey: key, EndKey: key}, nil)
+	s = DiffRegionKeyInfo(origin, region)
+	c.Assert(s, Matches, ".*StartKey Changed.*")
+	c.Assert(strings.Contains(s, "7480"), IsFalse)
+}
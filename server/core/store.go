@@ -27,6 +27,83 @@ import (
 	"go.uber.org/zap"
 )
 
+// StoreConfig overrides the schedule config for a store. A nil field means
+// the global schedule config takes effect.
+type StoreConfig struct {
+	MaxSnapshotCount    *uint64  `json:"max-snapshot-count,omitempty"`
+	MaxPendingPeerCount *uint64  `json:"max-pending-peer-count,omitempty"`
+	LowSpaceRatio       *float64 `json:"low-space-ratio,omitempty"`
+	HighSpaceRatio      *float64 `json:"high-space-ratio,omitempty"`
+}
+
+// IsEmpty returns true if the config overrides nothing.
+func (c *StoreConfig) IsEmpty() bool {
+	return c == nil || (c.MaxSnapshotCount == nil && c.MaxPendingPeerCount == nil &&
+		c.LowSpaceRatio == nil && c.HighSpaceRatio == nil)
+}
+
+// GetMaxSnapshotCount returns the overridden max snapshot count, or def if it
+// is not overridden.
+func (c *StoreConfig) GetMaxSnapshotCount(def uint64) uint64 {
+	if c == nil || c.MaxSnapshotCount == nil {
+		return def
+	}
+	return *c.MaxSnapshotCount
+}
+
+// GetMaxPendingPeerCount returns the overridden max pending peer count, or
+// def if it is not overridden.
+func (c *StoreConfig) GetMaxPendingPeerCount(def uint64) uint64 {
+	if c == nil || c.MaxPendingPeerCount == nil {
+		return def
+	}
+	return *c.MaxPendingPeerCount
+}
+
+// GetLowSpaceRatio returns the overridden low space ratio, or def if it is not
+// overridden.
+func (c *StoreConfig) GetLowSpaceRatio(def float64) float64 {
+	if c == nil || c.LowSpaceRatio == nil {
+		return def
+	}
+	return *c.LowSpaceRatio
+}
+
+// GetHighSpaceRatio returns the overridden high space ratio, or def if it is
+// not overridden.
+func (c *StoreConfig) GetHighSpaceRatio(def float64) float64 {
+	if c == nil || c.HighSpaceRatio == nil {
+		return def
+	}
+	return *c.HighSpaceRatio
+}
+
+// Clone returns a deep copy of the config, so changing it does not change
+// the config in use.
+func (c *StoreConfig) Clone() *StoreConfig {
+	if c == nil {
+		return nil
+	}
+	config := &StoreConfig{}
+	if c.MaxSnapshotCount != nil {
+		v := *c.MaxSnapshotCount
+		config.MaxSnapshotCount = &v
+	}
+	if c.MaxPendingPeerCount != nil {
+		v := *c.MaxPendingPeerCount
+		config.MaxPendingPeerCount = &v
+	}
+	if c.LowSpaceRatio != nil {
+		v := *c.LowSpaceRatio
+		config.LowSpaceRatio = &v
+	}
+	if c.HighSpaceRatio != nil {
+		v := *c.HighSpaceRatio
+		config.HighSpaceRatio = &v
+	}
+	return config
+}
+
 // StoreInfo contains information about a store.
 type StoreInfo struct {
 	meta  *metapb.Store
@@ -41,6 +118,7 @@ type StoreInfo struct {
 	lastHeartbeatTS   time.Time
 	leaderWeight      float64
 	regionWeight      float64
+	config            *StoreConfig
 	rollingStoreStats *RollingStoreStats
 }
 
@@ -73,6 +151,7 @@ func (s *StoreInfo) Clone(opts ...StoreCreateOption) *StoreInfo {
 		lastHeartbeatTS:   s.lastHeartbeatTS,
 		leaderWeight:      s.leaderWeight,
 		regionWeight:      s.regionWeight,
+		config:            s.config,
 		rollingStoreStats: s.rollingStoreStats,
 	}
 
@@ -237,6 +316,12 @@ func (s *StoreInfo) GetRegionWeight() float64 {
 	return s.regionWeight
 }
 
+// GetConfig returns the schedule config overrides of the store, nil if there
+// is no override.
+func (s *StoreInfo) GetConfig() *StoreConfig {
+	return s.config
+}
+
 // GetLastHeartbeatTS returns the last heartbeat timestamp of the store.
 func (s *StoreInfo) GetLastHeartbeatTS() time.Time {
 	return s.lastHeartbeatTS
@@ -301,6 +386,28 @@ func (s *StoreInfo) RegionScore(highSpaceRatio, lowSpaceRatio float64, delta int
 	return score / math.Max(s.GetRegionWeight(), minWeight)
 }
 
+// The stages of the region score by the available space of the store.
+const (
+	HighSpaceStage       = "high-space"
+	TransitionSpaceStage = "transition"
+	LowSpaceStage        = "low-space"
+)
+
+// SpaceStage returns the stage RegionScore uses for the store: the score is
+// the region size in the high space stage, grows with the used space in the
+// low space stage, and is linear between them in the transition stage.
+func (s *StoreInfo) SpaceStage(highSpaceRatio, lowSpaceRatio float64) string {
+	available := float64(s.GetAvailable())
+	capacity := float64(s.GetCapacity())
+	if available >= (1-highSpaceRatio)*capacity {
+		return HighSpaceStage
+	}
+	if available <= (1-lowSpaceRatio)*capacity {
+		return LowSpaceStage
+	}
+	return TransitionSpaceStage
+}
+
 // StorageSize returns store's used storage size reported from tikv.
 func (s *StoreInfo) StorageSize() uint64 {
 	return s.GetUsedSize()
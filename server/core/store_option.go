@@ -114,6 +114,16 @@ func SetRegionWeight(regionWeight float64) StoreCreateOption {
 	}
 }
 
+// SetStoreConfig sets the schedule config overrides for the store.
+func SetStoreConfig(config *StoreConfig) StoreCreateOption {
+	return func(store *StoreInfo) {
+		if config.IsEmpty() {
+			config = nil
+		}
+		store.config = config
+	}
+}
+
 // SetLastHeartbeatTS sets the time of last heartbeat for the store.
 func SetLastHeartbeatTS(lastHeartbeatTS time.Time) StoreCreateOption {
 	return func(store *StoreInfo) {
@@ -0,0 +1,174 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"github.com/pingcap/errcode"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/namespace"
+	"github.com/pkg/errors"
+)
+
+// FailureImpact is the estimated impact if the stores matching a label
+// selector went down.
+type FailureImpact struct {
+	Selector     map[string]string `json:"selector"`
+	FailedStores []uint64          `json:"failed_stores"`
+	// AffectedRegions are the regions with peers on the failed stores.
+	AffectedRegions *RegionHealthBucket `json:"affected_regions"`
+	// LoseQuorum are the regions which would lose the majority of voters.
+	// Only the alive peers count, whose stores are up and which are not down.
+	LoseQuorum *RegionHealthBucket `json:"lose_quorum"`
+	// LoseLeader are the regions whose leaders are on the failed stores.
+	LoseLeader *RegionHealthBucket `json:"lose_leader"`
+	// BelowMaxReplicas are the regions which would have fewer alive peers
+	// than max-replicas.
+	BelowMaxReplicas *RegionHealthBucket `json:"below_max_replicas"`
+	// Isolation is the histogram of the isolation levels of the remaining
+	// peers of the affected regions, like the one of the region health.
+	Isolation map[string]*RegionHealthBucket `json:"isolation"`
+	// ReplicateSize is the approximate size in MB of the peers to re-replicate.
+	ReplicateSize int64 `json:"replicate_size"`
+	// AvailableSize is the size in MB the remaining up stores can take before
+	// they reach low-space-ratio.
+	AvailableSize int64 `json:"available_size"`
+	// CanAbsorb is true if AvailableSize is enough for ReplicateSize. It does
+	// not consider the location labels of the remaining stores.
+	CanAbsorb bool `json:"can_absorb"`
+}
+
+// matchLabels returns true if the store has all labels of the selector.
+func matchLabels(store *core.StoreInfo, selector map[string]string) bool {
+	for k, v := range selector {
+		if store.GetLabelValue(k) != v {
+			return false
+		}
+	}
+	return true
+}
+
+// simulateFailure estimates the impact if the stores matching the selector
+// went down, with at most samples region IDs in each bucket.
+func (c *clusterInfo) simulateFailure(selector map[string]string, classifier namespace.Classifier, samples int) *FailureImpact {
+	c.RLock()
+	defer c.RUnlock()
+	labels := c.GetLocationLabels()
+	impact := &FailureImpact{
+		Selector:         selector,
+		FailedStores:     []uint64{},
+		AffectedRegions:  &RegionHealthBucket{RegionIDs: []uint64{}},
+		LoseQuorum:       &RegionHealthBucket{RegionIDs: []uint64{}},
+		LoseLeader:       &RegionHealthBucket{RegionIDs: []uint64{}},
+		BelowMaxReplicas: &RegionHealthBucket{RegionIDs: []uint64{}},
+		Isolation:        newRegionHealth(labels).Isolation,
+	}
+	failed := make(map[uint64]struct{})
+	for _, store := range c.core.GetStores() {
+		if store.IsTombstone() {
+			continue
+		}
+		if matchLabels(store, selector) {
+			failed[store.GetID()] = struct{}{}
+			impact.FailedStores = append(impact.FailedStores, store.GetID())
+			continue
+		}
+		if !store.IsUp() || store.IsDisconnected() {
+			continue
+		}
+		lowSpaceRatio := store.GetConfig().GetLowSpaceRatio(c.opt.GetLowSpaceRatio())
+		reserved := uint64(float64(store.GetCapacity()) * (1 - lowSpaceRatio))
+		if available := store.GetAvailable(); available > reserved {
+			impact.AvailableSize += int64((available - reserved) >> 20)
+		}
+	}
+	if len(failed) == 0 {
+		impact.CanAbsorb = true
+		return impact
+	}
+
+	c.core.Regions.ScanRangeWithIterator(nil, func(meta *metapb.Region) bool {
+		region := c.core.Regions.GetRegion(meta.GetId())
+		if region == nil {
+			return true
+		}
+		var (
+			voters, aliveVoters int
+			failedPeers         int
+			aliveStores         []*core.StoreInfo
+		)
+		for _, peer := range region.GetPeers() {
+			_, isFailed := failed[peer.GetStoreId()]
+			if isFailed {
+				failedPeers++
+			}
+			store := c.core.GetStore(peer.GetStoreId())
+			alive := !isFailed && store != nil && store.IsUp() && !store.IsDisconnected() &&
+				region.GetDownPeer(peer.GetId()) == nil
+			if alive {
+				aliveStores = append(aliveStores, store)
+			}
+			if peer.GetIsLearner() {
+				continue
+			}
+			voters++
+			if alive {
+				aliveVoters++
+			}
+		}
+		if failedPeers == 0 {
+			return true
+		}
+		id := region.GetID()
+		impact.AffectedRegions.add(id, samples)
+		if aliveVoters < voters/2+1 {
+			impact.LoseQuorum.add(id, samples)
+		}
+		if _, ok := failed[region.GetLeader().GetStoreId()]; ok {
+			impact.LoseLeader.add(id, samples)
+		}
+		if len(aliveStores) < c.opt.GetMaxReplicas(classifier.GetRegionNamespace(region)) {
+			impact.BelowMaxReplicas.add(id, samples)
+		}
+		label := isolationLabel(labels, getRegionLabelIsolationLevel(aliveStores, labels))
+		if impact.Isolation[label] == nil {
+			impact.Isolation[label] = &RegionHealthBucket{RegionIDs: []uint64{}}
+		}
+		impact.Isolation[label].add(id, samples)
+		impact.ReplicateSize += int64(failedPeers) * region.GetApproximateSize()
+		return true
+	})
+	impact.CanAbsorb = impact.ReplicateSize <= impact.AvailableSize
+	return impact
+}
+
+// SimulateFailure estimates the impact if all stores matching the label
+// selector went down. It is a read-only calculation on the current regions
+// and stores.
+func (h *Handler) SimulateFailure(selector map[string]string, samples int) (*FailureImpact, error) {
+	c := h.s.GetRaftCluster()
+	if c == nil {
+		return nil, ErrNotBootstrapped
+	}
+	if len(selector) == 0 {
+		return nil, errcode.NewInvalidInputErr(errors.New("label selector is empty"))
+	}
+	c.RLock()
+	defer c.RUnlock()
+	impact := c.cachedCluster.simulateFailure(selector, h.s.classifier, samples)
+	if len(impact.FailedStores) == 0 {
+		return nil, errcode.NewInvalidInputErr(errors.Errorf("no store matches the label selector %v", selector))
+	}
+	return impact, nil
+}
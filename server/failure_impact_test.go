@@ -0,0 +1,94 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/pingcap/pd/server/core"
+)
+
+var _ = Suite(&testFailureImpactSuite{})
+
+type testFailureImpactSuite struct{}
+
+func (s *testFailureImpactSuite) TestSimulateFailure(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	opt.GetReplication().load().LocationLabels = []string{"zone", "host"}
+	tc := newTestClusterInfo(opt)
+
+	for id, labels := range map[uint64][2]string{1: {"z1", "h1"}, 2: {"z1", "h2"}, 3: {"z2", "h1"}, 4: {"z3", "h1"}} {
+		store := core.NewStoreInfo(&metapb.Store{
+			Id:     id,
+			Labels: []*metapb.StoreLabel{{Key: "zone", Value: labels[0]}, {Key: "host", Value: labels[1]}},
+		},
+			core.SetStoreStats(&pdpb.StoreStats{Capacity: 1000 << 20, Available: 500 << 20}),
+			core.SetLastHeartbeatTS(time.Now()),
+		)
+		c.Assert(tc.putStore(store), IsNil)
+	}
+	newRegion := func(id uint64, storeIDs ...uint64) *core.RegionInfo {
+		meta := newTestRegionMeta(id)
+		for i, storeID := range storeIDs {
+			meta.Peers = append(meta.Peers, &metapb.Peer{Id: id*10 + uint64(i), StoreId: storeID})
+		}
+		return core.NewRegionInfo(meta, meta.Peers[0], core.SetApproximateSize(10))
+	}
+	for _, region := range []*core.RegionInfo{
+		newRegion(1, 1, 3, 4),
+		newRegion(2, 3, 1, 2),
+		newRegion(3, 3, 4, 2),
+		newRegion(4, 3, 4),
+	} {
+		c.Assert(tc.putRegion(region), IsNil)
+	}
+
+	impact := tc.simulateFailure(map[string]string{"zone": "z1"}, mockClassifier{}, 10)
+	c.Assert(impact.FailedStores, HasLen, 2)
+	c.Assert(impact.AffectedRegions, DeepEquals, &RegionHealthBucket{Count: 3, RegionIDs: []uint64{1, 2, 3}})
+	c.Assert(impact.LoseQuorum, DeepEquals, &RegionHealthBucket{Count: 1, RegionIDs: []uint64{2}})
+	c.Assert(impact.LoseLeader, DeepEquals, &RegionHealthBucket{Count: 1, RegionIDs: []uint64{1}})
+	c.Assert(impact.BelowMaxReplicas.Count, Equals, 3)
+	c.Assert(impact.Isolation["zone"].Count, Equals, 3)
+	c.Assert(impact.ReplicateSize, Equals, int64(40))
+	// Each remaining store has 300MB before it reaches the low space ratio.
+	c.Assert(impact.AvailableSize, Equals, int64(600))
+	c.Assert(impact.CanAbsorb, IsTrue)
+
+	// A down peer counts against the quorum.
+	r4 := newRegion(5, 3, 4, 1)
+	r4 = r4.Clone(core.WithDownPeers([]*pdpb.PeerStats{{Peer: r4.GetPeers()[1], DownSeconds: 3600}}))
+	c.Assert(tc.putRegion(r4), IsNil)
+	impact = tc.simulateFailure(map[string]string{"zone": "z1", "host": "h1"}, mockClassifier{}, 1)
+	c.Assert(impact.FailedStores, DeepEquals, []uint64{1})
+	c.Assert(impact.LoseQuorum, DeepEquals, &RegionHealthBucket{Count: 1, RegionIDs: []uint64{5}})
+	c.Assert(impact.AffectedRegions, DeepEquals, &RegionHealthBucket{Count: 3, RegionIDs: []uint64{1}})
+
+	// A peer on a disconnected or offline store is not alive either.
+	c.Assert(tc.putStore(tc.GetStore(4).Clone(core.SetLastHeartbeatTS(time.Now().Add(-time.Minute)))), IsNil)
+	impact = tc.simulateFailure(map[string]string{"zone": "z1", "host": "h2"}, mockClassifier{}, 10)
+	c.Assert(impact.FailedStores, DeepEquals, []uint64{2})
+	c.Assert(impact.LoseQuorum, DeepEquals, &RegionHealthBucket{Count: 1, RegionIDs: []uint64{3}})
+	c.Assert(impact.BelowMaxReplicas, DeepEquals, &RegionHealthBucket{Count: 2, RegionIDs: []uint64{2, 3}})
+	c.Assert(tc.putStore(tc.GetStore(4).Clone(core.SetLastHeartbeatTS(time.Now()), core.SetStoreState(metapb.StoreState_Offline))), IsNil)
+	impact = tc.simulateFailure(map[string]string{"zone": "z1", "host": "h2"}, mockClassifier{}, 10)
+	c.Assert(impact.LoseQuorum, DeepEquals, &RegionHealthBucket{Count: 1, RegionIDs: []uint64{3}})
+
+	impact = tc.simulateFailure(map[string]string{"zone": "z4"}, mockClassifier{}, 10)
+	c.Assert(impact.FailedStores, HasLen, 0)
+}
@@ -0,0 +1,114 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/pingcap/errcode"
+	log "github.com/pingcap/log"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// UpdateServiceGCSafePoint updates the safe point of a service, which holds
+// back GC until it expires after ttl seconds. A service whose ttl is not
+// positive is removed. The safe point is not updated if it is less than the
+// minimal safe point of all services. It returns the minimal safe point of
+// all services after updating, the caller can compare it with the requested
+// one to know whether the update succeeded.
+func (s *Server) UpdateServiceGCSafePoint(serviceID string, ttl int64, safePoint uint64) (*core.ServiceSafePoint, error) {
+	if !s.IsLeader() {
+		return nil, errors.WithStack(notLeaderError)
+	}
+	if serviceID == "" || strings.Contains(serviceID, "/") {
+		return nil, errcode.NewInvalidInputErr(errors.Errorf("invalid service id %q", serviceID))
+	}
+
+	s.serviceSafePointLock.Lock()
+	defer s.serviceSafePointLock.Unlock()
+
+	now := time.Now()
+	if err := s.kv.RemoveExpiredServiceGCSafePoints(now); err != nil {
+		return nil, err
+	}
+	if ttl <= 0 {
+		if err := s.kv.RemoveServiceGCSafePoint(serviceID); err != nil {
+			return nil, err
+		}
+		log.Info("service gc safe point is removed", zap.String("service-id", serviceID))
+		return s.kv.LoadMinServiceGCSafePoint(now)
+	}
+
+	// The data before the GC safe point may have been collected already.
+	gcSafePoint, err := s.kv.LoadGCSafePoint()
+	if err != nil {
+		return nil, err
+	}
+	if safePoint < gcSafePoint {
+		return nil, errcode.NewInvalidInputErr(errors.Errorf("service safe point %d is less than the gc safe point %d", safePoint, gcSafePoint))
+	}
+
+	min, err := s.kv.LoadMinServiceGCSafePoint(now)
+	if err != nil {
+		return nil, err
+	}
+	if min != nil && safePoint < min.SafePoint && serviceID != min.ServiceID {
+		return min, nil
+	}
+	ssp := &core.ServiceSafePoint{
+		ServiceID: serviceID,
+		ExpiredAt: now.Unix() + ttl,
+		SafePoint: safePoint,
+	}
+	// A large ttl means the safe point never expires.
+	if ttl > math.MaxInt64-now.Unix() {
+		ssp.ExpiredAt = math.MaxInt64
+	}
+	if err := s.kv.SaveServiceGCSafePoint(ssp); err != nil {
+		return nil, err
+	}
+	log.Info("service gc safe point is updated",
+		zap.String("service-id", serviceID),
+		zap.Int64("expire-at", ssp.ExpiredAt),
+		zap.Uint64("safe-point", safePoint))
+	return s.kv.LoadMinServiceGCSafePoint(now)
+}
+
+// GetServiceGCSafePoints returns the safe points of all services which are
+// not expired.
+func (s *Server) GetServiceGCSafePoints() ([]*core.ServiceSafePoint, error) {
+	if !s.IsLeader() {
+		return nil, errors.WithStack(notLeaderError)
+	}
+
+	s.serviceSafePointLock.Lock()
+	defer s.serviceSafePointLock.Unlock()
+
+	all, err := s.kv.LoadAllServiceGCSafePoints()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	ssps := make([]*core.ServiceSafePoint, 0, len(all))
+	for _, ssp := range all {
+		if ssp.ExpiredAt >= now {
+			ssps = append(ssps, ssp)
+		}
+	}
+	return ssps, nil
+}
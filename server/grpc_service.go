@@ -647,7 +647,7 @@ func (s *Server) SyncRegions(stream pdpb.PD_SyncRegionsServer) error {
 	if cluster == nil {
 		return ErrNotBootstrapped
 	}
-	return s.cluster.regionSyncer.Sync(stream)
+	return s.cluster.GetRegionSyncer().Sync(stream)
 }
 
 // UpdateGCSafePoint implements gRPC PDServer.
@@ -661,12 +661,27 @@ func (s *Server) UpdateGCSafePoint(ctx context.Context, request *pdpb.UpdateGCSa
 		return &pdpb.UpdateGCSafePointResponse{Header: s.notBootstrappedHeader()}, nil
 	}
 
+	s.serviceSafePointLock.Lock()
+	defer s.serviceSafePointLock.Unlock()
+
 	oldSafePoint, err := s.kv.LoadGCSafePoint()
 	if err != nil {
 		return nil, err
 	}
 
 	newSafePoint := request.SafePoint
+	// GC can not go beyond the safe points of services.
+	minServiceSafePoint, err := s.kv.LoadMinServiceGCSafePoint(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if minServiceSafePoint != nil && newSafePoint > minServiceSafePoint.SafePoint {
+		log.Info("gc safe point is held back by service",
+			zap.String("service-id", minServiceSafePoint.ServiceID),
+			zap.Uint64("service-safe-point", minServiceSafePoint.SafePoint),
+			zap.Uint64("requested-safe-point", newSafePoint))
+		newSafePoint = minServiceSafePoint.SafePoint
+	}
 
 	// Only save the safe point if it's greater than the previous one
 	if newSafePoint > oldSafePoint {
@@ -18,6 +18,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"sort"
 	"strings"
 
 	log "github.com/pingcap/log"
@@ -35,12 +36,13 @@ const (
 	privateDirMode = 0700
 )
 
-// PrepareJoinCluster sends MemberAdd command to PD cluster,
+// PrepareJoinCluster sends MemberAddAsLearner command to PD cluster,
 // and returns the initial configuration of the PD cluster.
 //
 // TL;TR: The join functionality is safe. With data, join does nothing, w/o data
-//        and it is not a member of cluster, join does MemberAdd, it returns an
-//        error if PD tries to join itself, missing data or join a duplicated PD.
+//        and it is not a member of cluster, join does MemberAddAsLearner, it
+//        returns an error if PD tries to join itself, missing data or join a
+//        duplicated PD.
 //
 // Etcd automatically re-joins the cluster if there is a data directory. So
 // first it checks if there is a data directory or not. If there is, it returns
@@ -50,17 +52,26 @@ const (
 // If there is no data directory, there are following cases:
 //
 //  - A new PD joins an existing cluster.
-//      What join does: MemberAdd, MemberList, then generate initial-cluster.
+//      What join does: MemberAddAsLearner, MemberList, then generate
+//                      initial-cluster.
 //
 //  - A failed PD re-joins the previous cluster.
 //      What join does: return an error. (etcd reports: raft log corrupted,
 //                      truncated, or lost?)
 //
 //  - A deleted PD joins to previous cluster.
-//      What join does: MemberAdd, MemberList, then generate initial-cluster.
+//      What join does: MemberAddAsLearner, MemberList, then generate
+//                      initial-cluster.
 //                      (it is not in the member list and there is no data, so
 //                       we can treat it as a new PD.)
 //
+//  - A new PD joins as the learner added by the member replacement.
+//      What join does: MemberList, then generate initial-cluster. (the learner
+//                      with its peer URLs is added but not started.)
+//
+// The member is added as a learner, which does not count for quorum. After it
+// starts, the PD leader promotes it to a voter once it catches up.
+//
 // If there is a data directory, there are following special cases:
 //
 //  - A failed PD tries to join the previous cluster but it has been deleted
@@ -123,8 +134,14 @@ func PrepareJoinCluster(cfg *Config) error {
 	}
 
 	existed := false
+	var addedID uint64
 	for _, m := range listResp.Members {
 		if len(m.Name) == 0 {
+			// - A PD joins as the learner added by the member replacement.
+			if isSamePeerURLs(m.PeerURLs, cfg.AdvertisePeerUrls) {
+				addedID = m.ID
+				continue
+			}
 			return errors.New("there is a member that has not joined successfully")
 		}
 		if m.Name == cfg.Name {
@@ -139,9 +156,12 @@ func PrepareJoinCluster(cfg *Config) error {
 
 	// - A new PD joins an existing cluster.
 	// - A deleted PD joins to previous cluster.
-	addResp, err := etcdutil.AddEtcdMember(client, []string{cfg.AdvertisePeerUrls})
-	if err != nil {
-		return err
+	if addedID == 0 {
+		addResp, err := etcdutil.AddEtcdLearner(client, []string{cfg.AdvertisePeerUrls})
+		if err != nil {
+			return err
+		}
+		addedID = addResp.Member.ID
 	}
 
 	listResp, err = etcdutil.ListEtcdMembers(client)
@@ -152,7 +172,7 @@ func PrepareJoinCluster(cfg *Config) error {
 	pds := []string{}
 	for _, memb := range listResp.Members {
 		n := memb.Name
-		if memb.ID == addResp.Member.ID {
+		if memb.ID == addedID {
 			n = cfg.Name
 		}
 		if len(n) == 0 {
@@ -174,6 +194,24 @@ func PrepareJoinCluster(cfg *Config) error {
 	return errors.WithStack(err)
 }
 
+// isSamePeerURLs returns true if the peer URLs of the member equal to the
+// comma separated URLs.
+func isSamePeerURLs(peerURLs []string, urls string) bool {
+	expect := strings.Split(urls, ",")
+	if len(peerURLs) != len(expect) {
+		return false
+	}
+	a := append([]string(nil), peerURLs...)
+	sort.Strings(a)
+	sort.Strings(expect)
+	for i := range a {
+		if a[i] != expect[i] {
+			return false
+		}
+	}
+	return true
+}
+
 func isDataExist(d string) bool {
 	dir, err := os.Open(d)
 	if err != nil {
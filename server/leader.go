@@ -18,6 +18,7 @@ import (
 	"math/rand"
 	"path"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/pingcap/kvproto/pkg/pdpb"
@@ -41,6 +42,12 @@ func (s *Server) GetLeaderID() uint64 {
 	return s.GetLeader().GetMemberId()
 }
 
+// getLeaderTerm returns the etcd revision at which this server is elected as
+// the leader the last time.
+func (s *Server) getLeaderTerm() uint64 {
+	return atomic.LoadUint64(&s.leaderTerm)
+}
+
 // GetLeader returns current leader of PD cluster.
 func (s *Server) GetLeader() *pdpb.Member {
 	leader := s.leader.Load()
@@ -126,33 +133,11 @@ func (s *Server) etcdLeaderLoop() {
 
 	ctx, cancel := context.WithCancel(s.serverLoopCtx)
 	defer cancel()
+	backoff := newLeaderTransferBackoff(s.cfg.LeaderPriorityCheckInterval.Duration)
 	for {
 		select {
 		case <-time.After(s.cfg.LeaderPriorityCheckInterval.Duration):
-			etcdLeader := s.GetEtcdLeader()
-			if etcdLeader == s.ID() || etcdLeader == 0 {
-				break
-			}
-			myPriority, err := s.GetMemberLeaderPriority(s.ID())
-			if err != nil {
-				log.Error("failed to load leader priority", zap.Error(err))
-				break
-			}
-			leaderPriority, err := s.GetMemberLeaderPriority(etcdLeader)
-			if err != nil {
-				log.Error("failed to load leader priority", zap.Error(err))
-				break
-			}
-			if myPriority > leaderPriority {
-				err := s.etcd.Server.MoveLeader(ctx, etcdLeader, s.ID())
-				if err != nil {
-					log.Error("failed to transfer etcd leader", zap.Error(err))
-				} else {
-					log.Info("transfer etcd leader",
-						zap.Uint64("from", etcdLeader),
-						zap.Uint64("to", s.ID()))
-				}
-			}
+			s.checkEtcdLeader(ctx, backoff)
 		case <-ctx.Done():
 			log.Info("server is closed, exit etcd leader loop")
 			return
@@ -231,6 +216,7 @@ func (s *Server) campaignLeader() error {
 	if !resp.Succeeded {
 		return errors.New("campaign leader failed, other server may campaign ok")
 	}
+	atomic.StoreUint64(&s.leaderTerm, uint64(resp.Header.GetRevision()))
 
 	// Make the leader keepalived.
 	ctx, cancel = context.WithCancel(s.serverLoopCtx)
@@ -264,6 +250,12 @@ func (s *Server) campaignLeader() error {
 	s.enableLeader()
 	defer s.disableLeader()
 
+	// Record the config loaded by the leader, so that the changes made out of
+	// the config API, such as by the config file, are kept in history too.
+	if err := s.SaveConfigHistory("", "loaded by leader"); err != nil {
+		log.Error("failed to save config history", zap.Error(err))
+	}
+
 	log.Info("load cluster version", zap.Stringer("cluster-version", s.scheduleOpt.loadClusterVersion()))
 	log.Info("PD cluster leader is ready to serve", zap.String("leader-name", s.Name()))
 	CheckPDVersion(s.scheduleOpt)
@@ -310,8 +302,8 @@ func (s *Server) watchLeader(leader *pdpb.Member, revision int64) {
 		return
 	}
 	if s.scheduleOpt.loadPDServerConfig().UseRegionStorage {
-		s.cluster.regionSyncer.StartSyncWithLeader(leader.GetClientUrls()[0])
-		defer s.cluster.regionSyncer.StopSyncWithLeader()
+		s.cluster.GetRegionSyncer().StartSyncWithLeader(leader.GetClientUrls()[0])
+		defer s.cluster.GetRegionSyncer().StopSyncWithLeader()
 	}
 
 	// The revision is the revision of last modification on this key.
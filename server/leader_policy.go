@@ -0,0 +1,312 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/pingcap/errcode"
+	log "github.com/pingcap/log"
+	"github.com/pkg/errors"
+	"go.etcd.io/etcd/clientv3"
+	"go.uber.org/zap"
+)
+
+const (
+	// leaderSettleTicks is the number of check intervals to wait after the
+	// etcd leader changes, before transferring the leader by the policy.
+	leaderSettleTicks = 2
+	// leaderFlapTicks is the number of check intervals. If the member loses
+	// the leader within it after transferring the leader to itself, the
+	// transfer is regarded as a flap and the backoff grows.
+	leaderFlapTicks = 10
+	// maxLeaderBackoffTicks limits the backoff of transfers.
+	maxLeaderBackoffTicks = 32
+)
+
+// LeaderPolicy places the PD leader on members by their labels. A member
+// matching all required labels is preferred to one that does not, and then
+// a member matching more preferred labels is preferred. The leader priority
+// of members is compared at last.
+type LeaderPolicy struct {
+	Required  map[string]string `json:"required,omitempty"`
+	Preferred map[string]string `json:"preferred,omitempty"`
+}
+
+// IsEmpty returns true if the policy has no constraint.
+func (p *LeaderPolicy) IsEmpty() bool {
+	return p == nil || (len(p.Required) == 0 && len(p.Preferred) == 0)
+}
+
+// Validate checks the labels of the policy.
+func (p *LeaderPolicy) Validate() error {
+	for _, labels := range []map[string]string{p.Required, p.Preferred} {
+		for k, v := range labels {
+			if err := ValidateLabelString(k); err != nil {
+				return err
+			}
+			if err := ValidateLabelString(v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// leaderScore is the score of a member to be the leader.
+type leaderScore struct {
+	required  bool
+	preferred int
+	priority  int
+}
+
+func (p *LeaderPolicy) score(labels map[string]string, priority int) leaderScore {
+	score := leaderScore{required: true, priority: priority}
+	if p == nil {
+		return score
+	}
+	for k, v := range p.Required {
+		if labels[k] != v {
+			score.required = false
+		}
+	}
+	for k, v := range p.Preferred {
+		if labels[k] == v {
+			score.preferred++
+		}
+	}
+	return score
+}
+
+func (s leaderScore) less(other leaderScore) bool {
+	if s.required != other.required {
+		return other.required
+	}
+	if s.preferred != other.preferred {
+		return s.preferred < other.preferred
+	}
+	return s.priority < other.priority
+}
+
+func (s leaderScore) String() string {
+	return fmt.Sprintf("{required: %v, preferred: %d, priority: %d}", s.required, s.preferred, s.priority)
+}
+
+func (s *Server) getLeaderPolicyPath() string {
+	return path.Join(s.rootPath, "leader_policy")
+}
+
+// SetLeaderPolicy saves the leader policy. An empty policy removes it.
+func (s *Server) SetLeaderPolicy(policy *LeaderPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return errcode.NewInvalidInputErr(err)
+	}
+	key := s.getLeaderPolicyPath()
+	op := clientv3.OpDelete(key)
+	if !policy.IsEmpty() {
+		value, err := json.Marshal(policy)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		op = clientv3.OpPut(key, string(value))
+	}
+	res, err := s.leaderTxn().Then(op).Commit()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !res.Succeeded {
+		return errors.New("save leader policy failed, maybe not leader")
+	}
+	log.Info("leader policy is updated", zap.Reflect("policy", policy))
+	return nil
+}
+
+// GetLeaderPolicy loads the leader policy, it returns an empty policy if it
+// is not set.
+func (s *Server) GetLeaderPolicy() (*LeaderPolicy, error) {
+	policy := &LeaderPolicy{}
+	value, err := getValue(s.client, s.getLeaderPolicyPath())
+	if err != nil || value == nil {
+		return policy, err
+	}
+	if err := json.Unmarshal(value, policy); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return policy, nil
+}
+
+func (s *Server) getMemberLabelsPath(id uint64) string {
+	return path.Join(s.rootPath, fmt.Sprintf("member/%d/labels", id))
+}
+
+// saveMemberLabels publishes the labels of the member, so that other members
+// can check the leader policy.
+func (s *Server) saveMemberLabels() error {
+	key := s.getMemberLabelsPath(s.ID())
+	op := clientv3.OpDelete(key)
+	if len(s.cfg.Labels) > 0 {
+		value, err := json.Marshal(s.cfg.Labels)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		op = clientv3.OpPut(key, string(value))
+	}
+	_, err := s.txn().Then(op).Commit()
+	return errors.WithStack(err)
+}
+
+// GetMemberLabels loads the labels of a member.
+func (s *Server) GetMemberLabels(id uint64) (map[string]string, error) {
+	value, err := getValue(s.client, s.getMemberLabelsPath(id))
+	if err != nil || value == nil {
+		return nil, err
+	}
+	var labels map[string]string
+	if err := json.Unmarshal(value, &labels); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return labels, nil
+}
+
+// DeleteMemberLabels removes the labels of a removed member.
+func (s *Server) DeleteMemberLabels(id uint64) error {
+	res, err := s.leaderTxn().Then(clientv3.OpDelete(s.getMemberLabelsPath(id))).Commit()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !res.Succeeded {
+		return errors.New("delete member labels failed, maybe not leader")
+	}
+	return nil
+}
+
+func (s *Server) getLeaderScore(id uint64, policy *LeaderPolicy) (leaderScore, error) {
+	priority, err := s.GetMemberLeaderPriority(id)
+	if err != nil {
+		return leaderScore{}, err
+	}
+	labels, err := s.GetMemberLabels(id)
+	if err != nil {
+		return leaderScore{}, err
+	}
+	return policy.score(labels, priority), nil
+}
+
+// leaderTransferBackoff limits the transfers of the etcd leader to the member.
+// It waits for the cluster to settle after the leader changes if a leader
+// policy is set, and backs off if a transfer fails or the leader flaps back.
+type leaderTransferBackoff struct {
+	interval time.Duration
+
+	leader          uint64
+	leaderChangedAt time.Time
+	transferredAt   time.Time
+	next            time.Time
+	wait            time.Duration
+}
+
+func newLeaderTransferBackoff(interval time.Duration) *leaderTransferBackoff {
+	return &leaderTransferBackoff{interval: interval, wait: interval}
+}
+
+// observe records the current etcd leader. The backoff is reset once the
+// member keeps the leader transferred to it longer than a flap.
+func (b *leaderTransferBackoff) observe(self, leader uint64, now time.Time) {
+	if b.leader == self && !b.transferredAt.IsZero() && now.Sub(b.transferredAt) >= leaderFlapTicks*b.interval {
+		b.wait, b.transferredAt = b.interval, time.Time{}
+	}
+	if leader == b.leader {
+		return
+	}
+	if b.leader == self && now.Sub(b.transferredAt) < leaderFlapTicks*b.interval {
+		b.grow()
+		b.next = now.Add(b.wait)
+		log.Warn("etcd leader flaps, back off the leader transfer", zap.Duration("backoff", b.wait))
+	}
+	b.leader, b.leaderChangedAt = leader, now
+}
+
+// allow returns true if the member can try to transfer the leader to itself.
+// The new leader is only given time to settle if a leader policy is set, as
+// leader priorities alone should take effect without delay.
+func (b *leaderTransferBackoff) allow(now time.Time, hasPolicy bool) bool {
+	if hasPolicy && now.Sub(b.leaderChangedAt) < leaderSettleTicks*b.interval {
+		return false
+	}
+	return !now.Before(b.next)
+}
+
+// done records the result of a transfer.
+func (b *leaderTransferBackoff) done(err error, now time.Time) {
+	if err != nil {
+		b.grow()
+	} else {
+		b.transferredAt = now
+	}
+	b.next = now.Add(b.wait)
+}
+
+func (b *leaderTransferBackoff) grow() {
+	b.wait *= 2
+	if b.wait > maxLeaderBackoffTicks*b.interval {
+		b.wait = maxLeaderBackoffTicks * b.interval
+	}
+}
+
+// checkEtcdLeader transfers the etcd leader to the member if it is more
+// suitable than the current leader by the leader policy and priorities.
+func (s *Server) checkEtcdLeader(ctx context.Context, backoff *leaderTransferBackoff) {
+	now := time.Now()
+	etcdLeader := s.GetEtcdLeader()
+	backoff.observe(s.ID(), etcdLeader, now)
+	if etcdLeader == s.ID() || etcdLeader == 0 {
+		return
+	}
+	policy, err := s.GetLeaderPolicy()
+	if err != nil {
+		log.Error("failed to load leader policy", zap.Error(err))
+		return
+	}
+	if !backoff.allow(now, !policy.IsEmpty()) {
+		return
+	}
+	myScore, err := s.getLeaderScore(s.ID(), policy)
+	if err != nil {
+		log.Error("failed to load leader score", zap.Error(err))
+		return
+	}
+	leaderScore, err := s.getLeaderScore(etcdLeader, policy)
+	if err != nil {
+		log.Error("failed to load leader score", zap.Error(err))
+		return
+	}
+	if !leaderScore.less(myScore) {
+		return
+	}
+	err = s.etcd.Server.MoveLeader(ctx, etcdLeader, s.ID())
+	backoff.done(err, time.Now())
+	if err != nil {
+		log.Error("failed to transfer etcd leader", zap.Error(err))
+		return
+	}
+	log.Info("transfer etcd leader",
+		zap.Uint64("from", etcdLeader),
+		zap.Uint64("to", s.ID()),
+		zap.Stringer("from-score", leaderScore),
+		zap.Stringer("to-score", myScore))
+}
@@ -0,0 +1,108 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pkg/errors"
+)
+
+var _ = Suite(&testLeaderPolicySuite{})
+
+type testLeaderPolicySuite struct{}
+
+func (s *testLeaderPolicySuite) TestScore(c *C) {
+	policy := &LeaderPolicy{
+		Required:  map[string]string{"dc": "dc1"},
+		Preferred: map[string]string{"zone": "z1", "rack": "r1"},
+	}
+	c.Assert(policy.IsEmpty(), IsFalse)
+	c.Assert(policy.Validate(), IsNil)
+
+	dc1 := map[string]string{"dc": "dc1", "zone": "z2"}
+	dc1z1 := map[string]string{"dc": "dc1", "zone": "z1"}
+	dc2z1 := map[string]string{"dc": "dc2", "zone": "z1", "rack": "r1"}
+
+	// Required labels come first, then preferred labels, then priorities.
+	c.Assert(policy.score(dc2z1, 100).less(policy.score(dc1, 0)), IsTrue)
+	c.Assert(policy.score(dc1, 100).less(policy.score(dc1z1, 0)), IsTrue)
+	c.Assert(policy.score(dc1z1, 0).less(policy.score(dc1z1, 1)), IsTrue)
+	c.Assert(policy.score(dc1z1, 1).less(policy.score(dc1z1, 1)), IsFalse)
+	c.Assert(policy.score(nil, 1).less(policy.score(dc1, 0)), IsTrue)
+
+	// Without a policy, only priorities are compared.
+	var empty *LeaderPolicy
+	c.Assert(empty.IsEmpty(), IsTrue)
+	c.Assert(empty.score(dc2z1, 0).less(empty.score(nil, 1)), IsTrue)
+	c.Assert((&LeaderPolicy{}).score(dc1z1, 1).less(empty.score(nil, 1)), IsFalse)
+
+	c.Assert((&LeaderPolicy{Required: map[string]string{"dc": "a b"}}).Validate(), NotNil)
+}
+
+func (s *testLeaderPolicySuite) TestTransferBackoff(c *C) {
+	interval := time.Second
+	b := newLeaderTransferBackoff(interval)
+	now := time.Now()
+
+	// Wait for the new leader to settle only if a policy is set.
+	b.observe(1, 2, now)
+	c.Assert(b.allow(now, true), IsFalse)
+	c.Assert(b.allow(now, false), IsTrue)
+	now = now.Add(leaderSettleTicks * interval)
+	b.observe(1, 2, now)
+	c.Assert(b.allow(now, true), IsTrue)
+
+	// Failures back off exponentially.
+	b.done(errors.New("fail"), now)
+	c.Assert(b.wait, Equals, 2*interval)
+	c.Assert(b.allow(now.Add(interval), false), IsFalse)
+	now = now.Add(2 * interval)
+	c.Assert(b.allow(now, true), IsTrue)
+	for i := 0; i < 10; i++ {
+		b.done(errors.New("fail"), now)
+	}
+	c.Assert(b.wait, Equals, maxLeaderBackoffTicks*interval)
+
+	// The leader flaps back soon after a transfer.
+	b.wait = interval
+	b.done(nil, now)
+	b.observe(1, 1, now)
+	now = now.Add(interval)
+	b.observe(1, 2, now)
+	c.Assert(b.wait, Equals, 2*interval)
+	now = now.Add(leaderSettleTicks * interval)
+	c.Assert(b.allow(now, true), IsTrue)
+
+	// A leader change long after the transfer is not a flap.
+	b.done(nil, now)
+	b.observe(1, 1, now)
+	now = now.Add(leaderFlapTicks * interval)
+	b.observe(1, 3, now)
+	c.Assert(b.wait, Equals, interval)
+
+	// The backoff is reset once the transferred leader is stable.
+	b.wait = maxLeaderBackoffTicks * interval
+	b.done(nil, now)
+	b.observe(1, 1, now)
+	now = now.Add(interval)
+	b.observe(1, 1, now)
+	c.Assert(b.wait, Equals, maxLeaderBackoffTicks*interval)
+	now = now.Add(leaderFlapTicks * interval)
+	b.observe(1, 1, now)
+	c.Assert(b.wait, Equals, interval)
+	b.observe(1, 2, now.Add(interval))
+	c.Assert(b.wait, Equals, interval)
+}
@@ -0,0 +1,204 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/pingcap/errcode"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	log "github.com/pingcap/log"
+	"github.com/pingcap/pd/pkg/etcdutil"
+	"github.com/pingcap/pd/pkg/logutil"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// learnerCheckInterval is the interval for the leader to promote learners.
+const learnerCheckInterval = time.Second
+
+// MemberStatus is the raft status of a PD member.
+type MemberStatus struct {
+	Name       string   `json:"name"`
+	MemberID   uint64   `json:"member_id"`
+	PeerURLs   []string `json:"peer_urls"`
+	ClientURLs []string `json:"client_urls"`
+	// IsLearner is true if the member has joined but is not promoted to a
+	// voter yet.
+	IsLearner  bool   `json:"is_learner"`
+	Healthy    bool   `json:"healthy"`
+	EtcdLeader bool   `json:"etcd_leader"`
+	RaftTerm   uint64 `json:"raft_term"`
+	// RaftIndex is the committed index of the member.
+	RaftIndex        uint64 `json:"raft_index"`
+	RaftAppliedIndex uint64 `json:"raft_applied_index"`
+	// ApplyLag is the number of entries committed by the cluster but not
+	// applied by the member yet.
+	ApplyLag uint64 `json:"apply_lag"`
+	Error    string `json:"error,omitempty"`
+}
+
+// GetMembersStatus returns the raft status of all members. A member which
+// does not respond is unhealthy.
+func (s *Server) GetMembersStatus() ([]*MemberStatus, error) {
+	listResp, err := etcdutil.ListEtcdMembers(s.client)
+	if err != nil {
+		return nil, err
+	}
+	var committed uint64
+	members := make([]*MemberStatus, 0, len(listResp.Members))
+	for _, m := range listResp.Members {
+		member := &MemberStatus{
+			Name:       m.Name,
+			MemberID:   m.ID,
+			PeerURLs:   m.PeerURLs,
+			ClientURLs: m.ClientURLs,
+			IsLearner:  m.IsLearner,
+		}
+		resp, err := etcdutil.GetEtcdMemberStatus(s.client, m)
+		if err != nil {
+			member.Error = err.Error()
+		} else {
+			member.Healthy = true
+			member.EtcdLeader = resp.Leader == m.ID
+			member.RaftTerm = resp.RaftTerm
+			member.RaftIndex = resp.RaftIndex
+			member.RaftAppliedIndex = resp.RaftAppliedIndex
+			if resp.RaftIndex > committed {
+				committed = resp.RaftIndex
+			}
+		}
+		members = append(members, member)
+	}
+	for _, m := range members {
+		if m.Healthy && committed > m.RaftAppliedIndex {
+			m.ApplyLag = committed - m.RaftAppliedIndex
+		}
+	}
+	return members, nil
+}
+
+// ReplaceMember replaces the unhealthy member of the name with a learner of
+// the peer URLs. The new PD should start with the peer URLs and join the
+// cluster, then the leader promotes it once it catches up. The unhealthy
+// member is removed before the learner is added, as etcd refuses to add any
+// member while a voter is unreachable. The learner does not count for quorum,
+// so the healthy voters are a quorum all the time. It refuses to replace a
+// healthy member, or if the healthy voters are not a quorum.
+func (s *Server) ReplaceMember(name string, peerURLs []string) (*pdpb.Member, error) {
+	if len(peerURLs) == 0 {
+		return nil, errcode.NewInvalidInputErr(errors.New("peer urls of the new member are required"))
+	}
+	for _, u := range peerURLs {
+		if parsed, err := url.Parse(u); err != nil || parsed.Host == "" {
+			return nil, errcode.NewInvalidInputErr(errors.Errorf("invalid peer url %q", u))
+		}
+	}
+	members, err := s.GetMembersStatus()
+	if err != nil {
+		return nil, err
+	}
+	var old *MemberStatus
+	voters, healthy := 0, 0
+	for _, m := range members {
+		if m.Name == name {
+			old = m
+		}
+		if !m.IsLearner {
+			voters++
+			if m.Healthy {
+				healthy++
+			}
+		}
+		for _, u := range m.PeerURLs {
+			for _, newURL := range peerURLs {
+				if u == newURL {
+					return nil, errcode.NewInvalidInputErr(errors.Errorf("peer url %s is used by member %s", u, m.Name))
+				}
+			}
+		}
+	}
+	if old == nil {
+		return nil, errcode.NewNotFoundErr(errors.Errorf("member %s not found", name))
+	}
+	if old.Healthy || old.IsLearner {
+		return nil, errcode.NewInvalidInputErr(errors.Errorf("member %s is healthy or a learner, only an unhealthy voter can be replaced", name))
+	}
+	if healthy*2 <= voters {
+		return nil, errcode.NewInvalidInputErr(errors.Errorf("replacing may lose quorum, only %d of %d members are healthy", healthy, voters))
+	}
+
+	if err := s.DeleteMemberLeaderPriority(old.MemberID); err != nil {
+		return nil, err
+	}
+	if err := s.DeleteMemberLabels(old.MemberID); err != nil {
+		return nil, err
+	}
+	if _, err := etcdutil.RemoveEtcdMember(s.client, old.MemberID); err != nil {
+		return nil, err
+	}
+	addResp, err := etcdutil.AddEtcdLearner(s.client, peerURLs)
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("member %s is removed but failed to add the learner, start the new PD with join instead", name))
+	}
+	log.Info("replace member with learner", zap.String("name", name), zap.Uint64("old-id", old.MemberID),
+		zap.Uint64("new-id", addResp.Member.ID), zap.Strings("peer-urls", peerURLs))
+	return &pdpb.Member{
+		MemberId: addResp.Member.ID,
+		PeerUrls: addResp.Member.PeerURLs,
+	}, nil
+}
+
+func (s *Server) learnerLoop() {
+	defer logutil.LogPanic()
+	defer s.serverLoopWg.Done()
+
+	ctx, cancel := context.WithCancel(s.serverLoopCtx)
+	defer cancel()
+	for {
+		select {
+		case <-time.After(learnerCheckInterval):
+			if s.IsLeader() {
+				s.promoteLearners()
+			}
+		case <-ctx.Done():
+			log.Info("server is closed, exit learner loop")
+			return
+		}
+	}
+}
+
+// promoteLearners promotes the learners which have caught up with the etcd
+// leader.
+func (s *Server) promoteLearners() {
+	listResp, err := etcdutil.ListEtcdMembers(s.client)
+	if err != nil {
+		log.Error("failed to list members", zap.Error(err))
+		return
+	}
+	for _, m := range listResp.Members {
+		// A learner without a name has not started yet.
+		if !m.IsLearner || m.Name == "" {
+			continue
+		}
+		if _, err := etcdutil.PromoteEtcdLearner(s.client, m.ID); err != nil {
+			log.Debug("learner is not promoted", zap.String("name", m.Name), zap.Uint64("id", m.ID), zap.Error(err))
+			continue
+		}
+		log.Info("promote learner", zap.String("name", m.Name), zap.Uint64("id", m.ID))
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"sort"
+	"time"
+
+	log "github.com/pingcap/log"
+	"github.com/pingcap/pd/server/core"
+	"go.uber.org/zap"
+)
+
+// getMetricsSample returns the metrics of the stores which are not tombstone
+// at the time.
+func (c *RaftCluster) getMetricsSample(now time.Time) *core.MetricsSample {
+	var hotWrite, hotRead core.StoreHotRegionsStat
+	if infos := c.coordinator.getHotWriteRegions(); infos != nil {
+		hotWrite = infos.AsPeer
+	}
+	if infos := c.coordinator.getHotReadRegions(); infos != nil {
+		hotRead = infos.AsLeader
+	}
+	cluster := c.cachedCluster
+	sample := &core.MetricsSample{Time: now.Unix(), Stores: []*core.StoreMetrics{}}
+	for _, store := range cluster.GetStores() {
+		if store.IsTombstone() {
+			continue
+		}
+		id := store.GetID()
+		metrics := &core.StoreMetrics{
+			StoreID:      id,
+			Capacity:     store.GetCapacity(),
+			Available:    store.GetAvailable(),
+			RegionCount:  store.GetRegionCount(),
+			LeaderCount:  store.GetLeaderCount(),
+			RegionSize:   store.GetRegionSize(),
+			LeaderSize:   store.GetLeaderSize(),
+			RegionScore:  store.RegionScore(cluster.GetStoreHighSpaceRatio(id), cluster.GetStoreLowSpaceRatio(id), 0),
+			LeaderScore:  store.LeaderScore(0),
+			BytesWritten: store.GetBytesWritten(),
+			BytesRead:    store.GetBytesRead(),
+		}
+		if stat, ok := hotWrite[id]; ok {
+			metrics.HotWriteFlow = stat.TotalFlowBytes
+		}
+		if stat, ok := hotRead[id]; ok {
+			metrics.HotReadFlow = stat.TotalFlowBytes
+		}
+		sample.Stores = append(sample.Stores, metrics)
+	}
+	sort.Slice(sample.Stores, func(i, j int) bool { return sample.Stores[i].StoreID < sample.Stores[j].StoreID })
+	return sample
+}
+
+// recordMetricsHistory saves the metrics of the stores to the metrics history
+// if metrics-history-retention is not 0.
+func (c *RaftCluster) recordMetricsHistory(now time.Time) {
+	retention := c.s.scheduleOpt.loadPDServerConfig().MetricsHistoryRetention.Duration
+	if c.s.metricsHistory == nil || retention == 0 {
+		return
+	}
+	if err := c.s.metricsHistory.Save(c.getMetricsSample(now), retention); err != nil {
+		log.Error("save metrics history meet error", zap.Error(err))
+	}
+}
+
+// GetMetricsHistory returns the metrics of the stores in [from, to] from the
+// oldest, one sample in each step. The metrics are recorded only when the
+// server is the leader, so there may be gaps after the leader changes.
+func (h *Handler) GetMetricsHistory(from, to time.Time, step time.Duration) ([]*core.MetricsSample, error) {
+	if h.s.metricsHistory == nil {
+		return []*core.MetricsSample{}, nil
+	}
+	return h.s.metricsHistory.Load(from, to, step)
+}
@@ -0,0 +1,82 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/pingcap/pd/pkg/typeutil"
+	"github.com/pingcap/pd/server/core"
+)
+
+var _ = Suite(&testMetricsHistorySuite{})
+
+type testMetricsHistorySuite struct {
+	baseCluster
+}
+
+func (s *testMetricsHistorySuite) TestRecordMetricsHistory(c *C) {
+	var err error
+	var cleanup func()
+	_, s.svr, cleanup, err = NewTestServer(c)
+	c.Assert(err, IsNil)
+	defer cleanup()
+	mustWaitLeader(c, []*Server{s.svr})
+	req := s.newBootstrapRequest(c, s.svr.clusterID, "127.0.0.1:0")
+	_, err = s.svr.bootstrapCluster(req)
+	c.Assert(err, IsNil)
+	cluster := s.svr.GetRaftCluster()
+	c.Assert(cluster, NotNil)
+
+	// The tombstone store is not recorded.
+	tombstone := s.newStore(c, 0, "127.0.0.1:1")
+	tombstone.State = metapb.StoreState_Tombstone
+	c.Assert(cluster.putStore(tombstone), IsNil)
+	store := cluster.cachedCluster.GetStore(req.GetStore().GetId())
+	store = store.Clone(
+		core.SetStoreStats(&pdpb.StoreStats{Capacity: 100, Available: 60, BytesWritten: 10, BytesRead: 20}),
+		core.SetRegionCount(2),
+		core.SetLeaderCount(1),
+	)
+	c.Assert(cluster.cachedCluster.putStore(store), IsNil)
+
+	cluster.recordMetricsHistory(time.Unix(60, 0))
+	cluster.recordMetricsHistory(time.Unix(120, 0))
+	samples, err := s.svr.GetHandler().GetMetricsHistory(time.Unix(0, 0), time.Unix(120, 0), time.Minute)
+	c.Assert(err, IsNil)
+	c.Assert(samples, HasLen, 2)
+	c.Assert(samples[0].Time, Equals, int64(60))
+	c.Assert(samples[1].Time, Equals, int64(120))
+	c.Assert(samples[1].Stores, HasLen, 1)
+	metrics := samples[1].Stores[0]
+	c.Assert(metrics.StoreID, Equals, store.GetID())
+	c.Assert(metrics.Capacity, Equals, uint64(100))
+	c.Assert(metrics.Available, Equals, uint64(60))
+	c.Assert(metrics.RegionCount, Equals, 2)
+	c.Assert(metrics.LeaderCount, Equals, 1)
+	c.Assert(metrics.BytesWritten, Equals, uint64(10))
+	c.Assert(metrics.BytesRead, Equals, uint64(20))
+
+	// Nothing is recorded if the retention is 0.
+	cfg := *s.svr.scheduleOpt.loadPDServerConfig()
+	cfg.MetricsHistoryRetention = typeutil.NewDuration(0)
+	c.Assert(s.svr.SetPDServerConfig(cfg), IsNil)
+	cluster.recordMetricsHistory(time.Unix(180, 0))
+	samples, err = s.svr.GetHandler().GetMetricsHistory(time.Unix(0, 0), time.Unix(180, 0), time.Minute)
+	c.Assert(err, IsNil)
+	c.Assert(samples, HasLen, 2)
+}
@@ -0,0 +1,155 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/pingcap/errcode"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pkg/errors"
+)
+
+// notIsolated is the isolation bucket of the regions which are not isolated
+// at any location label.
+const notIsolated = "none"
+
+// regionStatisticTypeNames are the names of the region categories in the
+// health summary.
+var regionStatisticTypeNames = []struct {
+	typ  regionStatisticType
+	name string
+}{
+	{missPeer, "miss-peer"},
+	{extraPeer, "extra-peer"},
+	{downPeer, "down-peer"},
+	{pendingPeer, "pending-peer"},
+	{offlinePeer, "offline-peer"},
+	{incorrectNamespace, "incorrect-namespace"},
+	{learnerPeer, "learner-peer"},
+}
+
+// RegionHealthBucket is the number of regions in a bucket of the health
+// summary, with the IDs of some of them.
+type RegionHealthBucket struct {
+	Count     int      `json:"count"`
+	RegionIDs []uint64 `json:"sample_region_ids"`
+}
+
+// RegionHealth is the health summary of regions.
+type RegionHealth struct {
+	RegionCount int `json:"region_count"`
+	// Categories are the regions with miss, extra, down, pending, offline or
+	// learner peers, or peers in incorrect namespaces.
+	Categories map[string]*RegionHealthBucket `json:"categories"`
+	// LocationLabels are the location labels from the top level.
+	LocationLabels []string `json:"location_labels"`
+	// Isolation is the histogram of the isolation levels. A region is in the
+	// bucket of the top most location label its peers are isolated at, or in
+	// "none" if they are not isolated at any label.
+	Isolation map[string]*RegionHealthBucket `json:"isolation"`
+}
+
+func newRegionHealth(labels []string) *RegionHealth {
+	h := &RegionHealth{
+		Categories:     make(map[string]*RegionHealthBucket),
+		LocationLabels: labels,
+		Isolation:      make(map[string]*RegionHealthBucket),
+	}
+	for _, t := range regionStatisticTypeNames {
+		h.Categories[t.name] = &RegionHealthBucket{RegionIDs: []uint64{}}
+	}
+	h.Isolation[notIsolated] = &RegionHealthBucket{RegionIDs: []uint64{}}
+	for _, label := range labels {
+		h.Isolation[label] = &RegionHealthBucket{RegionIDs: []uint64{}}
+	}
+	return h
+}
+
+func (b *RegionHealthBucket) add(regionID uint64, samples int) {
+	b.Count++
+	if len(b.RegionIDs) < samples {
+		b.RegionIDs = append(b.RegionIDs, regionID)
+	}
+}
+
+// isolationLabel returns the isolation bucket of the level computed by
+// getRegionLabelIsolationLevel.
+func isolationLabel(labels []string, level int) string {
+	if level == 0 {
+		return notIsolated
+	}
+	if level <= len(labels) {
+		return labels[level-1]
+	}
+	return fmt.Sprintf("level-%d", level)
+}
+
+// getRegionHealth summarizes the regions which overlap [startKey, endKey)
+// and are accepted by the filter, with at most samples region IDs in each
+// bucket.
+func (c *clusterInfo) getRegionHealth(startKey, endKey []byte, filter func(*core.RegionInfo) bool, samples int) *RegionHealth {
+	c.RLock()
+	defer c.RUnlock()
+	labels := c.GetLocationLabels()
+	health := newRegionHealth(labels)
+	c.core.Regions.ScanRangeWithIterator(startKey, func(meta *metapb.Region) bool {
+		if len(endKey) > 0 && bytes.Compare(meta.GetStartKey(), endKey) >= 0 {
+			return false
+		}
+		region := c.core.Regions.GetRegion(meta.GetId())
+		if region == nil || !filter(region) {
+			return true
+		}
+		health.RegionCount++
+		if c.regionStats != nil {
+			types := c.regionStats.index[region.GetID()]
+			for _, t := range regionStatisticTypeNames {
+				if types&t.typ != 0 {
+					health.Categories[t.name].add(region.GetID(), samples)
+				}
+			}
+		}
+		if level, ok := c.labelLevelStats.regionLabelLevelStats[region.GetID()]; ok {
+			label := isolationLabel(labels, level)
+			if health.Isolation[label] == nil {
+				health.Isolation[label] = &RegionHealthBucket{RegionIDs: []uint64{}}
+			}
+			health.Isolation[label].add(region.GetID(), samples)
+		}
+		return true
+	})
+	return health
+}
+
+// GetRegionHealth returns the health summary of the regions which overlap
+// [startKey, endKey) in the namespace. An empty endKey means no upper bound,
+// and an empty namespace means all namespaces.
+func (h *Handler) GetRegionHealth(startKey, endKey []byte, namespace string, samples int) (*RegionHealth, error) {
+	c := h.s.GetRaftCluster()
+	if c == nil {
+		return nil, ErrNotBootstrapped
+	}
+	if namespace != "" && !h.s.classifier.IsNamespaceExist(namespace) {
+		return nil, errcode.NewInvalidInputErr(errors.Errorf("namespace %s does not exist", namespace))
+	}
+	filter := func(region *core.RegionInfo) bool {
+		return namespace == "" || h.s.classifier.GetRegionNamespace(region) == namespace
+	}
+	c.RLock()
+	defer c.RUnlock()
+	return c.cachedCluster.getRegionHealth(startKey, endKey, filter, samples), nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/pingcap/pd/server/core"
+)
+
+var _ = Suite(&testRegionHealthSuite{})
+
+type testRegionHealthSuite struct{}
+
+func (s *testRegionHealthSuite) TestRegionHealth(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	opt.GetReplication().load().LocationLabels = []string{"zone", "host"}
+	tc := newTestClusterInfo(opt)
+	tc.regionStats = newRegionStatistics(opt, mockClassifier{})
+
+	for id, labels := range map[uint64][2]string{1: {"z1", "h1"}, 2: {"z1", "h2"}, 3: {"z2", "h1"}, 4: {"z3", "h1"}} {
+		store := core.NewStoreInfo(&metapb.Store{
+			Id:     id,
+			Labels: []*metapb.StoreLabel{{Key: "zone", Value: labels[0]}, {Key: "host", Value: labels[1]}},
+		})
+		c.Assert(tc.putStore(store), IsNil)
+	}
+	newRegion := func(id uint64, storeIDs ...uint64) *core.RegionInfo {
+		meta := newTestRegionMeta(id)
+		for i, storeID := range storeIDs {
+			meta.Peers = append(meta.Peers, &metapb.Peer{Id: id*10 + uint64(i), StoreId: storeID})
+		}
+		return core.NewRegionInfo(meta, meta.Peers[0])
+	}
+	r1 := newRegion(1, 1, 3, 4)
+	r2 := newRegion(2, 1, 2)
+	r3 := newRegion(3, 1, 3, 4)
+	r3 = r3.Clone(core.WithDownPeers([]*pdpb.PeerStats{{Peer: r3.GetPeers()[1], DownSeconds: 3600}}))
+	regions := []*core.RegionInfo{r1, r2, r3}
+	for _, region := range regions {
+		c.Assert(tc.handleRegionHeartbeat(region), IsNil)
+	}
+	tc.updateRegionsLabelLevelStats(regions)
+
+	all := func(*core.RegionInfo) bool { return true }
+	health := tc.getRegionHealth(nil, nil, all, 10)
+	c.Assert(health.RegionCount, Equals, 3)
+	c.Assert(health.LocationLabels, DeepEquals, []string{"zone", "host"})
+	c.Assert(health.Categories["miss-peer"], DeepEquals, &RegionHealthBucket{Count: 1, RegionIDs: []uint64{2}})
+	c.Assert(health.Categories["down-peer"], DeepEquals, &RegionHealthBucket{Count: 1, RegionIDs: []uint64{3}})
+	c.Assert(health.Categories["extra-peer"].Count, Equals, 0)
+	c.Assert(health.Isolation["zone"], DeepEquals, &RegionHealthBucket{Count: 2, RegionIDs: []uint64{1, 3}})
+	c.Assert(health.Isolation["host"], DeepEquals, &RegionHealthBucket{Count: 1, RegionIDs: []uint64{2}})
+	c.Assert(health.Isolation[notIsolated].Count, Equals, 0)
+
+	// Samples are limited.
+	health = tc.getRegionHealth(nil, nil, all, 1)
+	c.Assert(health.Isolation["zone"], DeepEquals, &RegionHealthBucket{Count: 2, RegionIDs: []uint64{1}})
+
+	// Filter by the key range, regions overlapping the range are included.
+	health = tc.getRegionHealth(r2.GetStartKey(), append(r2.GetStartKey(), 0), all, 10)
+	c.Assert(health.RegionCount, Equals, 1)
+	c.Assert(health.Categories["miss-peer"].Count, Equals, 1)
+	c.Assert(health.Categories["down-peer"].Count, Equals, 0)
+
+	// Filter by the namespace.
+	health = tc.getRegionHealth(nil, nil, func(*core.RegionInfo) bool { return false }, 10)
+	c.Assert(health.RegionCount, Equals, 0)
+}
@@ -16,6 +16,7 @@ package syncer
 import (
 	"context"
 	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/pingcap/kvproto/pkg/pdpb"
@@ -24,17 +25,104 @@ import (
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
+// GetSyncLag returns how many history indexes and how long this follower
+// falls behind the leader. The duration is counted from the last time this
+// follower was in sync with the leader.
+func (s *RegionSyncer) GetSyncLag() (uint64, time.Duration) {
+	s.lag.RLock()
+	defer s.lag.RUnlock()
+	return s.lagLocked()
+}
+
+func (s *RegionSyncer) lagLocked() (uint64, time.Duration) {
+	if s.lag.syncedTime.IsZero() {
+		return 0, 0
+	}
+	index := s.history.GetNextIndex()
+	if index >= s.lag.leaderIndex {
+		return 0, 0
+	}
+	return s.lag.leaderIndex - index, time.Since(s.lag.syncedTime)
+}
+
+// updateLag records the latest index of the leader and updates the lag metrics.
+func (s *RegionSyncer) updateLag(leaderIndex uint64) {
+	s.lag.Lock()
+	defer s.lag.Unlock()
+	s.lag.leaderIndex = leaderIndex
+	if s.history.GetNextIndex() >= leaderIndex {
+		s.lag.syncedTime = time.Now()
+	}
+	index, duration := s.lagLocked()
+	regionSyncerStatus.WithLabelValues("follower_lag_index").Set(float64(index))
+	regionSyncerStatus.WithLabelValues("follower_lag_seconds").Set(duration.Seconds())
+}
+
+// getTargetIndex returns the index this follower reaches after it catches up
+// with the leader, which is sent in the header of the stream. It returns 0 if
+// the leader does not send it.
+func getTargetIndex(header metadata.MD) uint64 {
+	values := header.Get(syncTargetIndexKey)
+	if len(values) == 0 {
+		return 0
+	}
+	index, err := strconv.ParseUint(values[0], 10, 64)
+	if err != nil {
+		log.Warn("invalid sync target index", zap.String("index", values[0]), zap.Error(err))
+		return 0
+	}
+	return index
+}
+
+func (s *RegionSyncer) resetLag() {
+	s.lag.Lock()
+	defer s.lag.Unlock()
+	s.lag.leaderIndex, s.lag.syncedTime = 0, time.Time{}
+	regionSyncerStatus.WithLabelValues("follower_lag_index").Set(0)
+	regionSyncerStatus.WithLabelValues("follower_lag_seconds").Set(0)
+}
+
+// GetSyncedRegions returns the regions synchronized from the leader and how
+// long they fall behind the leader. It returns nil if the follower is not
+// receiving from the leader.
+func (s *RegionSyncer) GetSyncedRegions() (*SyncedRegions, time.Duration) {
+	s.synced.RLock()
+	defer s.synced.RUnlock()
+	if !s.synced.ready {
+		return nil, 0
+	}
+	_, lag := s.GetSyncLag()
+	return s.synced.regions, lag
+}
+
+func (s *RegionSyncer) setSyncedRegions(regions *SyncedRegions) {
+	s.synced.Lock()
+	defer s.synced.Unlock()
+	s.synced.regions, s.synced.ready = regions, false
+}
+
+func (s *RegionSyncer) setSyncedReady(ready bool) {
+	s.synced.Lock()
+	defer s.synced.Unlock()
+	s.synced.ready = ready
+}
+
 // StopSyncWithLeader stop to sync the region with leader.
 func (s *RegionSyncer) StopSyncWithLeader() {
 	s.reset()
+	s.resetLag()
 	s.Lock()
 	close(s.closed)
 	s.closed = make(chan struct{})
 	s.Unlock()
 	s.wg.Wait()
+	s.setSyncedRegions(nil)
 }
 
 func (s *RegionSyncer) reset() {
@@ -56,13 +144,22 @@ func (s *RegionSyncer) establish(addr string) (ClientStream, error) {
 		return nil, err
 	}
 
-	cc, err := grpc.Dial(u.Host, grpc.WithInsecure(), grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(msgSize)))
+	opt := grpc.WithInsecure()
+	tlsCfg, err := s.server.GetTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	if tlsCfg != nil {
+		opt = grpc.WithTransportCredentials(credentials.NewTLS(tlsCfg))
+	}
+	cc, err := grpc.Dial(u.Host, opt, grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(msgSize)))
 	if err != nil {
 		return nil, err
 	}
 
 	ctx, cancel := context.WithCancel(s.server.Context())
-	client, err := pdpb.NewPDClient(cc).SyncRegions(ctx)
+	// The leader compresses the responses with the same compressor.
+	client, err := pdpb.NewPDClient(cc).SyncRegions(ctx, grpc.UseCompressor(gzip.Name))
 	if err != nil {
 		cancel()
 		return nil, err
@@ -79,9 +176,64 @@ func (s *RegionSyncer) establish(addr string) (ClientStream, error) {
 	s.Lock()
 	s.ctx, s.cancel = ctx, cancel
 	s.Unlock()
+	// The lag is counted from the first connection until the follower
+	// catches up with the leader.
+	s.lag.Lock()
+	if s.lag.syncedTime.IsZero() {
+		s.lag.syncedTime = time.Now()
+	}
+	s.lag.Unlock()
 	return client, nil
 }
 
+// applyResponse saves the regions in the response and updates the lag. The
+// target index is the latest index of the leader until this follower reaches
+// it, after which the last index of a response is the latest one, as the
+// leader sends the regions once it records them. The synced regions are
+// served only after the target index is reached, so that a follower in the
+// middle of a catch-up does not serve a partial view. It returns the target
+// index which is not reached yet, or 0.
+func (s *RegionSyncer) applyResponse(resp *pdpb.SyncRegionResponse, synced *SyncedRegions, targetIndex uint64) uint64 {
+	if s.history.GetNextIndex() != resp.GetStartIndex() {
+		log.Warn("server sync index not match the leader",
+			zap.String("server", s.server.Name()),
+			zap.Uint64("own", s.history.GetNextIndex()),
+			zap.Uint64("leader", resp.GetStartIndex()),
+			zap.Int("records-length", len(resp.GetRegions())))
+		// reset index
+		s.history.ResetWithIndex(resp.GetStartIndex())
+	}
+	for _, r := range resp.GetRegions() {
+		if err := s.server.GetStorage().SaveRegion(r); err == nil {
+			s.history.Record(core.NewRegionInfo(r, nil))
+		}
+		synced.setRegion(r)
+	}
+	leaderIndex := resp.GetStartIndex() + uint64(len(resp.GetRegions()))
+	if targetIndex > leaderIndex {
+		leaderIndex = targetIndex
+	} else {
+		targetIndex = 0
+	}
+	s.updateLag(leaderIndex)
+	if targetIndex == 0 {
+		s.setSyncedReady(true)
+	}
+	return targetIndex
+}
+
+// applyTargetIndex serves the synced regions if this follower has already
+// reached the target index. It returns the target index which is not reached
+// yet, or 0.
+func (s *RegionSyncer) applyTargetIndex(targetIndex uint64) uint64 {
+	if s.history.GetNextIndex() < targetIndex {
+		return targetIndex
+	}
+	s.updateLag(targetIndex)
+	s.setSyncedReady(true)
+	return 0
+}
+
 // StartSyncWithLeader starts to sync with leader.
 func (s *RegionSyncer) StartSyncWithLeader(addr string) {
 	s.wg.Add(1)
@@ -90,6 +242,13 @@ func (s *RegionSyncer) StartSyncWithLeader(addr string) {
 	s.RUnlock()
 	go func() {
 		defer s.wg.Done()
+		// The view starts from the regions saved by the former syncs, and is
+		// served once the stream with the leader is established.
+		synced := newSyncedRegions()
+		if err := s.server.GetStorage().LoadRegions(synced.regions); err != nil {
+			log.Error("failed to load synced regions", zap.String("server", s.server.Name()), zap.Error(err))
+		}
+		s.setSyncedRegions(synced)
 		for {
 			select {
 			case <-closed:
@@ -109,31 +268,27 @@ func (s *RegionSyncer) StartSyncWithLeader(addr string) {
 				continue
 			}
 			log.Info("server starts to synchronize with leader", zap.String("server", s.server.Name()), zap.String("leader", s.server.GetLeader().GetName()), zap.Uint64("request-index", s.history.GetNextIndex()))
+			// The header is sent before any response, and it is the only
+			// message if this follower has caught up with the leader.
+			var targetIndex uint64
+			header, err := client.Header()
+			if err != nil {
+				log.Warn("failed to get the header of the sync stream", zap.Error(err))
+			} else if len(header.Get(syncTargetIndexKey)) > 0 {
+				targetIndex = s.applyTargetIndex(getTargetIndex(header))
+			}
 			for {
 				resp, err := client.Recv()
 				if err != nil {
 					log.Error("region sync with leader meet error", zap.Error(err))
+					s.setSyncedReady(false)
 					if err = client.CloseSend(); err != nil {
 						log.Error("failed to terminate client stream", zap.Error(err))
 					}
 					time.Sleep(time.Second)
 					break
 				}
-				if s.history.GetNextIndex() != resp.GetStartIndex() {
-					log.Warn("server sync index not match the leader",
-						zap.String("server", s.server.Name()),
-						zap.Uint64("own", s.history.GetNextIndex()),
-						zap.Uint64("leader", resp.GetStartIndex()),
-						zap.Int("records-length", len(resp.GetRegions())))
-					// reset index
-					s.history.ResetWithIndex(resp.GetStartIndex())
-				}
-				for _, r := range resp.GetRegions() {
-					err = s.server.GetStorage().SaveRegion(r)
-					if err == nil {
-						s.history.Record(core.NewRegionInfo(r, nil))
-					}
-				}
+				targetIndex = s.applyResponse(resp, synced, targetIndex)
 			}
 		}
 	}()
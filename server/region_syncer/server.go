@@ -15,7 +15,9 @@ package syncer
 
 import (
 	"context"
+	"crypto/tls"
 	"io"
+	"strconv"
 	"sync"
 	"time"
 
@@ -27,6 +29,7 @@ import (
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
@@ -35,12 +38,17 @@ const (
 	defaultBucketRate        = 20 * 1024 * 1024 // 20MB/s
 	defaultBucketCapacity    = 20 * 1024 * 1024 // 20MB
 	maxSyncRegionBatchSize   = 100
+	maxSyncRegionBatchBytes  = 1024 * 1024 // 1MB
 	syncerKeepAliveInterval  = 10 * time.Second
 	defaultHistoryBufferSize = 10000
+	// syncTargetIndexKey is the key of the stream header which carries the
+	// index the requested server reaches after it catches up with the leader.
+	syncTargetIndexKey = "sync-target-index"
 )
 
 // ClientStream is the client side of the region syncer.
 type ClientStream interface {
+	Header() (metadata.MD, error)
 	Recv() (*pdpb.SyncRegionResponse, error)
 	CloseSend() error
 }
@@ -59,6 +67,7 @@ type Server interface {
 	GetStorage() *core.KV
 	Name() string
 	GetMetaRegions() []*metapb.Region
+	GetTLSConfig() (*tls.Config, error)
 }
 
 // RegionSyncer is used to sync the region information without raft.
@@ -72,6 +81,19 @@ type RegionSyncer struct {
 	wg      sync.WaitGroup
 	history *historyBuffer
 	limit   *ratelimit.Bucket
+	// lag records how far this follower falls behind the leader.
+	lag struct {
+		sync.RWMutex
+		leaderIndex uint64
+		syncedTime  time.Time
+	}
+	// synced is the view of the regions synchronized from the leader. It is
+	// nil unless this server is a follower syncing with the leader.
+	synced struct {
+		sync.RWMutex
+		regions *SyncedRegions
+		ready   bool
+	}
 }
 
 // NewRegionSyncer returns a region syncer.
@@ -153,12 +175,21 @@ func (s *RegionSyncer) Sync(stream pdpb.PD_SyncRegionsServer) error {
 	}
 }
 
+// sendTargetIndex tells the requested server the index it reaches after the
+// history regions are sent, so that it knows how far it falls behind.
+func sendTargetIndex(stream pdpb.PD_SyncRegionsServer, index uint64) {
+	if err := stream.SendHeader(metadata.Pairs(syncTargetIndexKey, strconv.FormatUint(index, 10))); err != nil {
+		log.Warn("failed to send the sync target index", zap.Uint64("index", index), zap.Error(err))
+	}
+}
+
 func (s *RegionSyncer) syncHistoryRegion(request *pdpb.SyncRegionRequest, stream pdpb.PD_SyncRegionsServer) error {
 	startIndex := request.GetStartIndex()
 	name := request.GetMember().GetName()
 	records := s.history.RecordsFrom(startIndex)
 	if len(records) == 0 {
 		if s.history.GetNextIndex() == startIndex {
+			sendTargetIndex(stream, startIndex)
 			log.Info("requested server has already in sync with server",
 				zap.String("requested-server", name), zap.String("server", s.server.Name()), zap.Uint64("last-index", startIndex))
 			return nil
@@ -166,33 +197,20 @@ func (s *RegionSyncer) syncHistoryRegion(request *pdpb.SyncRegionRequest, stream
 		// do full synchronization
 		if startIndex == 0 {
 			regions := s.server.GetMetaRegions()
-			lastIndex := 0
+			sendTargetIndex(stream, uint64(len(regions)))
 			start := time.Now()
-			res := make([]*metapb.Region, 0, maxSyncRegionBatchSize)
-			for syncedIndex, r := range regions {
-				res = append(res, r)
-				if len(res) < maxSyncRegionBatchSize && syncedIndex < len(regions)-1 {
-					continue
-				}
-				resp := &pdpb.SyncRegionResponse{
-					Header:     &pdpb.ResponseHeader{ClusterId: s.server.ClusterID()},
-					Regions:    res,
-					StartIndex: uint64(lastIndex),
-				}
-				s.limit.Wait(int64(resp.Size()))
-				lastIndex += len(res)
-				if err := stream.Send(resp); err != nil {
-					log.Error("failed to send sync region response", zap.Error(err))
-				}
-				res = res[:0]
+			if err := s.sendRegionsInBatches(stream, regions, 0); err != nil {
+				return err
 			}
 			log.Info("requested server has completed full synchronization with server",
 				zap.String("requested-server", name), zap.String("server", s.server.Name()), zap.Duration("cost", time.Since(start)))
 			return nil
 		}
+		sendTargetIndex(stream, s.history.GetNextIndex())
 		log.Warn("no history regions from index, the leader may be restarted", zap.Uint64("index", startIndex))
 		return nil
 	}
+	sendTargetIndex(stream, startIndex+uint64(len(records)))
 	log.Info("sync the history regions with server",
 		zap.String("server", name),
 		zap.Uint64("from-index", startIndex),
@@ -202,12 +220,31 @@ func (s *RegionSyncer) syncHistoryRegion(request *pdpb.SyncRegionRequest, stream
 	for i, r := range records {
 		regions[i] = r.GetMeta()
 	}
-	resp := &pdpb.SyncRegionResponse{
-		Header:     &pdpb.ResponseHeader{ClusterId: s.server.ClusterID()},
-		Regions:    regions,
-		StartIndex: startIndex,
+	return s.sendRegionsInBatches(stream, regions, startIndex)
+}
+
+// sendRegionsInBatches sends the regions in batches which are no larger than
+// maxSyncRegionBatchBytes. The first region is at startIndex.
+func (s *RegionSyncer) sendRegionsInBatches(stream ServerStream, regions []*metapb.Region, startIndex uint64) error {
+	batchStart, batchBytes := 0, 0
+	for i, r := range regions {
+		batchBytes += r.Size()
+		if batchBytes < maxSyncRegionBatchBytes && i < len(regions)-1 {
+			continue
+		}
+		resp := &pdpb.SyncRegionResponse{
+			Header:     &pdpb.ResponseHeader{ClusterId: s.server.ClusterID()},
+			Regions:    regions[batchStart : i+1],
+			StartIndex: startIndex + uint64(batchStart),
+		}
+		s.limit.Wait(int64(resp.Size()))
+		if err := stream.Send(resp); err != nil {
+			log.Error("failed to send sync region response", zap.Error(err))
+			return err
+		}
+		batchStart, batchBytes = i+1, 0
 	}
-	return stream.Send(resp)
+	return nil
 }
 
 // bindStream binds the established server stream.
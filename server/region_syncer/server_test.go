@@ -0,0 +1,160 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncer
+
+import (
+	"time"
+
+	"github.com/juju/ratelimit"
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/pingcap/pd/server/core"
+	"google.golang.org/grpc/metadata"
+)
+
+var _ = Suite(&testRegionSyncerSuite{})
+
+type testRegionSyncerSuite struct{}
+
+type mockServer struct {
+	Server
+	kv *core.KV
+}
+
+func (s *mockServer) ClusterID() uint64 {
+	return 1
+}
+
+func (s *mockServer) Name() string {
+	return "mock"
+}
+
+func (s *mockServer) GetStorage() *core.KV {
+	return s.kv
+}
+
+type mockServerStream struct {
+	responses []*pdpb.SyncRegionResponse
+}
+
+func (s *mockServerStream) Send(resp *pdpb.SyncRegionResponse) error {
+	s.responses = append(s.responses, resp)
+	return nil
+}
+
+func (t *testRegionSyncerSuite) TestSendRegionsInBatches(c *C) {
+	s := &RegionSyncer{
+		server: &mockServer{},
+		limit:  ratelimit.NewBucketWithRate(defaultBucketRate, defaultBucketCapacity),
+	}
+	// Each region is about 1/4 of the batch.
+	var regions []*metapb.Region
+	for i := 0; i < 10; i++ {
+		regions = append(regions, &metapb.Region{Id: uint64(i), StartKey: make([]byte, maxSyncRegionBatchBytes/4)})
+	}
+
+	stream := &mockServerStream{}
+	c.Assert(s.sendRegionsInBatches(stream, regions, 100), IsNil)
+	c.Assert(stream.responses, HasLen, 3)
+	index := uint64(100)
+	for _, resp := range stream.responses {
+		c.Assert(resp.GetStartIndex(), Equals, index)
+		c.Assert(resp.GetRegions()[0].GetId(), Equals, index-100)
+		index += uint64(len(resp.GetRegions()))
+	}
+	c.Assert(index, Equals, uint64(110))
+}
+
+func (t *testRegionSyncerSuite) TestSyncLag(c *C) {
+	s := &RegionSyncer{history: newHistoryBuffer(10, core.NewMemoryKV())}
+	index, duration := s.GetSyncLag()
+	c.Assert(index, Equals, uint64(0))
+	c.Assert(duration, Equals, time.Duration(0))
+
+	s.lag.syncedTime = time.Now().Add(-time.Minute)
+	s.updateLag(5)
+	index, duration = s.GetSyncLag()
+	c.Assert(index, Equals, uint64(5))
+	c.Assert(duration >= time.Minute, IsTrue)
+
+	s.history.ResetWithIndex(5)
+	s.updateLag(5)
+	index, duration = s.GetSyncLag()
+	c.Assert(index, Equals, uint64(0))
+	c.Assert(duration, Equals, time.Duration(0))
+}
+
+func (t *testRegionSyncerSuite) TestApplyResponse(c *C) {
+	s := &RegionSyncer{
+		server:  &mockServer{kv: core.NewKV(core.NewMemoryKV())},
+		history: newHistoryBuffer(100, core.NewMemoryKV()),
+	}
+	s.lag.syncedTime = time.Now()
+	synced := newSyncedRegions()
+	s.setSyncedRegions(synced)
+	newResponse := func(startIndex uint64, ids ...uint64) *pdpb.SyncRegionResponse {
+		resp := &pdpb.SyncRegionResponse{StartIndex: startIndex}
+		for _, id := range ids {
+			resp.Regions = append(resp.Regions, &metapb.Region{Id: id, StartKey: []byte{byte(id)}, EndKey: []byte{byte(id + 1)}})
+		}
+		return resp
+	}
+
+	header := metadata.Pairs(syncTargetIndexKey, "5")
+	c.Assert(getTargetIndex(header), Equals, uint64(5))
+	c.Assert(getTargetIndex(metadata.MD{}), Equals, uint64(0))
+
+	// The follower falls behind the target index during the catch-up.
+	target := s.applyResponse(newResponse(0, 1, 2), synced, 5)
+	c.Assert(target, Equals, uint64(5))
+	index, _ := s.GetSyncLag()
+	c.Assert(index, Equals, uint64(3))
+	// The partial view is not served.
+	regions, _ := s.GetSyncedRegions()
+	c.Assert(regions, IsNil)
+	target = s.applyResponse(newResponse(2, 3, 4, 5), synced, target)
+	c.Assert(target, Equals, uint64(0))
+	index, _ = s.GetSyncLag()
+	c.Assert(index, Equals, uint64(0))
+	regions, _ = s.GetSyncedRegions()
+	c.Assert(regions, NotNil)
+	c.Assert(regions.GetRegionCount(), Equals, 5)
+
+	// After the catch-up, the responses carry the latest index of the leader.
+	target = s.applyResponse(newResponse(10, 6), synced, target)
+	c.Assert(target, Equals, uint64(0))
+	index, _ = s.GetSyncLag()
+	c.Assert(index, Equals, uint64(0))
+	c.Assert(s.history.GetNextIndex(), Equals, uint64(11))
+}
+
+func (t *testRegionSyncerSuite) TestApplyTargetIndex(c *C) {
+	s := &RegionSyncer{history: newHistoryBuffer(10, core.NewMemoryKV())}
+	s.setSyncedRegions(newSyncedRegions())
+
+	// The follower falls behind the leader.
+	s.history.ResetWithIndex(3)
+	c.Assert(s.applyTargetIndex(5), Equals, uint64(5))
+	regions, _ := s.GetSyncedRegions()
+	c.Assert(regions, IsNil)
+
+	// The follower has caught up, and the leader sends no response.
+	s.history.ResetWithIndex(5)
+	c.Assert(s.applyTargetIndex(5), Equals, uint64(0))
+	regions, _ = s.GetSyncedRegions()
+	c.Assert(regions, NotNil)
+	index, _ := s.GetSyncLag()
+	c.Assert(index, Equals, uint64(0))
+}
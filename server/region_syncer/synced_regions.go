@@ -0,0 +1,82 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncer
+
+import (
+	"sync"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/server/core"
+)
+
+// SyncedRegions is a read-only view of the regions a follower synchronizes
+// from the leader. The regions carry no leader or statistics, since only the
+// region meta is synchronized.
+type SyncedRegions struct {
+	sync.RWMutex
+	regions *core.RegionsInfo
+}
+
+func newSyncedRegions() *SyncedRegions {
+	return &SyncedRegions{regions: core.NewRegionsInfo()}
+}
+
+func (r *SyncedRegions) setRegion(region *metapb.Region) {
+	r.Lock()
+	defer r.Unlock()
+	r.regions.SetRegion(core.NewRegionInfo(region, nil))
+}
+
+// GetRegionInfoByID returns the region by region id.
+func (r *SyncedRegions) GetRegionInfoByID(regionID uint64) *core.RegionInfo {
+	r.RLock()
+	defer r.RUnlock()
+	return r.regions.GetRegion(regionID)
+}
+
+// GetRegionInfoByKey returns the region which contains the key.
+func (r *SyncedRegions) GetRegionInfoByKey(regionKey []byte) *core.RegionInfo {
+	r.RLock()
+	defer r.RUnlock()
+	return r.regions.SearchRegion(regionKey)
+}
+
+// ScanRegionsByKey scans regions from the start key, until the number of
+// regions reaches the limit.
+func (r *SyncedRegions) ScanRegionsByKey(startKey []byte, limit int) []*core.RegionInfo {
+	r.RLock()
+	defer r.RUnlock()
+	return r.regions.ScanRange(startKey, limit)
+}
+
+// GetRegions returns all regions.
+func (r *SyncedRegions) GetRegions() []*core.RegionInfo {
+	r.RLock()
+	defer r.RUnlock()
+	return r.regions.GetRegions()
+}
+
+// GetStoreRegions returns all regions which have a peer on the store.
+func (r *SyncedRegions) GetStoreRegions(storeID uint64) []*core.RegionInfo {
+	r.RLock()
+	defer r.RUnlock()
+	return r.regions.GetStoreRegions(storeID)
+}
+
+// GetRegionCount returns the number of regions.
+func (r *SyncedRegions) GetRegionCount() int {
+	r.RLock()
+	defer r.RUnlock()
+	return r.regions.GetRegionCount()
+}
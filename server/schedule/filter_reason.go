@@ -0,0 +1,149 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pingcap/pd/server/core"
+)
+
+// FilterReason is a filter which excludes a store, and why.
+type FilterReason struct {
+	Filter string `json:"filter"`
+	Reason string `json:"reason"`
+}
+
+// ExplainSource returns the filters which exclude the store as a source store
+// with the reasons.
+func ExplainSource(opt Options, store *core.StoreInfo, filters []Filter) []FilterReason {
+	reasons := []FilterReason{}
+	for _, filter := range filters {
+		if reason := filter.SourceReason(opt, store); reason != "" {
+			reasons = append(reasons, FilterReason{Filter: filter.Type(), Reason: reason})
+		}
+	}
+	return reasons
+}
+
+// ExplainTarget returns the filters which exclude the store as a target store
+// with the reasons.
+func ExplainTarget(opt Options, store *core.StoreInfo, filters []Filter) []FilterReason {
+	reasons := []FilterReason{}
+	for _, filter := range filters {
+		if reason := filter.TargetReason(opt, store); reason != "" {
+			reasons = append(reasons, FilterReason{Filter: filter.Type(), Reason: reason})
+		}
+	}
+	return reasons
+}
+
+// joinReasons joins the reasons which are not empty.
+func joinReasons(reasons ...string) string {
+	var res []string
+	for _, r := range reasons {
+		if r != "" {
+			res = append(res, r)
+		}
+	}
+	return strings.Join(res, "; ")
+}
+
+func excludedReason(stores map[uint64]struct{}, store *core.StoreInfo) string {
+	if _, ok := stores[store.GetID()]; !ok {
+		return ""
+	}
+	return "the store is excluded"
+}
+
+func stateReason(store *core.StoreInfo, filtered bool) string {
+	if !filtered {
+		return ""
+	}
+	return fmt.Sprintf("the store is %s", strings.ToLower(store.GetState().String()))
+}
+
+func downReason(opt Options, store *core.StoreInfo) string {
+	if store.DownTime() <= opt.GetMaxStoreDownTime() {
+		return ""
+	}
+	return fmt.Sprintf("the store has been down for %v, longer than max-store-down-time %v", store.DownTime().Round(time.Second), opt.GetMaxStoreDownTime())
+}
+
+func disconnectReason(store *core.StoreInfo) string {
+	if !store.IsDisconnected() {
+		return ""
+	}
+	return fmt.Sprintf("the store is disconnected, the last heartbeat was %v ago", store.DownTime().Round(time.Second))
+}
+
+func blockedReason(store *core.StoreInfo) string {
+	if !store.IsBlocked() {
+		return ""
+	}
+	return "the store is blocked"
+}
+
+func busyReason(store *core.StoreInfo) string {
+	if !store.GetIsBusy() {
+		return ""
+	}
+	return "the store is busy"
+}
+
+func pendingPeerReason(opt Options, store *core.StoreInfo) string {
+	maxPendingPeerCount := opt.GetStoreMaxPendingPeerCount(store.GetID())
+	if maxPendingPeerCount == 0 || store.GetPendingPeerCount() <= int(maxPendingPeerCount) {
+		return ""
+	}
+	return fmt.Sprintf("the store has %d pending peers, more than max-pending-peer-count %d", store.GetPendingPeerCount(), maxPendingPeerCount)
+}
+
+func snapshotReason(opt Options, store *core.StoreInfo) string {
+	maxSnapshotCount := opt.GetStoreMaxSnapshotCount(store.GetID())
+	var counts []string
+	for _, c := range []struct {
+		name  string
+		count uint32
+	}{
+		{"sending", store.GetSendingSnapCount()},
+		{"receiving", store.GetReceivingSnapCount()},
+		{"applying", store.GetApplyingSnapCount()},
+	} {
+		if uint64(c.count) > maxSnapshotCount {
+			counts = append(counts, fmt.Sprintf("%d %s", c.count, c.name))
+		}
+	}
+	if len(counts) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("the store has %s snapshots, more than max-snapshot-count %d", strings.Join(counts, ", "), maxSnapshotCount)
+}
+
+func lowSpaceReason(opt Options, store *core.StoreInfo) string {
+	lowSpaceRatio := opt.GetStoreLowSpaceRatio(store.GetID())
+	if !store.IsLowSpace(lowSpaceRatio) {
+		return ""
+	}
+	return fmt.Sprintf("the available ratio %.2f is less than 1 - low-space-ratio %.2f", store.AvailableRatio(), 1-lowSpaceRatio)
+}
+
+func rejectLeaderReason(opt Options, store *core.StoreInfo) string {
+	if !opt.CheckLabelProperty(RejectLeader, store.GetLabels()) {
+		return ""
+	}
+	return "the store has a label with the reject-leader property"
+}
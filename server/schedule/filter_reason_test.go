@@ -0,0 +1,77 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"strings"
+
+	. "github.com/pingcap/check"
+)
+
+var _ = Suite(&testFilterReasonSuite{})
+
+type testFilterReasonSuite struct{}
+
+func (s *testFilterReasonSuite) TestExplain(c *C) {
+	opt := NewMockSchedulerOptions()
+	tc := NewMockCluster(opt)
+	tc.AddRegionStore(1, 10)
+	tc.AddRegionStore(2, 10)
+	tc.AddRegionStore(3, 10)
+
+	filters := []Filter{
+		StoreStateFilter{MoveRegion: true},
+		NewStateFilter(),
+		NewPendingPeerCountFilter(),
+		NewSnapshotCountFilter(),
+		NewStorageThresholdFilter(),
+	}
+	c.Assert(ExplainSource(tc, tc.GetStore(1), filters), HasLen, 0)
+	c.Assert(ExplainTarget(tc, tc.GetStore(1), filters), HasLen, 0)
+
+	tc.UpdatePendingPeerCount(1, 30)
+	tc.UpdateSnapshotCount(1, 10)
+	reasons := ExplainSource(tc, tc.GetStore(1), filters)
+	c.Assert(reasons, HasLen, 3)
+	c.Assert(reasons[0].Filter, Equals, "store-state-filter")
+	c.Assert(reasons[0].Reason, Equals, "the store has 30 pending peers, more than max-pending-peer-count 16; "+
+		"the store has 10 applying snapshots, more than max-snapshot-count 3")
+	c.Assert(reasons[1], DeepEquals, FilterReason{Filter: "pending-peer-filter", Reason: "the store has 30 pending peers, more than max-pending-peer-count 16"})
+	c.Assert(reasons[2].Filter, Equals, "snapshot-filter")
+
+	// Offline stores are excluded only as targets.
+	tc.SetStoreOffline(2)
+	c.Assert(ExplainSource(tc, tc.GetStore(2), filters), HasLen, 0)
+	reasons = ExplainTarget(tc, tc.GetStore(2), filters)
+	c.Assert(reasons, DeepEquals, []FilterReason{
+		{Filter: "store-state-filter", Reason: "the store is offline"},
+		{Filter: "state-filter", Reason: "the store is offline"},
+	})
+
+	tc.UpdateStorageRatio(3, 0.9, 0.1)
+	c.Assert(ExplainSource(tc, tc.GetStore(3), filters), HasLen, 0)
+	reasons = ExplainTarget(tc, tc.GetStore(3), filters)
+	c.Assert(reasons, DeepEquals, []FilterReason{
+		{Filter: "storage-threshold-filter", Reason: "the available ratio 0.10 is less than 1 - low-space-ratio 0.20"},
+	})
+
+	tc.SetStoreDown(3)
+	reasons = ExplainTarget(tc, tc.GetStore(3), []Filter{NewHealthFilter()})
+	c.Assert(reasons, HasLen, 1)
+	c.Assert(strings.HasPrefix(reasons[0].Reason, "the store has been down for"), IsTrue)
+
+	// Filters without a specific reason.
+	reasons = ExplainTarget(tc, tc.GetStore(1), []Filter{NewExcludedFilter(nil, map[uint64]struct{}{1: {}})})
+	c.Assert(reasons, DeepEquals, []FilterReason{{Filter: "exclude-filter", Reason: "the store is excluded"}})
+}
@@ -14,6 +14,8 @@
 package schedule
 
 import (
+	"fmt"
+
 	"github.com/pingcap/pd/server/cache"
 	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/server/namespace"
@@ -28,30 +30,50 @@ type Filter interface {
 	FilterSource(opt Options, store *core.StoreInfo) bool
 	// Return true if the store should not be used as a target store.
 	FilterTarget(opt Options, store *core.StoreInfo) bool
+	// SourceReason returns why the store should not be used as a source
+	// store, or "" if it can be used. It agrees with FilterSource, but builds
+	// the reason, so it is only used to explain the filters.
+	SourceReason(opt Options, store *core.StoreInfo) string
+	// TargetReason returns why the store should not be used as a target
+	// store, or "" if it can be used. It agrees with FilterTarget, but builds
+	// the reason, so it is only used to explain the filters.
+	TargetReason(opt Options, store *core.StoreInfo) string
 }
 
 // FilterSource checks if store can pass all Filters as source store.
 func FilterSource(opt Options, store *core.StoreInfo, filters []Filter) bool {
+	return SourceFilteredBy(opt, store, filters) != nil
+}
+
+// SourceFilteredBy returns the first filter which filters the store as source
+// store, or nil if the store passes all filters.
+func SourceFilteredBy(opt Options, store *core.StoreInfo, filters []Filter) Filter {
 	storeAddress := store.GetAddress()
 	for _, filter := range filters {
 		if filter.FilterSource(opt, store) {
 			filterCounter.WithLabelValues("filter-source", storeAddress, filter.Type()).Inc()
-			return true
+			return filter
 		}
 	}
-	return false
+	return nil
 }
 
 // FilterTarget checks if store can pass all Filters as target store.
 func FilterTarget(opt Options, store *core.StoreInfo, filters []Filter) bool {
+	return TargetFilteredBy(opt, store, filters) != nil
+}
+
+// TargetFilteredBy returns the first filter which filters the store as target
+// store, or nil if the store passes all filters.
+func TargetFilteredBy(opt Options, store *core.StoreInfo, filters []Filter) Filter {
 	storeAddress := store.GetAddress()
 	for _, filter := range filters {
 		if filter.FilterTarget(opt, store) {
 			filterCounter.WithLabelValues("filter-target", storeAddress, filter.Type()).Inc()
-			return true
+			return filter
 		}
 	}
-	return false
+	return nil
 }
 
 type excludedFilter struct {
@@ -81,6 +103,14 @@ func (f *excludedFilter) FilterTarget(opt Options, store *core.StoreInfo) bool {
 	return ok
 }
 
+func (f *excludedFilter) SourceReason(opt Options, store *core.StoreInfo) string {
+	return excludedReason(f.sources, store)
+}
+
+func (f *excludedFilter) TargetReason(opt Options, store *core.StoreInfo) string {
+	return excludedReason(f.targets, store)
+}
+
 type blockFilter struct{}
 
 // NewBlockFilter creates a Filter that filters all stores that are blocked from balance.
@@ -100,6 +130,14 @@ func (f *blockFilter) FilterTarget(opt Options, store *core.StoreInfo) bool {
 	return store.IsBlocked()
 }
 
+func (f *blockFilter) SourceReason(opt Options, store *core.StoreInfo) string {
+	return blockedReason(store)
+}
+
+func (f *blockFilter) TargetReason(opt Options, store *core.StoreInfo) string {
+	return blockedReason(store)
+}
+
 type stateFilter struct{}
 
 // NewStateFilter creates a Filter that filters all stores that are not UP.
@@ -119,6 +157,14 @@ func (f *stateFilter) FilterTarget(opt Options, store *core.StoreInfo) bool {
 	return !store.IsUp()
 }
 
+func (f *stateFilter) SourceReason(opt Options, store *core.StoreInfo) string {
+	return stateReason(store, store.IsTombstone())
+}
+
+func (f *stateFilter) TargetReason(opt Options, store *core.StoreInfo) string {
+	return stateReason(store, !store.IsUp())
+}
+
 type healthFilter struct{}
 
 // NewHealthFilter creates a Filter that filters all stores that are Busy or Down.
@@ -145,6 +191,18 @@ func (f *healthFilter) FilterTarget(opt Options, store *core.StoreInfo) bool {
 	return f.filter(opt, store)
 }
 
+func (f *healthFilter) reason(opt Options, store *core.StoreInfo) string {
+	return joinReasons(busyReason(store), downReason(opt, store))
+}
+
+func (f *healthFilter) SourceReason(opt Options, store *core.StoreInfo) string {
+	return f.reason(opt, store)
+}
+
+func (f *healthFilter) TargetReason(opt Options, store *core.StoreInfo) string {
+	return f.reason(opt, store)
+}
+
 type disconnectFilter struct{}
 
 // NewDisconnectFilter creates a Filter that filters all stores that are disconnected.
@@ -164,6 +222,14 @@ func (f *disconnectFilter) FilterTarget(opt Options, store *core.StoreInfo) bool
 	return store.IsDisconnected()
 }
 
+func (f *disconnectFilter) SourceReason(opt Options, store *core.StoreInfo) string {
+	return disconnectReason(store)
+}
+
+func (f *disconnectFilter) TargetReason(opt Options, store *core.StoreInfo) string {
+	return disconnectReason(store)
+}
+
 type pendingPeerCountFilter struct{}
 
 // NewPendingPeerCountFilter creates a Filter that filters all stores that are
@@ -177,10 +243,11 @@ func (p *pendingPeerCountFilter) Type() string {
 }
 
 func (p *pendingPeerCountFilter) filter(opt Options, store *core.StoreInfo) bool {
-	if opt.GetMaxPendingPeerCount() == 0 {
+	maxPendingPeerCount := opt.GetStoreMaxPendingPeerCount(store.GetID())
+	if maxPendingPeerCount == 0 {
 		return false
 	}
-	return store.GetPendingPeerCount() > int(opt.GetMaxPendingPeerCount())
+	return store.GetPendingPeerCount() > int(maxPendingPeerCount)
 }
 
 func (p *pendingPeerCountFilter) FilterSource(opt Options, store *core.StoreInfo) bool {
@@ -191,6 +258,14 @@ func (p *pendingPeerCountFilter) FilterTarget(opt Options, store *core.StoreInfo
 	return p.filter(opt, store)
 }
 
+func (p *pendingPeerCountFilter) SourceReason(opt Options, store *core.StoreInfo) string {
+	return pendingPeerReason(opt, store)
+}
+
+func (p *pendingPeerCountFilter) TargetReason(opt Options, store *core.StoreInfo) string {
+	return pendingPeerReason(opt, store)
+}
+
 type snapshotCountFilter struct{}
 
 // NewSnapshotCountFilter creates a Filter that filters all stores that are
@@ -204,9 +279,10 @@ func (f *snapshotCountFilter) Type() string {
 }
 
 func (f *snapshotCountFilter) filter(opt Options, store *core.StoreInfo) bool {
-	return uint64(store.GetSendingSnapCount()) > opt.GetMaxSnapshotCount() ||
-		uint64(store.GetReceivingSnapCount()) > opt.GetMaxSnapshotCount() ||
-		uint64(store.GetApplyingSnapCount()) > opt.GetMaxSnapshotCount()
+	maxSnapshotCount := opt.GetStoreMaxSnapshotCount(store.GetID())
+	return uint64(store.GetSendingSnapCount()) > maxSnapshotCount ||
+		uint64(store.GetReceivingSnapCount()) > maxSnapshotCount ||
+		uint64(store.GetApplyingSnapCount()) > maxSnapshotCount
 }
 
 func (f *snapshotCountFilter) FilterSource(opt Options, store *core.StoreInfo) bool {
@@ -217,6 +293,14 @@ func (f *snapshotCountFilter) FilterTarget(opt Options, store *core.StoreInfo) b
 	return f.filter(opt, store)
 }
 
+func (f *snapshotCountFilter) SourceReason(opt Options, store *core.StoreInfo) string {
+	return snapshotReason(opt, store)
+}
+
+func (f *snapshotCountFilter) TargetReason(opt Options, store *core.StoreInfo) string {
+	return snapshotReason(opt, store)
+}
+
 type cacheFilter struct {
 	cache *cache.TTLUint64
 }
@@ -238,6 +322,17 @@ func (f *cacheFilter) FilterTarget(opt Options, store *core.StoreInfo) bool {
 	return false
 }
 
+func (f *cacheFilter) SourceReason(opt Options, store *core.StoreInfo) string {
+	if !f.cache.Exists(store.GetID()) {
+		return ""
+	}
+	return "no operator was created for the store as the source recently"
+}
+
+func (f *cacheFilter) TargetReason(opt Options, store *core.StoreInfo) string {
+	return ""
+}
+
 type storageThresholdFilter struct{}
 
 // NewStorageThresholdFilter creates a Filter that filters all stores that are
@@ -255,7 +350,15 @@ func (f *storageThresholdFilter) FilterSource(opt Options, store *core.StoreInfo
 }
 
 func (f *storageThresholdFilter) FilterTarget(opt Options, store *core.StoreInfo) bool {
-	return store.IsLowSpace(opt.GetLowSpaceRatio())
+	return store.IsLowSpace(opt.GetStoreLowSpaceRatio(store.GetID()))
+}
+
+func (f *storageThresholdFilter) SourceReason(opt Options, store *core.StoreInfo) string {
+	return ""
+}
+
+func (f *storageThresholdFilter) TargetReason(opt Options, store *core.StoreInfo) string {
+	return lowSpaceReason(opt, store)
 }
 
 // distinctScoreFilter ensures that distinct score will not decrease.
@@ -295,6 +398,18 @@ func (f *distinctScoreFilter) FilterTarget(opt Options, store *core.StoreInfo) b
 	return DistinctScore(f.labels, f.stores, store) < f.safeScore
 }
 
+func (f *distinctScoreFilter) SourceReason(opt Options, store *core.StoreInfo) string {
+	return ""
+}
+
+func (f *distinctScoreFilter) TargetReason(opt Options, store *core.StoreInfo) string {
+	score := DistinctScore(f.labels, f.stores, store)
+	if score >= f.safeScore {
+		return ""
+	}
+	return fmt.Sprintf("the isolation score of the region would be %v on the store, lower than %v", score, f.safeScore)
+}
+
 type namespaceFilter struct {
 	classifier namespace.Classifier
 	namespace  string
@@ -325,6 +440,21 @@ func (f *namespaceFilter) FilterTarget(opt Options, store *core.StoreInfo) bool
 	return f.filter(store)
 }
 
+func (f *namespaceFilter) reason(store *core.StoreInfo) string {
+	if !f.filter(store) {
+		return ""
+	}
+	return fmt.Sprintf("the store is not in namespace %s", f.namespace)
+}
+
+func (f *namespaceFilter) SourceReason(opt Options, store *core.StoreInfo) string {
+	return f.reason(store)
+}
+
+func (f *namespaceFilter) TargetReason(opt Options, store *core.StoreInfo) string {
+	return f.reason(store)
+}
+
 type rejectLeaderFilter struct{}
 
 // NewRejectLeaderFilter creates a Filter that filters stores that marked as
@@ -345,6 +475,14 @@ func (f rejectLeaderFilter) FilterTarget(opt Options, store *core.StoreInfo) boo
 	return opt.CheckLabelProperty(RejectLeader, store.GetLabels())
 }
 
+func (f rejectLeaderFilter) SourceReason(opt Options, store *core.StoreInfo) string {
+	return ""
+}
+
+func (f rejectLeaderFilter) TargetReason(opt Options, store *core.StoreInfo) string {
+	return rejectLeaderReason(opt, store)
+}
+
 // StoreStateFilter is used to determine whether a store can be selected as the
 // source or target of the schedule based on the store's state.
 type StoreStateFilter struct {
@@ -402,13 +540,49 @@ func (f StoreStateFilter) filterMoveRegion(opt Options, store *core.StoreInfo) b
 	if store.GetIsBusy() {
 		return true
 	}
-	if opt.GetMaxPendingPeerCount() > 0 && store.GetPendingPeerCount() > int(opt.GetMaxPendingPeerCount()) {
+	maxPendingPeerCount := opt.GetStoreMaxPendingPeerCount(store.GetID())
+	if maxPendingPeerCount > 0 && store.GetPendingPeerCount() > int(maxPendingPeerCount) {
 		return true
 	}
-	if uint64(store.GetSendingSnapCount()) > opt.GetMaxSnapshotCount() ||
-		uint64(store.GetReceivingSnapCount()) > opt.GetMaxSnapshotCount() ||
-		uint64(store.GetApplyingSnapCount()) > opt.GetMaxSnapshotCount() {
+	maxSnapshotCount := opt.GetStoreMaxSnapshotCount(store.GetID())
+	if uint64(store.GetSendingSnapCount()) > maxSnapshotCount ||
+		uint64(store.GetReceivingSnapCount()) > maxSnapshotCount ||
+		uint64(store.GetApplyingSnapCount()) > maxSnapshotCount {
 		return true
 	}
 	return false
 }
+
+// SourceReason returns why the store cannot be selected as the schedule
+// source.
+func (f StoreStateFilter) SourceReason(opt Options, store *core.StoreInfo) string {
+	reasons := []string{stateReason(store, store.IsTombstone()), downReason(opt, store)}
+	if f.TransferLeader {
+		reasons = append(reasons, disconnectReason(store), blockedReason(store))
+	}
+	if f.MoveRegion {
+		reasons = append(reasons, busyReason(store), pendingPeerReason(opt, store), snapshotReason(opt, store))
+	}
+	return joinReasons(reasons...)
+}
+
+// TargetReason returns why the store cannot be selected as the schedule
+// target.
+func (f StoreStateFilter) TargetReason(opt Options, store *core.StoreInfo) string {
+	reasons := []string{
+		stateReason(store, store.IsTombstone() || store.IsOffline()),
+		downReason(opt, store),
+	}
+	if f.TransferLeader {
+		reasons = append(reasons, disconnectReason(store), blockedReason(store),
+			busyReason(store), rejectLeaderReason(opt, store))
+	}
+	if f.MoveRegion {
+		// The busy store is already checked for the leader transfer.
+		if !f.TransferLeader {
+			reasons = append(reasons, busyReason(store))
+		}
+		reasons = append(reasons, pendingPeerReason(opt, store), snapshotReason(opt, store))
+	}
+	return joinReasons(reasons...)
+}
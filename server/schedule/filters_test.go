@@ -13,8 +13,11 @@
 package schedule
 
 import (
+	"time"
+
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/pd/server/core"
 )
 
@@ -36,3 +39,75 @@ func (s *testReplicationSuite) TestPendingPeerFilter(c *C) {
 	c.Assert(filter.FilterSource(tc, newStore), IsFalse)
 	c.Assert(filter.FilterTarget(tc, newStore), IsFalse)
 }
+
+func (s *testFiltersSuite) TestStoreConfigOverride(c *C) {
+	opt := NewMockSchedulerOptions()
+	tc := NewMockCluster(opt)
+	store := core.NewStoreInfo(&metapb.Store{Id: 1}, core.SetPendingPeerCount(30))
+	pendingPeerFilter := NewPendingPeerCountFilter()
+	c.Assert(pendingPeerFilter.FilterTarget(tc, store), IsTrue)
+
+	maxPendingPeerCount, lowSpaceRatio := uint64(50), 0.5
+	opt.StoreConfigs = map[uint64]*core.StoreConfig{
+		1: {MaxPendingPeerCount: &maxPendingPeerCount, LowSpaceRatio: &lowSpaceRatio},
+	}
+	c.Assert(pendingPeerFilter.FilterTarget(tc, store), IsFalse)
+	c.Assert(tc.GetStoreMaxPendingPeerCount(2), Equals, opt.MaxPendingPeerCount)
+	c.Assert(tc.GetStoreLowSpaceRatio(1), Equals, lowSpaceRatio)
+	c.Assert(tc.GetStoreLowSpaceRatio(2), Equals, opt.LowSpaceRatio)
+	c.Assert(tc.GetStoreMaxSnapshotCount(1), Equals, opt.MaxSnapshotCount)
+}
+
+func (s *testFiltersSuite) TestStoreStateFilterReason(c *C) {
+	opt := NewMockSchedulerOptions()
+	tc := NewMockCluster(opt)
+	store := core.NewStoreInfo(&metapb.Store{Id: 1, State: metapb.StoreState_Up}, core.SetLastHeartbeatTS(time.Now()))
+	filter := StoreStateFilter{TransferLeader: true}
+	c.Assert(filter.SourceReason(tc, store), Equals, "")
+	c.Assert(filter.TargetReason(tc, store), Equals, "")
+
+	// A blocked store is filtered on both sides by the same reason which is
+	// reported by ExplainSource and ExplainTarget.
+	store = store.Clone(core.SetStoreBlock())
+	c.Assert(filter.FilterSource(tc, store), IsTrue)
+	c.Assert(filter.FilterTarget(tc, store), IsTrue)
+	reasons := ExplainTarget(tc, store, []Filter{filter, NewHealthFilter()})
+	c.Assert(reasons, DeepEquals, []FilterReason{{Filter: filter.Type(), Reason: filter.TargetReason(tc, store)}})
+	c.Assert(ExplainSource(tc, store, []Filter{filter}), DeepEquals, reasons)
+}
+
+func (s *testFiltersSuite) TestFilterReasonsAgree(c *C) {
+	opt := NewMockSchedulerOptions()
+	tc := NewMockCluster(opt)
+	now := time.Now()
+	stores := []*core.StoreInfo{
+		core.NewStoreInfo(&metapb.Store{Id: 1, State: metapb.StoreState_Up}, core.SetLastHeartbeatTS(now)),
+		core.NewStoreInfo(&metapb.Store{Id: 2, State: metapb.StoreState_Offline}, core.SetLastHeartbeatTS(now)),
+		core.NewStoreInfo(&metapb.Store{Id: 3, State: metapb.StoreState_Tombstone}),
+		core.NewStoreInfo(&metapb.Store{Id: 4, State: metapb.StoreState_Up}, core.SetLastHeartbeatTS(now), core.SetStoreBlock()),
+		core.NewStoreInfo(&metapb.Store{Id: 5, State: metapb.StoreState_Up}, core.SetLastHeartbeatTS(now), core.SetPendingPeerCount(30)),
+		core.NewStoreInfo(&metapb.Store{Id: 6, State: metapb.StoreState_Up}, core.SetLastHeartbeatTS(now),
+			core.SetStoreStats(&pdpb.StoreStats{IsBusy: true, SendingSnapCount: 10})),
+		core.NewStoreInfo(&metapb.Store{Id: 7, State: metapb.StoreState_Up}, core.SetLastHeartbeatTS(now.Add(-time.Hour))),
+	}
+	filters := []Filter{
+		NewBlockFilter(),
+		NewStateFilter(),
+		NewHealthFilter(),
+		NewDisconnectFilter(),
+		NewPendingPeerCountFilter(),
+		NewSnapshotCountFilter(),
+		NewStorageThresholdFilter(),
+		NewExcludedFilter(map[uint64]struct{}{1: {}}, map[uint64]struct{}{2: {}}),
+		StoreStateFilter{TransferLeader: true},
+		StoreStateFilter{MoveRegion: true},
+		StoreStateFilter{TransferLeader: true, MoveRegion: true},
+	}
+	for _, filter := range filters {
+		for _, store := range stores {
+			comment := Commentf("%s store %d", filter.Type(), store.GetID())
+			c.Assert(filter.FilterSource(tc, store), Equals, filter.SourceReason(tc, store) != "", comment)
+			c.Assert(filter.FilterTarget(tc, store), Equals, filter.TargetReason(tc, store) != "", comment)
+		}
+	}
+}
@@ -529,6 +529,7 @@ type MockSchedulerOptions struct {
 	DisableLocationReplacement   bool
 	DisableNamespaceRelocation   bool
 	LabelProperties              map[string][]*metapb.StoreLabel
+	StoreConfigs                 map[uint64]*core.StoreConfig
 }
 
 // NewMockSchedulerOptions creates a mock schedule option.
@@ -638,6 +639,26 @@ func (mso *MockSchedulerOptions) GetHighSpaceRatio() float64 {
 	return mso.HighSpaceRatio
 }
 
+// GetStoreMaxSnapshotCount mock method
+func (mso *MockSchedulerOptions) GetStoreMaxSnapshotCount(storeID uint64) uint64 {
+	return mso.StoreConfigs[storeID].GetMaxSnapshotCount(mso.MaxSnapshotCount)
+}
+
+// GetStoreMaxPendingPeerCount mock method
+func (mso *MockSchedulerOptions) GetStoreMaxPendingPeerCount(storeID uint64) uint64 {
+	return mso.StoreConfigs[storeID].GetMaxPendingPeerCount(mso.MaxPendingPeerCount)
+}
+
+// GetStoreLowSpaceRatio mock method
+func (mso *MockSchedulerOptions) GetStoreLowSpaceRatio(storeID uint64) float64 {
+	return mso.StoreConfigs[storeID].GetLowSpaceRatio(mso.LowSpaceRatio)
+}
+
+// GetStoreHighSpaceRatio mock method
+func (mso *MockSchedulerOptions) GetStoreHighSpaceRatio(storeID uint64) float64 {
+	return mso.StoreConfigs[storeID].GetHighSpaceRatio(mso.HighSpaceRatio)
+}
+
 // SetMaxReplicas mock method
 func (mso *MockSchedulerOptions) SetMaxReplicas(replicas int) {
 	mso.MaxReplicas = replicas
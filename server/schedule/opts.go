@@ -46,6 +46,13 @@ type Options interface {
 	GetLowSpaceRatio() float64
 	GetHighSpaceRatio() float64
 
+	// The store-level getters return the config overridden for the store,
+	// or the global one if it is not overridden.
+	GetStoreMaxSnapshotCount(storeID uint64) uint64
+	GetStoreMaxPendingPeerCount(storeID uint64) uint64
+	GetStoreLowSpaceRatio(storeID uint64) float64
+	GetStoreHighSpaceRatio(storeID uint64) float64
+
 	IsRaftLearnerEnabled() bool
 
 	IsRemoveDownReplicaEnabled() bool
@@ -49,12 +49,12 @@ func compareStoreScore(opt Options, storeA *core.StoreInfo, scoreA float64, stor
 		return -1
 	}
 	// The store with lower region score is better.
-	if storeA.RegionScore(opt.GetHighSpaceRatio(), opt.GetLowSpaceRatio(), 0) <
-		storeB.RegionScore(opt.GetHighSpaceRatio(), opt.GetLowSpaceRatio(), 0) {
+	if storeA.RegionScore(opt.GetStoreHighSpaceRatio(storeA.GetID()), opt.GetStoreLowSpaceRatio(storeA.GetID()), 0) <
+		storeB.RegionScore(opt.GetStoreHighSpaceRatio(storeB.GetID()), opt.GetStoreLowSpaceRatio(storeB.GetID()), 0) {
 		return 1
 	}
-	if storeA.RegionScore(opt.GetHighSpaceRatio(), opt.GetLowSpaceRatio(), 0) >
-		storeB.RegionScore(opt.GetHighSpaceRatio(), opt.GetLowSpaceRatio(), 0) {
+	if storeA.RegionScore(opt.GetStoreHighSpaceRatio(storeA.GetID()), opt.GetStoreLowSpaceRatio(storeA.GetID()), 0) >
+		storeB.RegionScore(opt.GetStoreHighSpaceRatio(storeB.GetID()), opt.GetStoreLowSpaceRatio(storeB.GetID()), 0) {
 		return -1
 	}
 	return 0
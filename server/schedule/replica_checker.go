@@ -126,16 +126,17 @@ func (r *ReplicaChecker) selectBestPeerToAddReplica(region *core.RegionInfo, fil
 	return newPeer, score
 }
 
+// TargetFilters returns the filters which every store to add a replica to
+// should pass, besides the ones depending on the region.
+func (r *ReplicaChecker) TargetFilters() []Filter {
+	filters := append([]Filter{}, r.filters...)
+	return append(filters, NewStateFilter(), NewPendingPeerCountFilter())
+}
+
 // selectBestStoreToAddReplica returns the store to add a replica.
 func (r *ReplicaChecker) selectBestStoreToAddReplica(region *core.RegionInfo, filters ...Filter) (uint64, float64) {
-	// Add some must have filters.
-	newFilters := []Filter{
-		NewStateFilter(),
-		NewPendingPeerCountFilter(),
-		NewExcludedFilter(nil, region.GetStoreIds()),
-	}
-	filters = append(filters, r.filters...)
-	filters = append(filters, newFilters...)
+	filters = append(filters, r.TargetFilters()...)
+	filters = append(filters, NewExcludedFilter(nil, region.GetStoreIds()))
 	if r.classifier != nil {
 		filters = append(filters, NewNamespaceFilter(r.classifier, r.classifier.GetRegionNamespace(region)))
 	}
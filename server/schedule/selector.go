@@ -37,35 +37,61 @@ func NewBalanceSelector(kind core.ResourceKind, filters []Filter) *BalanceSelect
 // SelectSource selects the store that can pass all filters and has the minimal
 // resource score.
 func (s *BalanceSelector) SelectSource(opt Options, stores []*core.StoreInfo) *core.StoreInfo {
-	var result *core.StoreInfo
+	return s.SelectSourceWithTrace(opt, stores, nil)
+}
+
+// SelectSourceWithTrace is SelectSource which records the candidates and the
+// result to the trace.
+func (s *BalanceSelector) SelectSourceWithTrace(opt Options, stores []*core.StoreInfo, trace *Trace) *core.StoreInfo {
+	var (
+		result      *core.StoreInfo
+		resultScore float64
+	)
 	for _, store := range stores {
-		if FilterSource(opt, store, s.filters) {
+		if filter := SourceFilteredBy(opt, store, s.filters); filter != nil {
+			trace.RejectSource(opt, store, filter)
 			continue
 		}
-		if result == nil ||
-			result.ResourceScore(s.kind, opt.GetHighSpaceRatio(), opt.GetLowSpaceRatio(), 0) <
-				store.ResourceScore(s.kind, opt.GetHighSpaceRatio(), opt.GetLowSpaceRatio(), 0) {
-			result = store
+		score := store.ResourceScore(s.kind, opt.GetStoreHighSpaceRatio(store.GetID()), opt.GetStoreLowSpaceRatio(store.GetID()), 0)
+		trace.AddSource(store.GetID(), score)
+		if result == nil || resultScore < score {
+			result, resultScore = store, score
 		}
 	}
+	if result != nil {
+		trace.SetSource(result.GetID())
+	}
 	return result
 }
 
 // SelectTarget selects the store that can pass all filters and has the maximal
 // resource score.
 func (s *BalanceSelector) SelectTarget(opt Options, stores []*core.StoreInfo, filters ...Filter) *core.StoreInfo {
+	return s.SelectTargetWithTrace(opt, stores, nil, filters...)
+}
+
+// SelectTargetWithTrace is SelectTarget which records the candidates and the
+// result to the trace.
+func (s *BalanceSelector) SelectTargetWithTrace(opt Options, stores []*core.StoreInfo, trace *Trace, filters ...Filter) *core.StoreInfo {
 	filters = append(filters, s.filters...)
-	var result *core.StoreInfo
+	var (
+		result      *core.StoreInfo
+		resultScore float64
+	)
 	for _, store := range stores {
-		if FilterTarget(opt, store, filters) {
+		if filter := TargetFilteredBy(opt, store, filters); filter != nil {
+			trace.RejectTarget(opt, store, filter)
 			continue
 		}
-		if result == nil ||
-			result.ResourceScore(s.kind, opt.GetHighSpaceRatio(), opt.GetLowSpaceRatio(), 0) >
-				store.ResourceScore(s.kind, opt.GetHighSpaceRatio(), opt.GetLowSpaceRatio(), 0) {
-			result = store
+		score := store.ResourceScore(s.kind, opt.GetStoreHighSpaceRatio(store.GetID()), opt.GetStoreLowSpaceRatio(store.GetID()), 0)
+		trace.AddTarget(store.GetID(), score)
+		if result == nil || resultScore > score {
+			result, resultScore = store, score
 		}
 	}
+	if result != nil {
+		trace.SetTarget(result.GetID())
+	}
 	return result
 }
 
@@ -0,0 +1,268 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pingcap/pd/server/core"
+)
+
+// TraceableScheduler is a scheduler which can record the decisions of its
+// runs.
+type TraceableScheduler interface {
+	GetTracer() *Tracer
+}
+
+// TraceCandidate is a store considered as the source or the target. Filter
+// and Reason are set if the store is rejected.
+type TraceCandidate struct {
+	StoreID uint64  `json:"store_id"`
+	Score   float64 `json:"score,omitempty"`
+	Filter  string  `json:"filter,omitempty"`
+	Reason  string  `json:"reason,omitempty"`
+}
+
+// TraceAttempt is an attempt to create an operator in a run of a scheduler,
+// usually for a region.
+type TraceAttempt struct {
+	RegionID         uint64            `json:"region_id,omitempty"`
+	Source           uint64            `json:"source,omitempty"`
+	Target           uint64            `json:"target,omitempty"`
+	TargetCandidates []*TraceCandidate `json:"target_candidates,omitempty"`
+	Skip             string            `json:"skip,omitempty"`
+}
+
+// Trace records the decisions of a run of a scheduler. All methods can be
+// called on a nil Trace, which does nothing, so the schedulers do not need to
+// check if tracing is enabled.
+type Trace struct {
+	Time             time.Time         `json:"time"`
+	SourceCandidates []*TraceCandidate `json:"source_candidates,omitempty"`
+	TargetCandidates []*TraceCandidate `json:"target_candidates,omitempty"`
+	Source           uint64            `json:"source,omitempty"`
+	Target           uint64            `json:"target,omitempty"`
+	Attempts         []*TraceAttempt   `json:"attempts,omitempty"`
+	Operators        []string          `json:"operators,omitempty"`
+	Skip             string            `json:"skip,omitempty"`
+}
+
+func (t *Trace) attempt() *TraceAttempt {
+	if len(t.Attempts) == 0 {
+		return nil
+	}
+	return t.Attempts[len(t.Attempts)-1]
+}
+
+// AddSource records a store which can be the source.
+func (t *Trace) AddSource(storeID uint64, score float64) {
+	if t == nil {
+		return
+	}
+	t.SourceCandidates = append(t.SourceCandidates, &TraceCandidate{StoreID: storeID, Score: score})
+}
+
+// RejectSource records a store which is rejected as the source by the filter.
+func (t *Trace) RejectSource(opt Options, store *core.StoreInfo, filter Filter) {
+	if t == nil {
+		return
+	}
+	t.SourceCandidates = append(t.SourceCandidates, &TraceCandidate{
+		StoreID: store.GetID(),
+		Filter:  filter.Type(),
+		Reason:  filter.SourceReason(opt, store),
+	})
+}
+
+// AddTarget records a store which can be the target, for the current attempt
+// if there is one.
+func (t *Trace) AddTarget(storeID uint64, score float64) {
+	if t == nil {
+		return
+	}
+	t.addTarget(&TraceCandidate{StoreID: storeID, Score: score})
+}
+
+// RejectTarget records a store which is rejected as the target by the filter,
+// for the current attempt if there is one.
+func (t *Trace) RejectTarget(opt Options, store *core.StoreInfo, filter Filter) {
+	if t == nil {
+		return
+	}
+	t.RejectTargetWithReason(store.GetID(), filter.Type(), filter.TargetReason(opt, store))
+}
+
+// RejectTargetWithReason records a store which is rejected as the target by
+// a check other than the filters.
+func (t *Trace) RejectTargetWithReason(storeID uint64, check, reason string) {
+	if t == nil {
+		return
+	}
+	t.addTarget(&TraceCandidate{StoreID: storeID, Filter: check, Reason: reason})
+}
+
+func (t *Trace) addTarget(candidate *TraceCandidate) {
+	if a := t.attempt(); a != nil {
+		a.TargetCandidates = append(a.TargetCandidates, candidate)
+		return
+	}
+	t.TargetCandidates = append(t.TargetCandidates, candidate)
+}
+
+// SetSource records the chosen source, for the current attempt if there is
+// one.
+func (t *Trace) SetSource(storeID uint64) {
+	if t == nil {
+		return
+	}
+	if a := t.attempt(); a != nil {
+		a.Source = storeID
+		return
+	}
+	t.Source = storeID
+}
+
+// SetTarget records the chosen target, for the current attempt if there is
+// one.
+func (t *Trace) SetTarget(storeID uint64) {
+	if t == nil {
+		return
+	}
+	if a := t.attempt(); a != nil {
+		a.Target = storeID
+		return
+	}
+	t.Target = storeID
+}
+
+// AddAttempt starts a new attempt, which becomes the current attempt.
+func (t *Trace) AddAttempt() {
+	if t == nil {
+		return
+	}
+	t.Attempts = append(t.Attempts, &TraceAttempt{})
+}
+
+// SetRegion records the region of the current attempt.
+func (t *Trace) SetRegion(regionID uint64) {
+	if t == nil {
+		return
+	}
+	if a := t.attempt(); a != nil {
+		a.RegionID = regionID
+	}
+}
+
+// SkipAttempt records why the current attempt creates no operator.
+func (t *Trace) SkipAttempt(reason string) {
+	if t == nil {
+		return
+	}
+	if a := t.attempt(); a != nil {
+		a.Skip = reason
+	}
+}
+
+// SkipAttemptf is SkipAttempt with a formatted reason, which is only
+// formatted if the trace is not nil.
+func (t *Trace) SkipAttemptf(format string, args ...interface{}) {
+	if t == nil {
+		return
+	}
+	t.SkipAttempt(fmt.Sprintf(format, args...))
+}
+
+// SetOperators records the operators created by the run.
+func (t *Trace) SetOperators(ops []*Operator) {
+	if t == nil {
+		return
+	}
+	for _, op := range ops {
+		t.Operators = append(t.Operators, op.String())
+	}
+}
+
+// SetSkip records why the run creates no operator.
+func (t *Trace) SetSkip(reason string) {
+	if t == nil {
+		return
+	}
+	t.Skip = reason
+}
+
+// Tracer keeps the traces of the recent runs of a scheduler in a ring buffer.
+// It is disabled by default.
+type Tracer struct {
+	sync.RWMutex
+	enabled bool
+	traces  []*Trace
+	next    int
+}
+
+// NewTracer creates a Tracer which keeps at most capacity traces.
+func NewTracer(capacity int) *Tracer {
+	return &Tracer{traces: make([]*Trace, 0, capacity)}
+}
+
+// Enable enables or disables the tracer. The recorded traces are kept.
+func (t *Tracer) Enable(enabled bool) {
+	t.Lock()
+	defer t.Unlock()
+	t.enabled = enabled
+}
+
+// IsEnabled returns if the tracer is enabled.
+func (t *Tracer) IsEnabled() bool {
+	t.RLock()
+	defer t.RUnlock()
+	return t.enabled
+}
+
+// Begin starts a trace for a run, or returns nil if the tracer is disabled.
+func (t *Tracer) Begin() *Trace {
+	if !t.IsEnabled() {
+		return nil
+	}
+	return &Trace{Time: time.Now()}
+}
+
+// Finish records the trace to the ring buffer. The trace must not be changed
+// after that.
+func (t *Tracer) Finish(trace *Trace) {
+	if trace == nil {
+		return
+	}
+	t.Lock()
+	defer t.Unlock()
+	if len(t.traces) < cap(t.traces) {
+		t.traces = append(t.traces, trace)
+		return
+	}
+	if len(t.traces) == 0 {
+		return
+	}
+	t.traces[t.next] = trace
+	t.next = (t.next + 1) % len(t.traces)
+}
+
+// GetTraces returns the recorded traces from the oldest.
+func (t *Tracer) GetTraces() []*Trace {
+	t.RLock()
+	defer t.RUnlock()
+	traces := make([]*Trace, 0, len(t.traces))
+	traces = append(traces, t.traces[t.next:]...)
+	return append(traces, t.traces[:t.next]...)
+}
@@ -0,0 +1,82 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"fmt"
+
+	. "github.com/pingcap/check"
+)
+
+var _ = Suite(&testTraceSuite{})
+
+type testTraceSuite struct{}
+
+func (s *testTraceSuite) TestTrace(c *C) {
+	// All methods of a nil trace do nothing.
+	var trace *Trace
+	trace.AddSource(1, 10)
+	trace.AddAttempt()
+	trace.SetRegion(1)
+	trace.RejectTargetWithReason(2, "score", "too high")
+	trace.SkipAttemptf("skip %d", 1)
+	trace.SetSkip("skip")
+
+	trace = &Trace{}
+	trace.AddSource(1, 10)
+	trace.SetSource(1)
+	trace.AddTarget(2, 5)
+	trace.AddAttempt()
+	trace.SetSource(1)
+	trace.SetRegion(3)
+	trace.RejectTargetWithReason(2, "score", "too high")
+	trace.SetTarget(4)
+	trace.SkipAttemptf("skip %d", 3)
+	c.Assert(trace.Source, Equals, uint64(1))
+	c.Assert(trace.Target, Equals, uint64(0))
+	c.Assert(trace.SourceCandidates, DeepEquals, []*TraceCandidate{{StoreID: 1, Score: 10}})
+	c.Assert(trace.TargetCandidates, DeepEquals, []*TraceCandidate{{StoreID: 2, Score: 5}})
+	c.Assert(trace.Attempts, DeepEquals, []*TraceAttempt{{
+		RegionID:         3,
+		Source:           1,
+		Target:           4,
+		TargetCandidates: []*TraceCandidate{{StoreID: 2, Filter: "score", Reason: "too high"}},
+		Skip:             "skip 3",
+	}})
+}
+
+func (s *testTraceSuite) TestTracer(c *C) {
+	tracer := NewTracer(3)
+	c.Assert(tracer.IsEnabled(), IsFalse)
+	c.Assert(tracer.Begin(), IsNil)
+	tracer.Finish(nil)
+	c.Assert(tracer.GetTraces(), HasLen, 0)
+
+	tracer.Enable(true)
+	var traces []*Trace
+	for i := 0; i < 5; i++ {
+		trace := tracer.Begin()
+		c.Assert(trace, NotNil)
+		trace.SetSkip(fmt.Sprintf("skip %d", i))
+		tracer.Finish(trace)
+		traces = append(traces, trace)
+	}
+	// The ring buffer keeps the latest 3 traces from the oldest.
+	c.Assert(tracer.GetTraces(), DeepEquals, traces[2:])
+
+	// The traces are kept after the tracer is disabled.
+	tracer.Enable(false)
+	c.Assert(tracer.Begin(), IsNil)
+	c.Assert(tracer.GetTraces(), DeepEquals, traces[2:])
+}
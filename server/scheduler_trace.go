@@ -0,0 +1,76 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"github.com/pingcap/errcode"
+	"github.com/pingcap/pd/server/schedule"
+	"github.com/pkg/errors"
+)
+
+// SchedulerTrace is the decision traces of the recent runs of a scheduler.
+type SchedulerTrace struct {
+	Name    string            `json:"name"`
+	Enabled bool              `json:"enabled"`
+	Traces  []*schedule.Trace `json:"traces"`
+}
+
+// getTracer returns the tracer of the scheduler, or an error if the scheduler
+// does not exist or does not support tracing.
+func (c *coordinator) getTracer(name string) (*schedule.Tracer, error) {
+	c.RLock()
+	defer c.RUnlock()
+	s, ok := c.schedulers[name]
+	if !ok {
+		return nil, errcode.NewNotFoundErr(errors.Errorf("scheduler %s not found", name))
+	}
+	traceable, ok := s.Scheduler.(schedule.TraceableScheduler)
+	if !ok {
+		return nil, errcode.NewInvalidInputErr(errors.Errorf("scheduler %s does not support tracing", name))
+	}
+	return traceable.GetTracer(), nil
+}
+
+// GetSchedulerTrace returns the decision traces of the recent runs of the
+// scheduler, from the oldest.
+func (h *Handler) GetSchedulerTrace(name string) (*SchedulerTrace, error) {
+	c, err := h.getCoordinator()
+	if err != nil {
+		return nil, err
+	}
+	tracer, err := c.getTracer(name)
+	if err != nil {
+		return nil, err
+	}
+	return &SchedulerTrace{
+		Name:    name,
+		Enabled: tracer.IsEnabled(),
+		Traces:  tracer.GetTraces(),
+	}, nil
+}
+
+// SetSchedulerTrace enables or disables recording the decision traces of the
+// scheduler.
+func (h *Handler) SetSchedulerTrace(name string, enabled bool) error {
+	c, err := h.getCoordinator()
+	if err != nil {
+		return err
+	}
+	tracer, err := c.getTracer(name)
+	if err != nil {
+		return err
+	}
+	tracer.Enable(enabled)
+	return nil
+}
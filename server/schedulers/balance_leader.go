@@ -35,22 +35,27 @@ type balanceLeaderScheduler struct {
 	selector     *schedule.BalanceSelector
 	taintStores  *cache.TTLUint64
 	opController *schedule.OperatorController
+	tracer       *schedule.Tracer
+}
+
+// BalanceLeaderFilters returns the filters of the source and target stores of
+// the balance-leader scheduler, besides the taint cache of the scheduler.
+func BalanceLeaderFilters() []schedule.Filter {
+	return []schedule.Filter{schedule.StoreStateFilter{TransferLeader: true}}
 }
 
 // newBalanceLeaderScheduler creates a scheduler that tends to keep leaders on
 // each store balanced.
 func newBalanceLeaderScheduler(opController *schedule.OperatorController) schedule.Scheduler {
 	taintStores := newTaintCache()
-	filters := []schedule.Filter{
-		schedule.StoreStateFilter{TransferLeader: true},
-		schedule.NewCacheFilter(taintStores),
-	}
+	filters := append(BalanceLeaderFilters(), schedule.NewCacheFilter(taintStores))
 	base := newBaseScheduler(opController)
 	s := &balanceLeaderScheduler{
 		baseScheduler: base,
 		selector:      schedule.NewBalanceSelector(core.LeaderKind, filters),
 		taintStores:   taintStores,
 		opController:  opController,
+		tracer:        schedule.NewTracer(traceCapacity),
 	}
 	return s
 }
@@ -59,6 +64,10 @@ func (l *balanceLeaderScheduler) GetName() string {
 	return "balance-leader-scheduler"
 }
 
+func (l *balanceLeaderScheduler) GetTracer() *schedule.Tracer {
+	return l.tracer
+}
+
 func (l *balanceLeaderScheduler) GetType() string {
 	return "balance-leader"
 }
@@ -69,13 +78,15 @@ func (l *balanceLeaderScheduler) IsScheduleAllowed(cluster schedule.Cluster) boo
 
 func (l *balanceLeaderScheduler) Schedule(cluster schedule.Cluster) []*schedule.Operator {
 	schedulerCounter.WithLabelValues(l.GetName(), "schedule").Inc()
+	trace := l.tracer.Begin()
+	defer l.tracer.Finish(trace)
 
 	stores := cluster.GetStores()
 
 	// source/target is the store with highest/lowest leader score in the list that
 	// can be selected as balance source/target.
-	source := l.selector.SelectSource(cluster, stores)
-	target := l.selector.SelectTarget(cluster, stores)
+	source := l.selector.SelectSourceWithTrace(cluster, stores, trace)
+	target := l.selector.SelectTargetWithTrace(cluster, stores, trace)
 
 	// No store can be selected as source or target.
 	if source == nil || target == nil {
@@ -85,6 +96,7 @@ func (l *balanceLeaderScheduler) Schedule(cluster schedule.Cluster) []*schedule.
 		// to sudden change of a store's leader. Here we clear the taint cache and
 		// re-iterate.
 		l.taintStores.Clear()
+		trace.SetSkip("no store can be selected as the source or the target")
 		return nil
 	}
 
@@ -96,12 +108,14 @@ func (l *balanceLeaderScheduler) Schedule(cluster schedule.Cluster) []*schedule.
 
 	opInfluence := l.opController.GetOpInfluence(cluster)
 	for i := 0; i < balanceLeaderRetryLimit; i++ {
-		if op := l.transferLeaderOut(source, cluster, opInfluence); op != nil {
+		if op := l.transferLeaderOut(source, cluster, opInfluence, trace); op != nil {
 			balanceLeaderCounter.WithLabelValues("transfer_out", sourceAddress).Inc()
+			trace.SetOperators(op)
 			return op
 		}
-		if op := l.transferLeaderIn(target, cluster, opInfluence); op != nil {
+		if op := l.transferLeaderIn(target, cluster, opInfluence, trace); op != nil {
 			balanceLeaderCounter.WithLabelValues("transfer_in", targetAddress).Inc()
+			trace.SetOperators(op)
 			return op
 		}
 	}
@@ -112,55 +126,68 @@ func (l *balanceLeaderScheduler) Schedule(cluster schedule.Cluster) []*schedule.
 	l.taintStores.Put(source.GetID())
 	balanceLeaderCounter.WithLabelValues("add_taint", targetAddress).Inc()
 	l.taintStores.Put(target.GetID())
+	trace.SetSkip("no operator can be created for the selected stores, they are ignored for a while")
 	return nil
 }
 
 // transferLeaderOut transfers leader from the source store.
 // It randomly selects a health region from the source store, then picks
 // the best follower peer and transfers the leader.
-func (l *balanceLeaderScheduler) transferLeaderOut(source *core.StoreInfo, cluster schedule.Cluster, opInfluence schedule.OpInfluence) []*schedule.Operator {
+func (l *balanceLeaderScheduler) transferLeaderOut(source *core.StoreInfo, cluster schedule.Cluster, opInfluence schedule.OpInfluence, trace *schedule.Trace) []*schedule.Operator {
+	trace.AddAttempt()
+	trace.SetSource(source.GetID())
 	region := cluster.RandLeaderRegion(source.GetID(), core.HealthRegion())
 	if region == nil {
 		log.Debug("store has no leader", zap.String("scheduler", l.GetName()), zap.Uint64("store-id", source.GetID()))
 		schedulerCounter.WithLabelValues(l.GetName(), "no_leader_region").Inc()
+		trace.SkipAttempt("the source store has no healthy leader region")
 		return nil
 	}
-	target := l.selector.SelectTarget(cluster, cluster.GetFollowerStores(region))
+	trace.SetRegion(region.GetID())
+	target := l.selector.SelectTargetWithTrace(cluster, cluster.GetFollowerStores(region), trace)
 	if target == nil {
 		log.Debug("region has no target store", zap.String("scheduler", l.GetName()), zap.Uint64("region-id", region.GetID()))
 		schedulerCounter.WithLabelValues(l.GetName(), "no_target_store").Inc()
+		trace.SkipAttempt("no follower store of the region can be selected as the target")
 		return nil
 	}
-	return l.createOperator(region, source, target, cluster, opInfluence)
+	return l.createOperator(region, source, target, cluster, opInfluence, trace)
 }
 
 // transferLeaderIn transfers leader to the target store.
 // It randomly selects a health region from the target store, then picks
 // the worst follower peer and transfers the leader.
-func (l *balanceLeaderScheduler) transferLeaderIn(target *core.StoreInfo, cluster schedule.Cluster, opInfluence schedule.OpInfluence) []*schedule.Operator {
+func (l *balanceLeaderScheduler) transferLeaderIn(target *core.StoreInfo, cluster schedule.Cluster, opInfluence schedule.OpInfluence, trace *schedule.Trace) []*schedule.Operator {
+	trace.AddAttempt()
+	trace.SetTarget(target.GetID())
 	region := cluster.RandFollowerRegion(target.GetID(), core.HealthRegion())
 	if region == nil {
 		log.Debug("store has no follower", zap.String("scheduler", l.GetName()), zap.Uint64("store-id", target.GetID()))
 		schedulerCounter.WithLabelValues(l.GetName(), "no_follower_region").Inc()
+		trace.SkipAttempt("the target store has no healthy follower region")
 		return nil
 	}
+	trace.SetRegion(region.GetID())
 	source := cluster.GetStore(region.GetLeader().GetStoreId())
 	if source == nil {
 		log.Debug("region has no leader", zap.String("scheduler", l.GetName()), zap.Uint64("region-id", region.GetID()))
 		schedulerCounter.WithLabelValues(l.GetName(), "no_leader").Inc()
+		trace.SkipAttempt("the region has no leader")
 		return nil
 	}
-	return l.createOperator(region, source, target, cluster, opInfluence)
+	trace.SetSource(source.GetID())
+	return l.createOperator(region, source, target, cluster, opInfluence, trace)
 }
 
 // createOperator creates the operator according to the source and target store.
 // If the region is hot or the difference between the two stores is tolerable, then
 // no new operator need to be created, otherwise create an operator that transfers
 // the leader from the source store to the target store for the region.
-func (l *balanceLeaderScheduler) createOperator(region *core.RegionInfo, source, target *core.StoreInfo, cluster schedule.Cluster, opInfluence schedule.OpInfluence) []*schedule.Operator {
+func (l *balanceLeaderScheduler) createOperator(region *core.RegionInfo, source, target *core.StoreInfo, cluster schedule.Cluster, opInfluence schedule.OpInfluence, trace *schedule.Trace) []*schedule.Operator {
 	if cluster.IsRegionHot(region.GetID()) {
 		log.Debug("region is hot region, ignore it", zap.String("scheduler", l.GetName()), zap.Uint64("region-id", region.GetID()))
 		schedulerCounter.WithLabelValues(l.GetName(), "region_hot").Inc()
+		trace.SkipAttempt("the region is hot")
 		return nil
 	}
 
@@ -173,6 +200,7 @@ func (l *balanceLeaderScheduler) createOperator(region *core.RegionInfo, source,
 			zap.Int64("target-influence", opInfluence.GetStoreInfluence(target.GetID()).ResourceSize(core.LeaderKind)),
 			zap.Int64("average-region-size", cluster.GetAverageRegionSize()))
 		schedulerCounter.WithLabelValues(l.GetName(), "skip").Inc()
+		traceSkipBalance(trace, cluster, source, target, region, core.LeaderKind, opInfluence)
 		return nil
 	}
 
@@ -36,22 +36,35 @@ type balanceRegionScheduler struct {
 	selector     *schedule.BalanceSelector
 	taintStores  *cache.TTLUint64
 	opController *schedule.OperatorController
+	tracer       *schedule.Tracer
+}
+
+// BalanceRegionSourceFilters returns the filters of the source stores of the
+// balance-region scheduler, besides the taint cache of the scheduler.
+func BalanceRegionSourceFilters() []schedule.Filter {
+	return []schedule.Filter{schedule.StoreStateFilter{MoveRegion: true}}
+}
+
+// BalanceRegionTargetFilters returns the filters of the target stores of the
+// balance-region scheduler, which are the ones of the replica checker to
+// replace a peer. The filters depending on the region, which exclude the
+// stores of the region and keep the distinct score, are not included.
+func BalanceRegionTargetFilters(cluster schedule.Cluster) []schedule.Filter {
+	return schedule.NewReplicaChecker(cluster, nil).TargetFilters()
 }
 
 // newBalanceRegionScheduler creates a scheduler that tends to keep regions on
 // each store balanced.
 func newBalanceRegionScheduler(opController *schedule.OperatorController) schedule.Scheduler {
 	taintStores := newTaintCache()
-	filters := []schedule.Filter{
-		schedule.StoreStateFilter{MoveRegion: true},
-		schedule.NewCacheFilter(taintStores),
-	}
+	filters := append(BalanceRegionSourceFilters(), schedule.NewCacheFilter(taintStores))
 	base := newBaseScheduler(opController)
 	s := &balanceRegionScheduler{
 		baseScheduler: base,
 		selector:      schedule.NewBalanceSelector(core.RegionKind, filters),
 		taintStores:   taintStores,
 		opController:  opController,
+		tracer:        schedule.NewTracer(traceCapacity),
 	}
 	return s
 }
@@ -60,6 +73,10 @@ func (s *balanceRegionScheduler) GetName() string {
 	return "balance-region-scheduler"
 }
 
+func (s *balanceRegionScheduler) GetTracer() *schedule.Tracer {
+	return s.tracer
+}
+
 func (s *balanceRegionScheduler) GetType() string {
 	return "balance-region"
 }
@@ -70,16 +87,19 @@ func (s *balanceRegionScheduler) IsScheduleAllowed(cluster schedule.Cluster) boo
 
 func (s *balanceRegionScheduler) Schedule(cluster schedule.Cluster) []*schedule.Operator {
 	schedulerCounter.WithLabelValues(s.GetName(), "schedule").Inc()
+	trace := s.tracer.Begin()
+	defer s.tracer.Finish(trace)
 
 	stores := cluster.GetStores()
 
 	// source is the store with highest region score in the list that can be selected as balance source.
-	source := s.selector.SelectSource(cluster, stores)
+	source := s.selector.SelectSourceWithTrace(cluster, stores, trace)
 	if source == nil {
 		schedulerCounter.WithLabelValues(s.GetName(), "no_store").Inc()
 		// Unlike the balanceLeaderScheduler, we don't need to clear the taintCache
 		// here. Because normally region score won't change rapidly, and the region
 		// balance requires lower sensitivity compare to leader balance.
+		trace.SetSkip("no store can be selected as the source")
 		return nil
 	}
 
@@ -90,6 +110,8 @@ func (s *balanceRegionScheduler) Schedule(cluster schedule.Cluster) []*schedule.
 	opInfluence := s.opController.GetOpInfluence(cluster)
 	var hasPotentialTarget bool
 	for i := 0; i < balanceRegionRetryLimit; i++ {
+		trace.AddAttempt()
+		trace.SetSource(source.GetID())
 		// Priority the region that has a follower in the source store.
 		region := cluster.RandFollowerRegion(source.GetID(), core.HealthRegion())
 		if region == nil {
@@ -98,14 +120,17 @@ func (s *balanceRegionScheduler) Schedule(cluster schedule.Cluster) []*schedule.
 		}
 		if region == nil {
 			schedulerCounter.WithLabelValues(s.GetName(), "no_region").Inc()
+			trace.SkipAttempt("the source store has no healthy region")
 			continue
 		}
 		log.Debug("select region", zap.String("scheduler", s.GetName()), zap.Uint64("region-id", region.GetID()))
+		trace.SetRegion(region.GetID())
 
 		// We don't schedule region with abnormal number of replicas.
 		if len(region.GetPeers()) != cluster.GetMaxReplicas() {
 			log.Debug("region has abnormal replica count", zap.String("scheduler", s.GetName()), zap.Uint64("region-id", region.GetID()))
 			schedulerCounter.WithLabelValues(s.GetName(), "abnormal_replica").Inc()
+			trace.SkipAttemptf("the region has %d replicas, not max-replicas %d", len(region.GetPeers()), cluster.GetMaxReplicas())
 			continue
 		}
 
@@ -113,18 +138,22 @@ func (s *balanceRegionScheduler) Schedule(cluster schedule.Cluster) []*schedule.
 		if cluster.IsRegionHot(region.GetID()) {
 			log.Debug("region is hot", zap.String("scheduler", s.GetName()), zap.Uint64("region-id", region.GetID()))
 			schedulerCounter.WithLabelValues(s.GetName(), "region_hot").Inc()
+			trace.SkipAttempt("the region is hot")
 			continue
 		}
 
-		if !s.hasPotentialTarget(cluster, region, source, opInfluence) {
+		if !s.hasPotentialTarget(cluster, region, source, opInfluence, trace) {
+			trace.SkipAttempt("no store can be the target of the region")
 			continue
 		}
 		hasPotentialTarget = true
 
 		oldPeer := region.GetStorePeer(source.GetID())
-		if op := s.transferPeer(cluster, region, oldPeer, opInfluence); op != nil {
+		if op := s.transferPeer(cluster, region, oldPeer, opInfluence, trace); op != nil {
 			schedulerCounter.WithLabelValues(s.GetName(), "new_operator").Inc()
-			return []*schedule.Operator{op}
+			ops := []*schedule.Operator{op}
+			trace.SetOperators(ops)
+			return ops
 		}
 	}
 
@@ -133,13 +162,16 @@ func (s *balanceRegionScheduler) Schedule(cluster schedule.Cluster) []*schedule.
 		log.Debug("no operator created for selected store", zap.String("scheduler", s.GetName()), zap.Uint64("store-id", source.GetID()))
 		balanceRegionCounter.WithLabelValues("add_taint", sourceAddress).Inc()
 		s.taintStores.Put(source.GetID())
+		trace.SetSkip("no potential target for the source store, it is ignored for a while")
+		return nil
 	}
 
+	trace.SetSkip("no operator can be created for the source store")
 	return nil
 }
 
 // transferPeer selects the best store to create a new peer to replace the old peer.
-func (s *balanceRegionScheduler) transferPeer(cluster schedule.Cluster, region *core.RegionInfo, oldPeer *metapb.Peer, opInfluence schedule.OpInfluence) *schedule.Operator {
+func (s *balanceRegionScheduler) transferPeer(cluster schedule.Cluster, region *core.RegionInfo, oldPeer *metapb.Peer, opInfluence schedule.OpInfluence, trace *schedule.Trace) *schedule.Operator {
 	// scoreGuard guarantees that the distinct score will not decrease.
 	stores := cluster.GetRegionStores(region)
 	source := cluster.GetStore(oldPeer.GetStoreId())
@@ -149,8 +181,10 @@ func (s *balanceRegionScheduler) transferPeer(cluster schedule.Cluster, region *
 	storeID, _ := checker.SelectBestReplacementStore(region, oldPeer, scoreGuard)
 	if storeID == 0 {
 		schedulerCounter.WithLabelValues(s.GetName(), "no_replacement").Inc()
+		trace.SkipAttempt("no store can replace the peer on the source store")
 		return nil
 	}
+	trace.SetTarget(storeID)
 
 	target := cluster.GetStore(storeID)
 	log.Debug("", zap.Uint64("region-id", region.GetID()), zap.Uint64("source-store", source.GetID()), zap.Uint64("target-store", target.GetID()))
@@ -158,18 +192,20 @@ func (s *balanceRegionScheduler) transferPeer(cluster schedule.Cluster, region *
 	if !shouldBalance(cluster, source, target, region, core.RegionKind, opInfluence) {
 		log.Debug("skip balance region",
 			zap.String("scheduler", s.GetName()), zap.Uint64("region-id", region.GetID()), zap.Uint64("source-store", source.GetID()), zap.Uint64("target-store", target.GetID()),
-			zap.Int64("source-size", source.GetRegionSize()), zap.Float64("source-score", source.RegionScore(cluster.GetHighSpaceRatio(), cluster.GetLowSpaceRatio(), 0)),
+			zap.Int64("source-size", source.GetRegionSize()), zap.Float64("source-score", source.RegionScore(cluster.GetStoreHighSpaceRatio(source.GetID()), cluster.GetStoreLowSpaceRatio(source.GetID()), 0)),
 			zap.Int64("source-influence", opInfluence.GetStoreInfluence(source.GetID()).ResourceSize(core.RegionKind)),
-			zap.Int64("target-size", target.GetRegionSize()), zap.Float64("target-score", target.RegionScore(cluster.GetHighSpaceRatio(), cluster.GetLowSpaceRatio(), 0)),
+			zap.Int64("target-size", target.GetRegionSize()), zap.Float64("target-score", target.RegionScore(cluster.GetStoreHighSpaceRatio(target.GetID()), cluster.GetStoreLowSpaceRatio(target.GetID()), 0)),
 			zap.Int64("target-influence", opInfluence.GetStoreInfluence(target.GetID()).ResourceSize(core.RegionKind)),
 			zap.Int64("average-region-size", cluster.GetAverageRegionSize()))
 		schedulerCounter.WithLabelValues(s.GetName(), "skip").Inc()
+		traceSkipBalance(trace, cluster, source, target, region, core.RegionKind, opInfluence)
 		return nil
 	}
 
 	newPeer, err := cluster.AllocPeer(storeID)
 	if err != nil {
 		schedulerCounter.WithLabelValues(s.GetName(), "no_peer").Inc()
+		trace.SkipAttemptf("failed to allocate the peer: %v", err)
 		return nil
 	}
 	balanceRegionCounter.WithLabelValues("move_peer", source.GetAddress()+"-out").Inc()
@@ -177,6 +213,7 @@ func (s *balanceRegionScheduler) transferPeer(cluster schedule.Cluster, region *
 	op, err := schedule.CreateMovePeerOperator("balance-region", cluster, region, schedule.OpBalance, oldPeer.GetStoreId(), newPeer.GetStoreId(), newPeer.GetId())
 	if err != nil {
 		schedulerCounter.WithLabelValues(s.GetName(), "create_operator_fail").Inc()
+		trace.SkipAttemptf("failed to create the operator: %v", err)
 		return nil
 	}
 	return op
@@ -187,22 +224,26 @@ func (s *balanceRegionScheduler) transferPeer(cluster schedule.Cluster, region *
 // The main factor for judgment includes StoreState, DistinctScore, and
 // ResourceScore, while excludes factors such as ServerBusy, too many snapshot,
 // which may recover soon.
-func (s *balanceRegionScheduler) hasPotentialTarget(cluster schedule.Cluster, region *core.RegionInfo, source *core.StoreInfo, opInfluence schedule.OpInfluence) bool {
+func (s *balanceRegionScheduler) hasPotentialTarget(cluster schedule.Cluster, region *core.RegionInfo, source *core.StoreInfo, opInfluence schedule.OpInfluence, trace *schedule.Trace) bool {
 	filters := []schedule.Filter{
 		schedule.NewExcludedFilter(nil, region.GetStoreIds()),
 		schedule.NewDistinctScoreFilter(cluster.GetLocationLabels(), cluster.GetRegionStores(region), source),
 	}
 
 	for _, store := range cluster.GetStores() {
-		if schedule.FilterTarget(cluster, store, filters) {
+		if filter := schedule.TargetFilteredBy(cluster, store, filters); filter != nil {
+			trace.RejectTarget(cluster, store, filter)
 			continue
 		}
 		if !store.IsUp() || store.DownTime() > cluster.GetMaxStoreDownTime() {
+			trace.RejectTargetWithReason(store.GetID(), "state", "the store is not up or is down")
 			continue
 		}
 		if !shouldBalance(cluster, source, store, region, core.RegionKind, opInfluence) {
+			trace.RejectTargetWithReason(store.GetID(), "score", "the score of the store would not be lower than the source store after the move")
 			continue
 		}
+		trace.AddTarget(store.GetID(), store.RegionScore(cluster.GetStoreHighSpaceRatio(store.GetID()), cluster.GetStoreLowSpaceRatio(store.GetID()), 0))
 		return true
 	}
 	return false
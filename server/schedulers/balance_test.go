@@ -230,6 +230,52 @@ func (s *testBalanceLeaderSchedulerSuite) TestBalanceFilter(c *C) {
 	c.Assert(s.schedule(), HasLen, 0)
 }
 
+func (s *testBalanceLeaderSchedulerSuite) TestTrace(c *C) {
+	// Stores:     1    2    3
+	// Leaders:    1    2   16
+	// Region1:    F    F    L
+	s.tc.AddLeaderStore(1, 1)
+	s.tc.AddLeaderStore(2, 2)
+	s.tc.AddLeaderStore(3, 16)
+	s.tc.AddLeaderRegion(1, 3, 1, 2)
+	tracer := s.lb.(schedule.TraceableScheduler).GetTracer()
+
+	// Nothing is recorded until the tracer is enabled.
+	c.Assert(s.schedule(), HasLen, 1)
+	c.Assert(tracer.GetTraces(), HasLen, 0)
+
+	tracer.Enable(true)
+	s.tc.SetStoreDown(1)
+	testutil.CheckTransferLeader(c, s.schedule()[0], schedule.OpBalance, 3, 2)
+	traces := tracer.GetTraces()
+	c.Assert(traces, HasLen, 1)
+	trace := traces[0]
+	c.Assert(trace.Source, Equals, uint64(3))
+	c.Assert(trace.Target, Equals, uint64(2))
+	c.Assert(trace.Operators, HasLen, 1)
+	c.Assert(trace.Skip, Equals, "")
+	for _, candidate := range trace.TargetCandidates {
+		if candidate.StoreID == 1 {
+			c.Assert(candidate.Filter, Equals, "store-state-filter")
+			c.Assert(candidate.Reason, Matches, "the store has been down for .*")
+		} else {
+			c.Assert(candidate.Filter, Equals, "")
+		}
+	}
+	c.Assert(trace.Attempts, HasLen, 1)
+	c.Assert(trace.Attempts[0].RegionID, Equals, uint64(1))
+
+	// Store 3 is the only one left, so it is both the source and the target.
+	s.tc.SetStoreDown(2)
+	c.Assert(s.schedule(), HasLen, 0)
+	traces = tracer.GetTraces()
+	c.Assert(traces, HasLen, 2)
+	c.Assert(traces[1].Skip, Equals, "no operator can be created for the selected stores, they are ignored for a while")
+	for _, attempt := range traces[1].Attempts {
+		c.Assert(attempt.Skip, Not(Equals), "")
+	}
+}
+
 func (s *testBalanceLeaderSchedulerSuite) TestLeaderWeight(c *C) {
 	// Stores:	1	2	3	4
 	// Leaders:    10      10      10      10
@@ -29,6 +29,10 @@ const (
 	ScheduleIntervalFactor = 1.3
 )
 
+// traceCapacity is the number of the recent runs a scheduler keeps the traces
+// of when tracing is enabled.
+const traceCapacity = 100
+
 type intervalGrowthType int
 
 const (
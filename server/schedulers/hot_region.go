@@ -74,8 +74,9 @@ type balanceHotRegionsScheduler struct {
 	types []BalanceType
 
 	// store id -> hot regions statistics as the role of leader
-	stats *storeStatistics
-	r     *rand.Rand
+	stats  *storeStatistics
+	r      *rand.Rand
+	tracer *schedule.Tracer
 }
 
 func newBalanceHotRegionsScheduler(opController *schedule.OperatorController) *balanceHotRegionsScheduler {
@@ -86,6 +87,7 @@ func newBalanceHotRegionsScheduler(opController *schedule.OperatorController) *b
 		stats:         newStoreStaticstics(),
 		types:         []BalanceType{hotWriteRegionBalance, hotReadRegionBalance},
 		r:             rand.New(rand.NewSource(time.Now().UnixNano())),
+		tracer:        schedule.NewTracer(traceCapacity),
 	}
 }
 
@@ -97,6 +99,7 @@ func newBalanceHotReadRegionsScheduler(opController *schedule.OperatorController
 		stats:         newStoreStaticstics(),
 		types:         []BalanceType{hotReadRegionBalance},
 		r:             rand.New(rand.NewSource(time.Now().UnixNano())),
+		tracer:        schedule.NewTracer(traceCapacity),
 	}
 }
 
@@ -108,6 +111,7 @@ func newBalanceHotWriteRegionsScheduler(opController *schedule.OperatorControlle
 		stats:         newStoreStaticstics(),
 		types:         []BalanceType{hotWriteRegionBalance},
 		r:             rand.New(rand.NewSource(time.Now().UnixNano())),
+		tracer:        schedule.NewTracer(traceCapacity),
 	}
 }
 
@@ -119,6 +123,10 @@ func (h *balanceHotRegionsScheduler) GetType() string {
 	return "hot-region"
 }
 
+func (h *balanceHotRegionsScheduler) GetTracer() *schedule.Tracer {
+	return h.tracer
+}
+
 func (h *balanceHotRegionsScheduler) IsScheduleAllowed(cluster schedule.Cluster) bool {
 	return h.allowBalanceLeader(cluster) || h.allowBalanceRegion(cluster)
 }
@@ -142,27 +150,31 @@ func (h *balanceHotRegionsScheduler) allowBalanceRegion(cluster schedule.Cluster
 
 func (h *balanceHotRegionsScheduler) Schedule(cluster schedule.Cluster) []*schedule.Operator {
 	schedulerCounter.WithLabelValues(h.GetName(), "schedule").Inc()
-	return h.dispatch(h.types[h.r.Int()%len(h.types)], cluster)
+	trace := h.tracer.Begin()
+	defer h.tracer.Finish(trace)
+	ops := h.dispatch(h.types[h.r.Int()%len(h.types)], cluster, trace)
+	trace.SetOperators(ops)
+	return ops
 }
 
-func (h *balanceHotRegionsScheduler) dispatch(typ BalanceType, cluster schedule.Cluster) []*schedule.Operator {
+func (h *balanceHotRegionsScheduler) dispatch(typ BalanceType, cluster schedule.Cluster, trace *schedule.Trace) []*schedule.Operator {
 	h.Lock()
 	defer h.Unlock()
 	switch typ {
 	case hotReadRegionBalance:
 		h.stats.readStatAsLeader = calcScore(cluster.RegionReadStats(), cluster, core.LeaderKind)
-		return h.balanceHotReadRegions(cluster)
+		return h.balanceHotReadRegions(cluster, trace)
 	case hotWriteRegionBalance:
 		h.stats.writeStatAsLeader = calcScore(cluster.RegionWriteStats(), cluster, core.LeaderKind)
 		h.stats.writeStatAsPeer = calcScore(cluster.RegionWriteStats(), cluster, core.RegionKind)
-		return h.balanceHotWriteRegions(cluster)
+		return h.balanceHotWriteRegions(cluster, trace)
 	}
 	return nil
 }
 
-func (h *balanceHotRegionsScheduler) balanceHotReadRegions(cluster schedule.Cluster) []*schedule.Operator {
+func (h *balanceHotRegionsScheduler) balanceHotReadRegions(cluster schedule.Cluster, trace *schedule.Trace) []*schedule.Operator {
 	// balance by leader
-	srcRegion, newLeader := h.balanceByLeader(cluster, h.stats.readStatAsLeader)
+	srcRegion, newLeader := h.balanceByLeader(cluster, h.stats.readStatAsLeader, trace)
 	if srcRegion != nil {
 		schedulerCounter.WithLabelValues(h.GetName(), "move_leader").Inc()
 		step := schedule.TransferLeader{FromStore: srcRegion.GetLeader().GetStoreId(), ToStore: newLeader.GetStoreId()}
@@ -170,33 +182,36 @@ func (h *balanceHotRegionsScheduler) balanceHotReadRegions(cluster schedule.Clus
 	}
 
 	// balance by peer
-	srcRegion, srcPeer, destPeer := h.balanceByPeer(cluster, h.stats.readStatAsLeader)
+	srcRegion, srcPeer, destPeer := h.balanceByPeer(cluster, h.stats.readStatAsLeader, trace)
 	if srcRegion != nil {
 		op, err := schedule.CreateMovePeerOperator("moveHotReadRegion", cluster, srcRegion, schedule.OpHotRegion, srcPeer.GetStoreId(), destPeer.GetStoreId(), destPeer.GetId())
 		if err != nil {
 			schedulerCounter.WithLabelValues(h.GetName(), "create_operator_fail").Inc()
+			trace.SkipAttemptf("failed to create the operator: %v", err)
 			return nil
 		}
 		schedulerCounter.WithLabelValues(h.GetName(), "move_peer").Inc()
 		return []*schedule.Operator{op}
 	}
 	schedulerCounter.WithLabelValues(h.GetName(), "skip").Inc()
+	trace.SetSkip("no hot read region can be balanced")
 	return nil
 }
 
 // balanceHotRetryLimit is the limit to retry schedule for selected balance strategy.
 const balanceHotRetryLimit = 10
 
-func (h *balanceHotRegionsScheduler) balanceHotWriteRegions(cluster schedule.Cluster) []*schedule.Operator {
+func (h *balanceHotRegionsScheduler) balanceHotWriteRegions(cluster schedule.Cluster, trace *schedule.Trace) []*schedule.Operator {
 	for i := 0; i < balanceHotRetryLimit; i++ {
 		switch h.r.Int() % 2 {
 		case 0:
 			// balance by peer
-			srcRegion, srcPeer, destPeer := h.balanceByPeer(cluster, h.stats.writeStatAsPeer)
+			srcRegion, srcPeer, destPeer := h.balanceByPeer(cluster, h.stats.writeStatAsPeer, trace)
 			if srcRegion != nil {
 				op, err := schedule.CreateMovePeerOperator("moveHotWriteRegion", cluster, srcRegion, schedule.OpHotRegion, srcPeer.GetStoreId(), destPeer.GetStoreId(), destPeer.GetId())
 				if err != nil {
 					schedulerCounter.WithLabelValues(h.GetName(), "create_operator_fail").Inc()
+					trace.SkipAttemptf("failed to create the operator: %v", err)
 					return nil
 				}
 				schedulerCounter.WithLabelValues(h.GetName(), "move_peer").Inc()
@@ -204,7 +219,7 @@ func (h *balanceHotRegionsScheduler) balanceHotWriteRegions(cluster schedule.Clu
 			}
 		case 1:
 			// balance by leader
-			srcRegion, newLeader := h.balanceByLeader(cluster, h.stats.writeStatAsLeader)
+			srcRegion, newLeader := h.balanceByLeader(cluster, h.stats.writeStatAsLeader, trace)
 			if srcRegion != nil {
 				schedulerCounter.WithLabelValues(h.GetName(), "move_leader").Inc()
 				step := schedule.TransferLeader{FromStore: srcRegion.GetLeader().GetStoreId(), ToStore: newLeader.GetStoreId()}
@@ -214,6 +229,7 @@ func (h *balanceHotRegionsScheduler) balanceHotWriteRegions(cluster schedule.Clu
 	}
 
 	schedulerCounter.WithLabelValues(h.GetName(), "skip").Inc()
+	trace.SetSkip("no hot write region can be balanced")
 	return nil
 }
 
@@ -269,7 +285,7 @@ func calcScore(items []*core.RegionStat, cluster schedule.Cluster, kind core.Res
 }
 
 // balanceByPeer balances the peer distribution of hot regions.
-func (h *balanceHotRegionsScheduler) balanceByPeer(cluster schedule.Cluster, storesStat core.StoreHotRegionsStat) (*core.RegionInfo, *metapb.Peer, *metapb.Peer) {
+func (h *balanceHotRegionsScheduler) balanceByPeer(cluster schedule.Cluster, storesStat core.StoreHotRegionsStat, trace *schedule.Trace) (*core.RegionInfo, *metapb.Peer, *metapb.Peer) {
 	if !h.allowBalanceRegion(cluster) {
 		return nil, nil, nil
 	}
@@ -278,6 +294,7 @@ func (h *balanceHotRegionsScheduler) balanceByPeer(cluster schedule.Cluster, sto
 	if srcStoreID == 0 {
 		return nil, nil, nil
 	}
+	trace.SetSource(srcStoreID)
 
 	// get one source region and a target store.
 	// For each region in the source store, we try to find the best target store;
@@ -286,8 +303,12 @@ func (h *balanceHotRegionsScheduler) balanceByPeer(cluster schedule.Cluster, sto
 	var destStoreID uint64
 	for _, i := range h.r.Perm(storesStat[srcStoreID].RegionsStat.Len()) {
 		rs := storesStat[srcStoreID].RegionsStat[i]
+		trace.AddAttempt()
+		trace.SetSource(srcStoreID)
+		trace.SetRegion(rs.RegionID)
 		srcRegion := cluster.GetRegion(rs.RegionID)
 		if srcRegion == nil || len(srcRegion.GetDownPeers()) != 0 || len(srcRegion.GetPendingPeers()) != 0 {
+			trace.SkipAttempt("the region is not found or has down or pending peers")
 			continue
 		}
 
@@ -299,18 +320,22 @@ func (h *balanceHotRegionsScheduler) balanceByPeer(cluster schedule.Cluster, sto
 		}
 		destStoreIDs := make([]uint64, 0, len(stores))
 		for _, store := range stores {
-			if schedule.FilterTarget(cluster, store, filters) {
+			if filter := schedule.TargetFilteredBy(cluster, store, filters); filter != nil {
+				trace.RejectTarget(cluster, store, filter)
 				continue
 			}
+			trace.AddTarget(store.GetID(), storeFlowBytes(storesStat, store.GetID()))
 			destStoreIDs = append(destStoreIDs, store.GetID())
 		}
 
 		destStoreID = h.selectDestStore(destStoreIDs, rs.FlowBytes, srcStoreID, storesStat)
 		if destStoreID != 0 {
 			h.adjustBalanceLimit(srcStoreID, storesStat)
+			trace.SetTarget(destStoreID)
 
 			srcPeer := srcRegion.GetStorePeer(srcStoreID)
 			if srcPeer == nil {
+				trace.SkipAttempt("the region has no peer on the source store")
 				return nil, nil, nil
 			}
 
@@ -319,18 +344,29 @@ func (h *balanceHotRegionsScheduler) balanceByPeer(cluster schedule.Cluster, sto
 			destPeer, err := cluster.AllocPeer(destStoreID)
 			if err != nil {
 				log.Error("failed to allocate peer", zap.Error(err))
+				trace.SkipAttemptf("failed to allocate the peer: %v", err)
 				return nil, nil, nil
 			}
 
 			return srcRegion, srcPeer, destPeer
 		}
+		trace.SkipAttempt("no target store has less hot regions and flow after the move")
 	}
 
 	return nil, nil, nil
 }
 
+// storeFlowBytes returns the total flow bytes of the hot regions on the store,
+// which is the score of the store as a target.
+func storeFlowBytes(storesStat core.StoreHotRegionsStat, storeID uint64) float64 {
+	if stat, ok := storesStat[storeID]; ok {
+		return float64(stat.TotalFlowBytes)
+	}
+	return 0
+}
+
 // balanceByLeader balances the leader distribution of hot regions.
-func (h *balanceHotRegionsScheduler) balanceByLeader(cluster schedule.Cluster, storesStat core.StoreHotRegionsStat) (*core.RegionInfo, *metapb.Peer) {
+func (h *balanceHotRegionsScheduler) balanceByLeader(cluster schedule.Cluster, storesStat core.StoreHotRegionsStat, trace *schedule.Trace) (*core.RegionInfo, *metapb.Peer) {
 	if !h.allowBalanceLeader(cluster) {
 		return nil, nil
 	}
@@ -339,35 +375,47 @@ func (h *balanceHotRegionsScheduler) balanceByLeader(cluster schedule.Cluster, s
 	if srcStoreID == 0 {
 		return nil, nil
 	}
+	trace.SetSource(srcStoreID)
 
 	// select destPeer
 	for _, i := range h.r.Perm(storesStat[srcStoreID].RegionsStat.Len()) {
 		rs := storesStat[srcStoreID].RegionsStat[i]
+		trace.AddAttempt()
+		trace.SetSource(srcStoreID)
+		trace.SetRegion(rs.RegionID)
 		srcRegion := cluster.GetRegion(rs.RegionID)
 		if srcRegion == nil || len(srcRegion.GetDownPeers()) != 0 || len(srcRegion.GetPendingPeers()) != 0 {
+			trace.SkipAttempt("the region is not found or has down or pending peers")
 			continue
 		}
 
 		filters := []schedule.Filter{schedule.StoreStateFilter{TransferLeader: true}}
 		candidateStoreIDs := make([]uint64, 0, len(srcRegion.GetPeers())-1)
 		for _, store := range cluster.GetFollowerStores(srcRegion) {
-			if !schedule.FilterTarget(cluster, store, filters) {
-				candidateStoreIDs = append(candidateStoreIDs, store.GetID())
+			if filter := schedule.TargetFilteredBy(cluster, store, filters); filter != nil {
+				trace.RejectTarget(cluster, store, filter)
+				continue
 			}
+			trace.AddTarget(store.GetID(), storeFlowBytes(storesStat, store.GetID()))
+			candidateStoreIDs = append(candidateStoreIDs, store.GetID())
 		}
 		if len(candidateStoreIDs) == 0 {
+			trace.SkipAttempt("no follower can be the new leader")
 			continue
 		}
 		destStoreID := h.selectDestStore(candidateStoreIDs, rs.FlowBytes, srcStoreID, storesStat)
 		if destStoreID == 0 {
+			trace.SkipAttempt("no follower store has less hot regions and flow after the move")
 			continue
 		}
+		trace.SetTarget(destStoreID)
 
 		destPeer := srcRegion.GetStoreVoter(destStoreID)
 		if destPeer != nil {
 			h.adjustBalanceLimit(srcStoreID, storesStat)
 			return srcRegion, destPeer
 		}
+		trace.SkipAttempt("the target store has no voter of the region")
 	}
 	return nil, nil
 }
@@ -44,6 +44,14 @@ func minDuration(a, b time.Duration) time.Duration {
 }
 
 func shouldBalance(cluster schedule.Cluster, source, target *core.StoreInfo, region *core.RegionInfo, kind core.ResourceKind, opInfluence schedule.OpInfluence) bool {
+	sourceScore, targetScore := balanceScores(cluster, source, target, region, kind, opInfluence)
+	// Make sure after move, source score is still greater than target score.
+	return sourceScore > targetScore
+}
+
+// balanceScores returns the scores of the source and target store after the
+// region is moved, with the influence of the running operators.
+func balanceScores(cluster schedule.Cluster, source, target *core.StoreInfo, region *core.RegionInfo, kind core.ResourceKind, opInfluence schedule.OpInfluence) (float64, float64) {
 	// The reason we use max(regionSize, averageRegionSize) to check is:
 	// 1. prevent moving small regions between stores with close scores, leading to unnecessary balance.
 	// 2. prevent moving huge regions, leading to over balance.
@@ -56,9 +64,18 @@ func shouldBalance(cluster schedule.Cluster, source, target *core.StoreInfo, reg
 	sourceDelta := opInfluence.GetStoreInfluence(source.GetID()).ResourceSize(kind) - regionSize
 	targetDelta := opInfluence.GetStoreInfluence(target.GetID()).ResourceSize(kind) + regionSize
 
-	// Make sure after move, source score is still greater than target score.
-	return source.ResourceScore(kind, cluster.GetHighSpaceRatio(), cluster.GetLowSpaceRatio(), sourceDelta) >
-		target.ResourceScore(kind, cluster.GetHighSpaceRatio(), cluster.GetLowSpaceRatio(), targetDelta)
+	return source.ResourceScore(kind, cluster.GetStoreHighSpaceRatio(source.GetID()), cluster.GetStoreLowSpaceRatio(source.GetID()), sourceDelta),
+		target.ResourceScore(kind, cluster.GetStoreHighSpaceRatio(target.GetID()), cluster.GetStoreLowSpaceRatio(target.GetID()), targetDelta)
+}
+
+// traceSkipBalance records why shouldBalance returns false to the trace.
+func traceSkipBalance(trace *schedule.Trace, cluster schedule.Cluster, source, target *core.StoreInfo, region *core.RegionInfo, kind core.ResourceKind, opInfluence schedule.OpInfluence) {
+	if trace == nil {
+		return
+	}
+	sourceScore, targetScore := balanceScores(cluster, source, target, region, kind, opInfluence)
+	trace.SkipAttemptf("the score %.2f of source store %d would not be higher than the score %.2f of target store %d after the move",
+		sourceScore, source.GetID(), targetScore, target.GetID())
 }
 
 func adjustBalanceLimit(cluster schedule.Cluster, kind core.ResourceKind) uint64 {
@@ -15,11 +15,11 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"math/rand"
 	"net/http"
 	"path"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
@@ -28,13 +28,17 @@ import (
 
 	"github.com/coreos/go-semver/semver"
 	"github.com/golang/protobuf/proto"
+	"github.com/pingcap/errcode"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	log "github.com/pingcap/log"
+	"github.com/pingcap/pd/pkg/encryption"
 	"github.com/pingcap/pd/pkg/etcdutil"
 	"github.com/pingcap/pd/pkg/logutil"
+	"github.com/pingcap/pd/pkg/tlsutil"
 	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/server/namespace"
+	syncer "github.com/pingcap/pd/server/region_syncer"
 	"github.com/pkg/errors"
 	"go.etcd.io/etcd/clientv3"
 	"go.etcd.io/etcd/embed"
@@ -44,9 +48,15 @@ import (
 )
 
 const (
-	etcdTimeout           = time.Second * 3
-	etcdStartTimeout      = time.Minute * 5
+	etcdTimeout      = time.Second * 3
+	etcdStartTimeout = time.Minute * 5
+	// learnerPromoteTimeout bounds the wait for the leader to promote the
+	// learner, as a leader without learner support never promotes it.
+	learnerPromoteTimeout = time.Minute * 10
 	serverMetricsInterval = time.Minute
+	// membersCacheTTL bounds how long a member change is unseen by
+	// GetCachedMembers.
+	membersCacheTTL = time.Second * 5
 	// pdRootPath for all pd servers.
 	pdRootPath      = "/pd"
 	pdAPIPrefix     = "/pd/"
@@ -61,6 +71,8 @@ type Server struct {
 	// Server state.
 	isServing int64
 	leader    atomic.Value
+	// The etcd revision at which this server is elected as the leader.
+	leaderTerm uint64
 
 	// Configs and initial fields.
 	cfg         *Config
@@ -83,6 +95,11 @@ type Server struct {
 	// etcd leader key when the PD node is successfully elected as the leader
 	// of the cluster. Every write will use it to check leadership.
 	memberValue string
+	// members are the members of the cluster loaded at membersTime, which
+	// are reused by GetCachedMembers for membersCacheTTL.
+	membersLock sync.Mutex
+	members     []*pdpb.Member
+	membersTime time.Time
 
 	// Server services.
 	// for id allocator, we can use one allocator for
@@ -91,6 +108,17 @@ type Server struct {
 	idAlloc *idAllocator
 	// for kv operation.
 	kv *core.KV
+	// metricsHistory keeps the metrics of stores in the region storage.
+	metricsHistory *core.MetricsHistory
+	// serviceSafePointLock serializes the updates of GC safe points.
+	serviceSafePointLock sync.Mutex
+	// configHistoryLock serializes the versions of config history.
+	configHistoryLock sync.Mutex
+	// fileConfig is the config loaded by the last reload of the config file,
+	// which the reloaded config is compared with, so that each change of the
+	// static items is reported once. It is nil before the first reload.
+	fileConfigLock sync.Mutex
+	fileConfig     *Config
 	// for namespace.
 	classifier namespace.Classifier
 	// for raft cluster
@@ -103,16 +131,21 @@ type Server struct {
 	// Zap logger
 	lg       *zap.Logger
 	logProps *log.ZapProperties
+	// auditFile is nil if the audit records are not written to a file.
+	auditFile *auditFile
 }
 
 // CreateServer creates the UNINITIALIZED pd server with given configuration.
 func CreateServer(cfg *Config, apiRegister func(*Server) http.Handler) (*Server, error) {
+	core.SetRedactInfoLog(cfg.Security.RedactInfoLog)
+	core.SetRedactRegionAPI(cfg.Security.RedactRegionAPI)
 	log.Info("PD Config", zap.Reflect("config", cfg))
 	rand.Seed(time.Now().UnixNano())
 
 	s := &Server{
 		cfg:         cfg,
 		scheduleOpt: newScheduleOption(cfg),
+		auditFile:   newAuditFile(&cfg.Audit),
 	}
 	s.handler = newHandler(s)
 
@@ -143,6 +176,7 @@ func CreateServer(cfg *Config, apiRegister func(*Server) http.Handler) (*Server,
 
 func (s *Server) startEtcd(ctx context.Context) error {
 	log.Info("start embed etcd")
+	serverCtx := ctx
 	ctx, cancel := context.WithTimeout(ctx, etcdStartTimeout)
 	defer cancel()
 
@@ -170,6 +204,11 @@ func (s *Server) startEtcd(ctx context.Context) error {
 	case <-ctx.Done():
 		return errors.Errorf("canceled when waiting embed etcd to be ready")
 	}
+	// A learner does not serve the client requests, wait until the leader
+	// promotes it, which takes as long as catching up with the leader.
+	if err := waitLearnerPromoted(serverCtx, etcd); err != nil {
+		return err
+	}
 
 	endpoints := []string{s.etcdCfg.ACUrls[0].String()}
 	log.Info("create etcd v3 client", zap.Strings("endpoints", endpoints))
@@ -206,6 +245,28 @@ func (s *Server) startEtcd(ctx context.Context) error {
 	return nil
 }
 
+func waitLearnerPromoted(ctx context.Context, etcd *embed.Etcd) error {
+	if !etcd.Server.IsLearner() {
+		return nil
+	}
+	log.Info("wait for the learner to be promoted", zap.Stringer("id", etcd.Server.ID()))
+	timeout := time.NewTimer(learnerPromoteTimeout)
+	defer timeout.Stop()
+	ticker := time.NewTicker(learnerCheckInterval)
+	defer ticker.Stop()
+	for etcd.Server.IsLearner() {
+		select {
+		case <-ticker.C:
+		case <-timeout.C:
+			return errors.Errorf("the learner is not promoted in %v, check that the PD leader supports learners and the learner can catch up", learnerPromoteTimeout)
+		case <-ctx.Done():
+			return errors.Errorf("canceled when waiting the learner to be promoted")
+		}
+	}
+	log.Info("the learner is promoted", zap.Stringer("id", etcd.Server.ID()))
+	return nil
+}
+
 func (s *Server) startServer() error {
 	var err error
 	if err = s.initClusterID(); err != nil {
@@ -218,15 +279,23 @@ func (s *Server) startServer() error {
 
 	s.rootPath = path.Join(pdRootPath, strconv.FormatUint(s.clusterID, 10))
 	s.member, s.memberValue = s.memberInfo()
+	if err = s.saveMemberLabels(); err != nil {
+		return err
+	}
 
 	s.idAlloc = &idAllocator{s: s}
 	kvBase := newEtcdKVBase(s)
-	path := filepath.Join(s.cfg.DataDir, "region-meta")
-	regionKV, err := core.NewRegionKV(path)
+	engine := s.cfg.RegionStorageEngine
+	keys, err := encryption.NewKeyManager(&s.cfg.Security.Encryption)
+	if err != nil {
+		return err
+	}
+	regionKV, err := core.NewRegionKV(engine, core.RegionStoragePath(s.cfg.DataDir, engine), keys)
 	if err != nil {
 		return err
 	}
 	s.kv = core.NewKV(kvBase).SetRegionKV(regionKV)
+	s.metricsHistory = core.NewMetricsHistory(regionKV)
 	s.cluster = newRaftCluster(s, s.clusterID)
 	s.hbStreams = newHeartbeatStreams(s.clusterID, s.cluster)
 	if s.classifier, err = namespace.CreateClassifier(s.cfg.NamespaceClassifier, s.kv, s.idAlloc); err != nil {
@@ -279,6 +348,11 @@ func (s *Server) Close() {
 	if err := s.kv.Close(); err != nil {
 		log.Error("close kv meet error", zap.Error(err))
 	}
+	if s.auditFile != nil {
+		if err := s.auditFile.close(); err != nil {
+			log.Error("close audit file meet error", zap.Error(err))
+		}
+	}
 
 	log.Info("close server")
 }
@@ -319,10 +393,11 @@ func (s *Server) Context() context.Context {
 
 func (s *Server) startServerLoop() {
 	s.serverLoopCtx, s.serverLoopCancel = context.WithCancel(context.Background())
-	s.serverLoopWg.Add(3)
+	s.serverLoopWg.Add(4)
 	go s.leaderLoop()
 	go s.etcdLeaderLoop()
 	go s.serverMetricsLoop()
+	go s.learnerLoop()
 }
 
 func (s *Server) stopServerLoop() {
@@ -340,6 +415,7 @@ func (s *Server) serverMetricsLoop() {
 		select {
 		case <-time.After(serverMetricsInterval):
 			s.collectEtcdStateMetrics()
+			tlsutil.CheckAll()
 		case <-ctx.Done():
 			log.Info("server is closed, exit metrics loop")
 			return
@@ -453,6 +529,22 @@ func (s *Server) GetMemberInfo() *pdpb.Member {
 	return proto.Clone(s.member).(*pdpb.Member)
 }
 
+// GetCachedMembers returns the members of the cluster. The members are loaded
+// from etcd at most once in membersCacheTTL, for the callers which check them
+// on every request.
+func (s *Server) GetCachedMembers() ([]*pdpb.Member, error) {
+	s.membersLock.Lock()
+	defer s.membersLock.Unlock()
+	if s.members == nil || time.Since(s.membersTime) >= membersCacheTTL {
+		members, err := GetMembers(s.GetClient())
+		if err != nil {
+			return nil, err
+		}
+		s.members, s.membersTime = members, time.Now()
+	}
+	return s.members, nil
+}
+
 // GetHandler returns the handler for API.
 func (s *Server) GetHandler() *Handler {
 	return s.handler
@@ -531,7 +623,7 @@ func (s *Server) GetScheduleConfig() *ScheduleConfig {
 // SetScheduleConfig sets the balance config information.
 func (s *Server) SetScheduleConfig(cfg ScheduleConfig) error {
 	if err := cfg.validate(); err != nil {
-		return err
+		return errcode.NewInvalidInputErr(err)
 	}
 	old := s.scheduleOpt.load()
 	s.scheduleOpt.store(&cfg)
@@ -552,7 +644,7 @@ func (s *Server) GetReplicationConfig() *ReplicationConfig {
 // SetReplicationConfig sets the replication config.
 func (s *Server) SetReplicationConfig(cfg ReplicationConfig) error {
 	if err := cfg.validate(); err != nil {
-		return err
+		return errcode.NewInvalidInputErr(err)
 	}
 	old := s.scheduleOpt.rep.load()
 	s.scheduleOpt.rep.store(&cfg)
@@ -574,6 +666,35 @@ func (s *Server) SetPDServerConfig(cfg PDServerConfig) error {
 	return nil
 }
 
+// SetScheduleOptionConfig validates and sets the schedule, replication and
+// server configs together, so that none of them is changed if any one is
+// invalid. They are persisted once after all are updated.
+func (s *Server) SetScheduleOptionConfig(schedule ScheduleConfig, replication ReplicationConfig, pdServer PDServerConfig) error {
+	if err := schedule.validate(); err != nil {
+		return errcode.NewInvalidInputErr(err)
+	}
+	if err := replication.validate(); err != nil {
+		return errcode.NewInvalidInputErr(err)
+	}
+	oldSchedule := s.scheduleOpt.load()
+	oldReplication := s.scheduleOpt.rep.load()
+	oldPDServer := s.scheduleOpt.loadPDServerConfig()
+	s.scheduleOpt.store(&schedule)
+	s.scheduleOpt.rep.store(&replication)
+	s.scheduleOpt.pdServerConfig.Store(&pdServer)
+	if err := s.scheduleOpt.persist(s.kv); err != nil {
+		s.scheduleOpt.store(oldSchedule)
+		s.scheduleOpt.rep.store(oldReplication)
+		s.scheduleOpt.pdServerConfig.Store(oldPDServer)
+		return err
+	}
+	log.Info("schedule option config is updated",
+		zap.Reflect("new-schedule", schedule), zap.Reflect("old-schedule", oldSchedule),
+		zap.Reflect("new-replication", replication), zap.Reflect("old-replication", oldReplication),
+		zap.Reflect("new-pd-server", pdServer), zap.Reflect("old-pd-server", oldPDServer))
+	return nil
+}
+
 // GetNamespaceConfig get the namespace config.
 func (s *Server) GetNamespaceConfig(name string) *NamespaceConfig {
 	if _, ok := s.scheduleOpt.ns[name]; !ok {
@@ -642,6 +763,17 @@ func (s *Server) SetLabelProperty(typ, labelKey, labelValue string) error {
 	return nil
 }
 
+// SetLabelPropertyConfig replaces the whole label property config.
+func (s *Server) SetLabelPropertyConfig(cfg LabelPropertyConfig) error {
+	old := s.scheduleOpt.loadLabelPropertyConfig()
+	s.scheduleOpt.labelProperty.Store(cfg.clone())
+	if err := s.scheduleOpt.persist(s.kv); err != nil {
+		return err
+	}
+	log.Info("label property config is updated", zap.Reflect("new", cfg), zap.Reflect("old", old))
+	return nil
+}
+
 // DeleteLabelProperty deletes a label property config.
 func (s *Server) DeleteLabelProperty(typ, labelKey, labelValue string) error {
 	s.scheduleOpt.DeleteLabelProperty(typ, labelKey, labelValue)
@@ -678,6 +810,12 @@ func (s *Server) GetClusterVersion() semver.Version {
 	return s.scheduleOpt.loadClusterVersion()
 }
 
+// GetTLSConfig returns the TLS config to connect to other members, it is nil
+// if TLS is not enabled.
+func (s *Server) GetTLSConfig() (*tls.Config, error) {
+	return s.cfg.Security.ToTLSConfig()
+}
+
 // GetSecurityConfig get the security config.
 func (s *Server) GetSecurityConfig() *SecurityConfig {
 	return &s.cfg.Security
@@ -701,6 +839,25 @@ func (s *Server) GetRaftCluster() *RaftCluster {
 	return s.cluster
 }
 
+// GetFollowerRegions returns the regions synced from the leader and their
+// lag, if this server is a follower which may serve region reads by itself.
+// It requires the region storage, and the lag should be less than
+// FollowerRegionMaxLag. Otherwise it returns nil.
+func (s *Server) GetFollowerRegions() (*syncer.SyncedRegions, time.Duration) {
+	if s.isClosed() || s.cluster == nil || s.IsLeader() {
+		return nil, 0
+	}
+	cfg := s.scheduleOpt.loadPDServerConfig()
+	if !cfg.UseRegionStorage {
+		return nil, 0
+	}
+	regions, lag := s.cluster.GetRegionSyncer().GetSyncedRegions()
+	if regions == nil || lag >= cfg.FollowerRegionMaxLag.Duration {
+		return nil, 0
+	}
+	return regions, lag
+}
+
 // GetCluster gets cluster.
 func (s *Server) GetCluster() *metapb.Cluster {
 	return &metapb.Cluster{
@@ -783,6 +940,18 @@ func (s *Server) SetLogLevel(level string) {
 	log.Warn("log level changed", zap.String("level", log.GetLevel().String()))
 }
 
+// SetRedactConfig sets whether to replace the keys of regions with "?" in logs
+// and in the API output.
+func (s *Server) SetRedactConfig(redactInfoLog, redactRegionAPI bool) {
+	s.cfg.Security.RedactInfoLog = redactInfoLog
+	s.cfg.Security.RedactRegionAPI = redactRegionAPI
+	core.SetRedactInfoLog(redactInfoLog)
+	core.SetRedactRegionAPI(redactRegionAPI)
+	log.Warn("redact config changed",
+		zap.Bool("redact-info-log", redactInfoLog),
+		zap.Bool("redact-region-api", redactRegionAPI))
+}
+
 var healthURL = "/pd/ping"
 
 // CheckHealth checks if members are healthy.
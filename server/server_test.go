@@ -156,3 +156,25 @@ func (s *testServerSuite) TestCheckClusterID(c *C) {
 	err = svr.Run(context.TODO())
 	c.Assert(err, NotNil)
 }
+
+func (s *testServerSuite) TestGetCachedMembers(c *C) {
+	svr, cleanup := mustRunTestServer(c)
+	defer cleanup()
+
+	members, err := svr.GetCachedMembers()
+	c.Assert(err, IsNil)
+	c.Assert(members, HasLen, 1)
+	c.Assert(members[0].GetName(), Equals, svr.Name())
+
+	// The members are reused until they expire.
+	cached, err := svr.GetCachedMembers()
+	c.Assert(err, IsNil)
+	c.Assert(&cached[0], Equals, &members[0])
+	svr.membersLock.Lock()
+	svr.membersTime = svr.membersTime.Add(-membersCacheTTL)
+	svr.membersLock.Unlock()
+	reloaded, err := svr.GetCachedMembers()
+	c.Assert(err, IsNil)
+	c.Assert(&reloaded[0], Not(Equals), &members[0])
+	c.Assert(reloaded, DeepEquals, members)
+}
@@ -0,0 +1,135 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"sort"
+
+	"github.com/pingcap/pd/pkg/typeutil"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/schedule"
+	"github.com/pingcap/pd/server/schedulers"
+)
+
+// ResourceScore explains the leader or region score of a store.
+type ResourceScore struct {
+	Count  int     `json:"count"`
+	Size   int64   `json:"size"`
+	Weight float64 `json:"weight"`
+	Score  float64 `json:"score"`
+	// InfluenceSize is the size the running operators will add to the store,
+	// negative if they remove from it.
+	InfluenceSize int64 `json:"influence_size"`
+	// InfluenceCount is the count the running operators will add to the store.
+	InfluenceCount int64 `json:"influence_count"`
+	// ScoreWithInfluence is the score after the running operators finish,
+	// which is the one the balance schedulers compare.
+	ScoreWithInfluence float64 `json:"score_with_influence"`
+	// SourceFilters are the filters which exclude the store as a source of
+	// the balance scheduler.
+	SourceFilters []schedule.FilterReason `json:"source_filters"`
+	// TargetFilters are the filters which exclude the store as a target of
+	// the balance scheduler.
+	TargetFilters []schedule.FilterReason `json:"target_filters"`
+}
+
+// StoreScore explains how the balance schedulers see a store.
+type StoreScore struct {
+	StoreID        uint64            `json:"store_id"`
+	Address        string            `json:"address"`
+	StateName      string            `json:"state_name"`
+	Capacity       typeutil.ByteSize `json:"capacity"`
+	Available      typeutil.ByteSize `json:"available"`
+	HighSpaceRatio float64           `json:"high_space_ratio"`
+	LowSpaceRatio  float64           `json:"low_space_ratio"`
+	// SpaceStage is high-space, transition or low-space, which decides how
+	// the region score is calculated.
+	SpaceStage string         `json:"space_stage"`
+	Leader     *ResourceScore `json:"leader"`
+	Region     *ResourceScore `json:"region"`
+}
+
+func newResourceScore(store *core.StoreInfo, kind core.ResourceKind, highSpaceRatio, lowSpaceRatio float64, influence *schedule.StoreInfluence) *ResourceScore {
+	score := &ResourceScore{
+		Count:              int(store.ResourceCount(kind)),
+		Size:               store.ResourceSize(kind),
+		Weight:             store.ResourceWeight(kind),
+		Score:              store.ResourceScore(kind, highSpaceRatio, lowSpaceRatio, 0),
+		InfluenceSize:      influence.ResourceSize(kind),
+		ScoreWithInfluence: store.ResourceScore(kind, highSpaceRatio, lowSpaceRatio, influence.ResourceSize(kind)),
+	}
+	if kind == core.LeaderKind {
+		score.InfluenceCount = influence.LeaderCount
+	} else {
+		score.InfluenceCount = influence.RegionCount
+	}
+	return score
+}
+
+// getStoreScore explains the leader and region score of the store with the
+// influence of the running operators.
+func (c *clusterInfo) getStoreScore(store *core.StoreInfo, opInfluence schedule.OpInfluence) *StoreScore {
+	highSpaceRatio := c.GetStoreHighSpaceRatio(store.GetID())
+	lowSpaceRatio := c.GetStoreLowSpaceRatio(store.GetID())
+	influence := opInfluence.GetStoreInfluence(store.GetID())
+	score := &StoreScore{
+		StoreID:        store.GetID(),
+		Address:        store.GetAddress(),
+		StateName:      store.GetState().String(),
+		Capacity:       typeutil.ByteSize(store.GetCapacity()),
+		Available:      typeutil.ByteSize(store.GetAvailable()),
+		HighSpaceRatio: highSpaceRatio,
+		LowSpaceRatio:  lowSpaceRatio,
+		SpaceStage:     store.SpaceStage(highSpaceRatio, lowSpaceRatio),
+		Leader:         newResourceScore(store, core.LeaderKind, highSpaceRatio, lowSpaceRatio, influence),
+		Region:         newResourceScore(store, core.RegionKind, highSpaceRatio, lowSpaceRatio, influence),
+	}
+	// The filters depending on the region or the state of the scheduler, like
+	// the taint cache, are not included.
+	score.Leader.SourceFilters = schedule.ExplainSource(c, store, schedulers.BalanceLeaderFilters())
+	score.Leader.TargetFilters = schedule.ExplainTarget(c, store, schedulers.BalanceLeaderFilters())
+	score.Region.SourceFilters = schedule.ExplainSource(c, store, schedulers.BalanceRegionSourceFilters())
+	score.Region.TargetFilters = schedule.ExplainTarget(c, store, schedulers.BalanceRegionTargetFilters(c))
+	return score
+}
+
+// GetStoreScores explains the leader and region score of all stores, and why
+// the balance schedulers can not use them as the source or the target.
+func (h *Handler) GetStoreScores() ([]*StoreScore, error) {
+	c, err := h.getCoordinator()
+	if err != nil {
+		return nil, err
+	}
+	opInfluence := c.opController.GetOpInfluence(c.cluster)
+	stores := c.cluster.GetStores()
+	scores := make([]*StoreScore, 0, len(stores))
+	for _, store := range stores {
+		scores = append(scores, c.cluster.getStoreScore(store, opInfluence))
+	}
+	sort.Slice(scores, func(i, j int) bool { return scores[i].StoreID < scores[j].StoreID })
+	return scores, nil
+}
+
+// GetStoreScore explains the leader and region score of the store.
+func (h *Handler) GetStoreScore(storeID uint64) (*StoreScore, error) {
+	c, err := h.getCoordinator()
+	if err != nil {
+		return nil, err
+	}
+	store := c.cluster.GetStore(storeID)
+	if store == nil {
+		return nil, core.NewStoreNotFoundErr(storeID)
+	}
+	return c.cluster.getStoreScore(store, c.opController.GetOpInfluence(c.cluster)), nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/schedule"
+)
+
+var _ = Suite(&testStoreScoreSuite{})
+
+type testStoreScoreSuite struct{}
+
+func (s *testStoreScoreSuite) TestStoreScore(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	tc := newTestClusterInfo(opt)
+
+	for id, available := range map[uint64]uint64{1: 800, 2: 300, 3: 100} {
+		store := core.NewStoreInfo(&metapb.Store{Id: id, State: metapb.StoreState_Up},
+			core.SetStoreStats(&pdpb.StoreStats{Capacity: 1000 << 20, UsedSize: (1000 - available) << 20, Available: available << 20}),
+			core.SetLastHeartbeatTS(time.Now()),
+			core.SetRegionCount(10),
+			core.SetRegionSize(100),
+			core.SetLeaderSize(50),
+		)
+		c.Assert(tc.putStore(store), IsNil)
+	}
+	meta := newTestRegionMeta(1)
+	meta.Peers = []*metapb.Peer{{Id: 10, StoreId: 1}}
+	c.Assert(tc.putRegion(core.NewRegionInfo(meta, meta.Peers[0], core.SetApproximateSize(20))), IsNil)
+	op := schedule.NewOperator("test", 1, meta.GetRegionEpoch(), schedule.OpRegion, schedule.AddPeer{ToStore: 2, PeerID: 11})
+	opInfluence := schedule.NewOpInfluence([]*schedule.Operator{op}, tc)
+
+	score := tc.getStoreScore(tc.GetStore(1), opInfluence)
+	c.Assert(score.SpaceStage, Equals, core.HighSpaceStage)
+	c.Assert(score.Leader.Size, Equals, int64(50))
+	c.Assert(score.Leader.Score, Equals, float64(50))
+	c.Assert(score.Leader.SourceFilters, HasLen, 0)
+	c.Assert(score.Region.Count, Equals, 10)
+	c.Assert(score.Region.Score, Equals, float64(100))
+	c.Assert(score.Region.InfluenceSize, Equals, int64(0))
+	c.Assert(score.Region.ScoreWithInfluence, Equals, float64(100))
+	c.Assert(score.Region.TargetFilters, HasLen, 0)
+
+	// The running operator adds a peer to store 2.
+	score = tc.getStoreScore(tc.GetStore(2), opInfluence)
+	c.Assert(score.SpaceStage, Equals, core.TransitionSpaceStage)
+	c.Assert(score.Region.InfluenceSize, Equals, int64(20))
+	c.Assert(score.Region.InfluenceCount, Equals, int64(1))
+	c.Assert(score.Region.ScoreWithInfluence > score.Region.Score, IsTrue)
+
+	// The store in the low space stage is kept away by its score rather than
+	// by the filters of balance-region.
+	score = tc.getStoreScore(tc.GetStore(3), opInfluence)
+	c.Assert(score.SpaceStage, Equals, core.LowSpaceStage)
+	c.Assert(score.Region.SourceFilters, HasLen, 0)
+	c.Assert(score.Region.TargetFilters, HasLen, 0)
+
+	// Busy stores are excluded from both sides.
+	c.Assert(tc.putStore(tc.GetStore(3).Clone(core.SetStoreStats(&pdpb.StoreStats{Capacity: 1000 << 20, Available: 100 << 20, IsBusy: true}))), IsNil)
+	score = tc.getStoreScore(tc.GetStore(3), opInfluence)
+	c.Assert(score.Region.SourceFilters, DeepEquals, []schedule.FilterReason{
+		{Filter: "store-state-filter", Reason: "the store is busy"},
+	})
+	c.Assert(score.Region.TargetFilters, DeepEquals, []schedule.FilterReason{
+		{Filter: "health-filter", Reason: "the store is busy"},
+	})
+	c.Assert(score.Leader.SourceFilters, HasLen, 0)
+	c.Assert(score.Leader.TargetFilters, DeepEquals, score.Region.SourceFilters)
+}
@@ -80,7 +80,10 @@ func (s *storeStatistics) Observe(store *core.StoreInfo) {
 		s.resetStoreStatistics(storeAddress)
 		return
 	}
-	if store.IsLowSpace(s.opt.GetLowSpaceRatio()) {
+	config := store.GetConfig()
+	highSpaceRatio := config.GetHighSpaceRatio(s.opt.GetHighSpaceRatio())
+	lowSpaceRatio := config.GetLowSpaceRatio(s.opt.GetLowSpaceRatio())
+	if store.IsLowSpace(lowSpaceRatio) {
 		s.LowSpace++
 	}
 
@@ -90,7 +93,7 @@ func (s *storeStatistics) Observe(store *core.StoreInfo) {
 	s.RegionCount += store.GetRegionCount()
 	s.LeaderCount += store.GetLeaderCount()
 
-	storeStatusGauge.WithLabelValues(s.namespace, storeAddress, "region_score").Set(store.RegionScore(s.opt.GetHighSpaceRatio(), s.opt.GetLowSpaceRatio(), 0))
+	storeStatusGauge.WithLabelValues(s.namespace, storeAddress, "region_score").Set(store.RegionScore(highSpaceRatio, lowSpaceRatio, 0))
 	storeStatusGauge.WithLabelValues(s.namespace, storeAddress, "leader_score").Set(store.LeaderScore(0))
 	storeStatusGauge.WithLabelValues(s.namespace, storeAddress, "region_size").Set(float64(store.GetRegionSize()))
 	storeStatusGauge.WithLabelValues(s.namespace, storeAddress, "region_count").Set(float64(store.GetRegionCount()))
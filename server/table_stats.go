@@ -0,0 +1,170 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/pingcap/errcode"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/table"
+	"github.com/pkg/errors"
+)
+
+// The ways to group the table stats.
+const (
+	TableStatsGroupByTable = "table"
+	TableStatsGroupByIndex = "index"
+)
+
+// tableStatsLess are the orders of the table stats by the sort keys, from
+// the largest.
+var tableStatsLess = map[string]func(a, b *TableStats) bool{
+	"size":          func(a, b *TableStats) bool { return a.StorageSize > b.StorageSize },
+	"keys":          func(a, b *TableStats) bool { return a.StorageKeys > b.StorageKeys },
+	"region_count":  func(a, b *TableStats) bool { return a.RegionCount > b.RegionCount },
+	"read_bytes":    func(a, b *TableStats) bool { return a.ReadBytes > b.ReadBytes },
+	"written_bytes": func(a, b *TableStats) bool { return a.WrittenBytes > b.WrittenBytes },
+}
+
+// TableStatsOption is the options to get the table stats.
+type TableStatsOption struct {
+	// GroupBy is TableStatsGroupByTable or TableStatsGroupByIndex.
+	GroupBy string
+	// SortBy is size, keys, region_count, read_bytes or written_bytes. The
+	// groups are sorted from the largest.
+	SortBy string
+	Offset int
+	Limit  int
+}
+
+// TableStats is the statistics of the regions of a table, or of an index if
+// the stats are grouped by index.
+type TableStats struct {
+	// TableID is 0 for the regions which do not start with a table key, like
+	// the ones of the meta keys.
+	TableID int64 `json:"table_id"`
+	// IndexID is 0 for the row data, or if the stats are grouped by table.
+	IndexID          int64          `json:"index_id,omitempty"`
+	RegionCount      int            `json:"region_count"`
+	StorageSize      int64          `json:"storage_size"`
+	StorageKeys      int64          `json:"storage_keys"`
+	ReadBytes        uint64         `json:"read_bytes"`
+	WrittenBytes     uint64         `json:"written_bytes"`
+	StoreLeaderCount map[uint64]int `json:"store_leader_count"`
+}
+
+// TableStatsPage is a page of the sorted table stats.
+type TableStatsPage struct {
+	// Total is the count of the groups before paging.
+	Total  int           `json:"total"`
+	Tables []*TableStats `json:"tables"`
+}
+
+type tableStatsKey struct {
+	tableID int64
+	indexID int64
+}
+
+// getTableStats groups the stats of the regions in [startKey, endKey) by the
+// table, and the index if groupByIndex, of their start keys. A region across
+// several tables is counted for the first one.
+func (c *clusterInfo) getTableStats(startKey, endKey []byte, groupByIndex bool) []*TableStats {
+	c.RLock()
+	defer c.RUnlock()
+	groups := make(map[tableStatsKey]*TableStats)
+	c.core.Regions.ScanRangeWithIterator(startKey, func(meta *metapb.Region) bool {
+		if len(endKey) > 0 && (len(meta.EndKey) == 0 || bytes.Compare(meta.EndKey, endKey) > 0) {
+			return false
+		}
+		region := c.core.Regions.GetRegion(meta.GetId())
+		if region == nil {
+			return true
+		}
+		key := table.Key(region.GetStartKey())
+		k := tableStatsKey{tableID: key.TableID()}
+		if groupByIndex {
+			k.indexID = key.IndexID()
+		}
+		stats, ok := groups[k]
+		if !ok {
+			stats = &TableStats{TableID: k.tableID, IndexID: k.indexID, StoreLeaderCount: make(map[uint64]int)}
+			groups[k] = stats
+		}
+		stats.RegionCount++
+		stats.StorageSize += region.GetApproximateSize()
+		stats.StorageKeys += region.GetApproximateKeys()
+		stats.ReadBytes += region.GetBytesRead()
+		stats.WrittenBytes += region.GetBytesWritten()
+		if leader := region.GetLeader(); leader != nil {
+			stats.StoreLeaderCount[leader.GetStoreId()]++
+		}
+		return true
+	})
+	tables := make([]*TableStats, 0, len(groups))
+	for _, stats := range groups {
+		tables = append(tables, stats)
+	}
+	return tables
+}
+
+// pageTableStats sorts the table stats and returns the page of the option.
+func pageTableStats(tables []*TableStats, opt *TableStatsOption) (*TableStatsPage, error) {
+	less, ok := tableStatsLess[opt.SortBy]
+	if !ok {
+		return nil, errcode.NewInvalidInputErr(errors.Errorf("unknown sort by %s", opt.SortBy))
+	}
+	if opt.Offset < 0 || opt.Limit <= 0 {
+		return nil, errcode.NewInvalidInputErr(errors.New("offset should not be negative and limit should be positive"))
+	}
+	sort.Slice(tables, func(i, j int) bool {
+		if less(tables[i], tables[j]) {
+			return true
+		}
+		if less(tables[j], tables[i]) {
+			return false
+		}
+		if tables[i].TableID != tables[j].TableID {
+			return tables[i].TableID < tables[j].TableID
+		}
+		return tables[i].IndexID < tables[j].IndexID
+	})
+	page := &TableStatsPage{Total: len(tables), Tables: []*TableStats{}}
+	if opt.Offset < len(tables) {
+		end := opt.Offset + opt.Limit
+		if end > len(tables) {
+			end = len(tables)
+		}
+		page.Tables = tables[opt.Offset:end]
+	}
+	return page, nil
+}
+
+// GetTableStats returns the stats of the regions in [startKey, endKey)
+// grouped by table or index, sorted and paged by the option. An empty endKey
+// means the end of the keyspace.
+func (h *Handler) GetTableStats(startKey, endKey []byte, opt *TableStatsOption) (*TableStatsPage, error) {
+	c := h.s.GetRaftCluster()
+	if c == nil {
+		return nil, ErrNotBootstrapped
+	}
+	if opt.GroupBy != TableStatsGroupByTable && opt.GroupBy != TableStatsGroupByIndex {
+		return nil, errcode.NewInvalidInputErr(errors.Errorf("unknown group by %s", opt.GroupBy))
+	}
+	c.RLock()
+	defer c.RUnlock()
+	tables := c.cachedCluster.getTableStats(startKey, endKey, opt.GroupBy == TableStatsGroupByIndex)
+	return pageTableStats(tables, opt)
+}
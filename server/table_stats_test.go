@@ -0,0 +1,98 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/table"
+)
+
+var _ = Suite(&testTableStatsSuite{})
+
+type testTableStatsSuite struct{}
+
+func (s *testTableStatsSuite) TestTableStats(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	tc := newTestClusterInfo(opt)
+
+	// Regions:  [, t1) [t1, t1_i1) [t1_i1, t1_i2) [t1_i2, t2) [t2, )
+	// Leaders:   1      1          2              2           3
+	keys := [][]byte{
+		nil,
+		table.EncodeBytes(table.GenerateTableKey(1)),
+		table.EncodeBytes(table.GenerateIndexKey(1, 1)),
+		table.EncodeBytes(table.GenerateIndexKey(1, 2)),
+		table.EncodeBytes(table.GenerateTableKey(2)),
+		nil,
+	}
+	for i, leader := range []uint64{1, 1, 2, 2, 3} {
+		id := uint64(i + 1)
+		meta := &metapb.Region{
+			Id:          id,
+			StartKey:    keys[i],
+			EndKey:      keys[i+1],
+			Peers:       []*metapb.Peer{{Id: id * 10, StoreId: leader}},
+			RegionEpoch: &metapb.RegionEpoch{Version: 1, ConfVer: 1},
+		}
+		region := core.NewRegionInfo(meta, meta.Peers[0],
+			core.SetApproximateSize(int64(id)*10),
+			core.SetApproximateKeys(int64(id)*100),
+			core.SetReadBytes(id*1000),
+			core.SetWrittenBytes(6000-id*1000),
+		)
+		c.Assert(tc.putRegion(region), IsNil)
+	}
+
+	tables := tc.getTableStats(nil, nil, false)
+	page, err := pageTableStats(tables, &TableStatsOption{SortBy: "size", Limit: 10})
+	c.Assert(err, IsNil)
+	c.Assert(page.Total, Equals, 3)
+	c.Assert(page.Tables, DeepEquals, []*TableStats{
+		{TableID: 1, RegionCount: 3, StorageSize: 90, StorageKeys: 900, ReadBytes: 9000, WrittenBytes: 9000, StoreLeaderCount: map[uint64]int{1: 1, 2: 2}},
+		{TableID: 2, RegionCount: 1, StorageSize: 50, StorageKeys: 500, ReadBytes: 5000, WrittenBytes: 1000, StoreLeaderCount: map[uint64]int{3: 1}},
+		{TableID: 0, RegionCount: 1, StorageSize: 10, StorageKeys: 100, ReadBytes: 1000, WrittenBytes: 5000, StoreLeaderCount: map[uint64]int{1: 1}},
+	})
+
+	// Group by index, from the one with most written bytes.
+	tables = tc.getTableStats(nil, nil, true)
+	page, err = pageTableStats(tables, &TableStatsOption{SortBy: "written_bytes", Offset: 1, Limit: 2})
+	c.Assert(err, IsNil)
+	c.Assert(page.Total, Equals, 5)
+	c.Assert(page.Tables, HasLen, 2)
+	c.Assert(page.Tables[0].TableID, Equals, int64(1))
+	c.Assert(page.Tables[0].IndexID, Equals, int64(0))
+	c.Assert(page.Tables[1].TableID, Equals, int64(1))
+	c.Assert(page.Tables[1].IndexID, Equals, int64(1))
+
+	// Regions in [t1_i1, t2).
+	tables = tc.getTableStats(keys[2], keys[4], true)
+	page, err = pageTableStats(tables, &TableStatsOption{SortBy: "region_count", Limit: 10})
+	c.Assert(err, IsNil)
+	c.Assert(page.Total, Equals, 2)
+	c.Assert(page.Tables[0].IndexID, Equals, int64(1))
+	c.Assert(page.Tables[1].IndexID, Equals, int64(2))
+
+	page, err = pageTableStats(tables, &TableStatsOption{SortBy: "size", Offset: 5, Limit: 10})
+	c.Assert(err, IsNil)
+	c.Assert(page.Total, Equals, 2)
+	c.Assert(page.Tables, HasLen, 0)
+
+	_, err = pageTableStats(tables, &TableStatsOption{SortBy: "unknown", Limit: 10})
+	c.Assert(err, NotNil)
+	_, err = pageTableStats(tables, &TableStatsOption{SortBy: "size"})
+	c.Assert(err, NotNil)
+}
@@ -15,9 +15,11 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/binary"
 	"fmt"
 	"math/rand"
+	"net"
 	"net/http"
 	"regexp"
 	"time"
@@ -271,10 +273,23 @@ func InitHTTPClient(svr *Server) error {
 		return err
 	}
 
-	DialClient = &http.Client{Transport: &http.Transport{
-		TLSClientConfig:   tlsConfig,
-		DisableKeepAlives: true,
-	}}
+	transport := &http.Transport{DisableKeepAlives: true}
+	if tlsConfig != nil {
+		// The TLS config is built for every connection, so that the reloaded
+		// certificate and CA are used.
+		security := svr.GetSecurityConfig()
+		transport.DialTLS = func(network, addr string) (net.Conn, error) {
+			cfg, err := security.ToTLSConfig()
+			if err != nil {
+				return nil, err
+			}
+			if cfg.ServerName, _, err = net.SplitHostPort(addr); err != nil {
+				return nil, errors.WithStack(err)
+			}
+			return tls.Dial(network, addr, cfg)
+		}
+	}
+	DialClient = &http.Client{Transport: transport}
 	return nil
 }
 
@@ -24,6 +24,8 @@ var (
 	tablePrefix  = []byte{'t'}
 	metaPrefix   = []byte{'m'}
 	recordPrefix = []byte{'r'}
+	// indexPrefixSep separates the table ID and the index ID in index keys.
+	indexPrefixSep = []byte("_i")
 )
 
 const (
@@ -53,6 +55,27 @@ func (k Key) TableID() int64 {
 	return tableID
 }
 
+// IndexID returns the index ID of the key, if the key is not an index key,
+// returns 0.
+func (k Key) IndexID() int64 {
+	_, key, err := DecodeBytes(k)
+	if err != nil {
+		return 0
+	}
+	if !bytes.HasPrefix(key, tablePrefix) {
+		return 0
+	}
+	key, _, err = DecodeInt(key[len(tablePrefix):])
+	if err != nil || !bytes.HasPrefix(key, indexPrefixSep) {
+		return 0
+	}
+	_, indexID, err := DecodeInt(key[len(indexPrefixSep):])
+	if err != nil {
+		return 0
+	}
+	return indexID
+}
+
 // MetaOrTable checks if the key is a meta key or table key.
 // If the key is a meta key, it returns true and 0.
 // If the key is a table key, it returns false and table ID.
@@ -194,3 +217,13 @@ func GenerateRowKey(tableID, rowID int64) []byte {
 	buf = EncodeInt(buf, rowID)
 	return buf
 }
+
+// GenerateIndexKey generates an index split key.
+func GenerateIndexKey(tableID, indexID int64) []byte {
+	buf := make([]byte, 0, len(tablePrefix)+len(indexPrefixSep)+8*2)
+	buf = append(buf, tablePrefix...)
+	buf = EncodeInt(buf, tableID)
+	buf = append(buf, indexPrefixSep...)
+	buf = EncodeInt(buf, indexID)
+	return buf
+}
@@ -52,3 +52,26 @@ func (s *testCodecSuite) TestTableID(c *C) {
 	key = EncodeBytes([]byte("t\x80\x00\x00\x00\x00\x00\xff"))
 	c.Assert(key.TableID(), Equals, int64(0))
 }
+
+func (s *testCodecSuite) TestIndexID(c *C) {
+	key := EncodeBytes(GenerateIndexKey(0xff, 2))
+	c.Assert(key.TableID(), Equals, int64(0xff))
+	c.Assert(key.IndexID(), Equals, int64(2))
+
+	key = EncodeBytes(append(GenerateIndexKey(0xff, 2), 0x01, 0x02))
+	c.Assert(key.IndexID(), Equals, int64(2))
+
+	// Table prefixes and row keys are not index keys.
+	key = EncodeBytes(GenerateTableKey(0xff))
+	c.Assert(key.IndexID(), Equals, int64(0))
+	key = EncodeBytes([]byte("t\x80\x00\x00\x00\x00\x00\x00\xff_r\x01\x02"))
+	c.Assert(key.IndexID(), Equals, int64(0))
+
+	// The index ID is truncated.
+	key = EncodeBytes([]byte("t\x80\x00\x00\x00\x00\x00\x00\xff_i\x01\x02"))
+	c.Assert(key.IndexID(), Equals, int64(0))
+
+	// Keys which are not encoded are not index keys.
+	key = GenerateIndexKey(0xff, 2)
+	c.Assert(key.IndexID(), Equals, int64(0))
+}
@@ -23,6 +23,7 @@ import (
 	"time"
 
 	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
 	pd "github.com/pingcap/pd/client"
 	"github.com/pingcap/pd/pkg/testutil"
 	"github.com/pingcap/pd/server"
@@ -172,6 +173,84 @@ func (s *serverTestSuite) TestLeaderTransfer(c *C) {
 	wg.Wait()
 }
 
+func (s *serverTestSuite) TestWatchStores(c *C) {
+	c.Parallel()
+
+	cluster, err := tests.NewTestCluster(1)
+	c.Assert(err, IsNil)
+	defer cluster.Destroy()
+
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	leader := cluster.GetServer(cluster.WaitLeader())
+	c.Assert(leader.BootstrapCluster(), IsNil)
+
+	cli, err := pd.NewClient([]string{leader.GetConfig().AdvertiseClientUrls}, pd.SecurityOption{})
+	c.Assert(err, IsNil)
+	defer cli.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := cli.WatchStores(ctx)
+	c.Assert(err, IsNil)
+
+	err = leader.GetRaftCluster().SetStoreState(1, metapb.StoreState_Offline)
+	c.Assert(err, IsNil)
+	select {
+	case e := <-ch:
+		c.Assert(e.Type, Equals, "store_put")
+		c.Assert(e.Store.GetId(), Equals, uint64(1))
+		c.Assert(e.Store.GetState(), Equals, metapb.StoreState_Offline)
+	case <-time.After(5 * time.Second):
+		c.Fatal("no store event")
+	}
+
+	cancel()
+	for range ch {
+	}
+}
+
+func (s *serverTestSuite) TestUpdateServiceGCSafePoint(c *C) {
+	c.Parallel()
+
+	cluster, err := tests.NewTestCluster(1)
+	c.Assert(err, IsNil)
+	defer cluster.Destroy()
+
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	leader := cluster.GetServer(cluster.WaitLeader())
+	c.Assert(leader.BootstrapCluster(), IsNil)
+
+	cli, err := pd.NewClient([]string{leader.GetConfig().AdvertiseClientUrls}, pd.SecurityOption{})
+	c.Assert(err, IsNil)
+	defer cli.Close()
+
+	ctx := context.Background()
+	min, err := cli.UpdateServiceGCSafePoint(ctx, "a", 1000, 2)
+	c.Assert(err, IsNil)
+	c.Assert(min, Equals, uint64(2))
+	min, err = cli.UpdateServiceGCSafePoint(ctx, "b", 1000, 3)
+	c.Assert(err, IsNil)
+	c.Assert(min, Equals, uint64(2))
+	// The safe point less than the minimal one is not updated.
+	min, err = cli.UpdateServiceGCSafePoint(ctx, "c", 1000, 1)
+	c.Assert(err, IsNil)
+	c.Assert(min, Equals, uint64(2))
+
+	newSafePoint, err := cli.UpdateGCSafePoint(ctx, 10)
+	c.Assert(err, IsNil)
+	c.Assert(newSafePoint, Equals, uint64(2))
+
+	// Remove a, then b holds back GC.
+	min, err = cli.UpdateServiceGCSafePoint(ctx, "a", 0, 0)
+	c.Assert(err, IsNil)
+	c.Assert(min, Equals, uint64(3))
+	min, err = cli.UpdateServiceGCSafePoint(ctx, "b", 0, 0)
+	c.Assert(err, IsNil)
+	c.Assert(min, Equals, uint64(0))
+}
+
 func (s *serverTestSuite) waitLeader(c *C, cli client, leader string) {
 	testutil.WaitUntil(c, func(c *C) bool {
 		cli.ScheduleCheckLeader()
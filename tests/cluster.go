@@ -422,8 +422,8 @@ func (c *TestCluster) HandleRegionHeartbeat(region *core.RegionInfo) error {
 }
 
 // Join is used to add a new TestServer into the cluster.
-func (c *TestCluster) Join() (*TestServer, error) {
-	conf, err := c.config.Join().Generate()
+func (c *TestCluster) Join(opts ...ConfigOption) (*TestServer, error) {
+	conf, err := c.config.Join().Generate(opts...)
 	if err != nil {
 		return nil, err
 	}
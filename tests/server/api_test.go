@@ -14,10 +14,15 @@
 package server_test
 
 import (
+	"encoding/json"
 	"net/http"
 
 	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/pd/pkg/testutil"
+	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/api"
+	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/tests"
 )
 
@@ -73,3 +78,52 @@ func (s *serverTestSuite) TestReconnect(c *C) {
 		}
 	}
 }
+
+func (s *serverTestSuite) TestFollowerServeRegions(c *C) {
+	c.Parallel()
+
+	cluster, err := tests.NewTestCluster(2, func(conf *server.Config) { conf.PDServerCfg.UseRegionStorage = true })
+	c.Assert(err, IsNil)
+	defer cluster.Destroy()
+
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	leader := cluster.GetServer(cluster.WaitLeader())
+	c.Assert(leader.BootstrapCluster(), IsNil)
+	region := &metapb.Region{
+		Id:          100,
+		RegionEpoch: &metapb.RegionEpoch{ConfVer: 1, Version: 1},
+		StartKey:    []byte("a"),
+		EndKey:      []byte("b"),
+		Peers:       []*metapb.Peer{{Id: 101, StoreId: 1}},
+	}
+	err = leader.GetServer().GetRaftCluster().HandleRegionHeartbeat(core.NewRegionInfo(region, region.Peers[0]))
+	c.Assert(err, IsNil)
+
+	for name, s := range cluster.GetServers() {
+		if name == leader.GetConfig().Name {
+			continue
+		}
+		// The region is served by the follower once it is synced.
+		testutil.WaitUntil(c, func(c *C) bool {
+			res, e := http.Get(s.GetConfig().AdvertiseClientUrls + "/pd/api/v1/region/id/100")
+			c.Assert(e, IsNil)
+			defer res.Body.Close()
+			info := &api.RegionInfo{}
+			c.Assert(json.NewDecoder(res.Body).Decode(info), IsNil)
+			return res.Header.Get("PD-Served-By") == name && info.ID == 100
+		})
+		res, e := http.Get(s.GetConfig().AdvertiseClientUrls + "/pd/api/v1/regions/store/1")
+		c.Assert(e, IsNil)
+		res.Body.Close()
+		c.Assert(res.Header.Get("PD-Served-By"), Equals, name)
+		c.Assert(res.Header.Get("PD-Region-Staleness"), Not(Equals), "")
+
+		// Other APIs are still redirected to the leader.
+		res, e = http.Get(s.GetConfig().AdvertiseClientUrls + "/pd/api/v1/regions/check/down-peer")
+		c.Assert(e, IsNil)
+		res.Body.Close()
+		c.Assert(res.StatusCode, Equals, http.StatusOK)
+		c.Assert(res.Header.Get("PD-Served-By"), Equals, "")
+	}
+}
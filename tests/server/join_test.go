@@ -52,6 +52,10 @@ func (s *serverTestSuite) TestSimpleJoin(c *C) {
 	members, err = etcdutil.ListEtcdMembers(client)
 	c.Assert(err, IsNil)
 	c.Assert(members.Members, HasLen, 2)
+	// The PD joins as a learner, and it runs after it is promoted.
+	for _, m := range members.Members {
+		c.Assert(m.IsLearner, IsFalse)
+	}
 	c.Assert(pd2.GetClusterID(), Equals, pd1.GetClusterID())
 
 	// Wait for all nodes becoming healthy.
@@ -15,6 +15,7 @@ package server_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -23,6 +24,7 @@ import (
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/pingcap/pd/pkg/tempurl"
 	"github.com/pingcap/pd/pkg/testutil"
 	"github.com/pingcap/pd/server"
 	"github.com/pingcap/pd/tests"
@@ -90,6 +92,94 @@ func (s *serverTestSuite) TestMemberDelete(c *C) {
 	}
 }
 
+func (s *serverTestSuite) TestMemberReplace(c *C) {
+	c.Parallel()
+
+	cluster, err := tests.NewTestCluster(3)
+	c.Assert(err, IsNil)
+	defer cluster.Destroy()
+
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	leaderName := cluster.WaitLeader()
+	c.Assert(leaderName, Not(Equals), "")
+	leader := cluster.GetServer(leaderName)
+	addr := leader.GetConfig().ClientUrls + "/pd/api/v1/members"
+	// Wait for all nodes becoming healthy.
+	time.Sleep(time.Second * 5)
+
+	var statuses []*server.MemberStatus
+	c.Assert(readJSON(addr+"/status", &statuses), IsNil)
+	c.Assert(statuses, HasLen, 3)
+	for _, status := range statuses {
+		c.Assert(status.Healthy, IsTrue)
+		c.Assert(status.RaftAppliedIndex, Greater, uint64(0))
+	}
+
+	// The first server is kept, as new servers join through it.
+	var dead *tests.TestServer
+	for _, s := range cluster.GetConfig().InitialServers[1:] {
+		if s.Name != leaderName {
+			dead = cluster.GetServer(s.Name)
+			break
+		}
+	}
+	c.Assert(dead.Stop(), IsNil)
+	peerURL := tempurl.Alloc()
+
+	replace := func(name string) int {
+		body := fmt.Sprintf(`{"name": %q, "peer-urls": [%q]}`, name, peerURL)
+		res, err := http.Post(addr+"/replace", "application/json", bytes.NewBufferString(body))
+		c.Assert(err, IsNil)
+		res.Body.Close()
+		return res.StatusCode
+	}
+	c.Assert(replace("foobar"), Equals, http.StatusNotFound)
+	c.Assert(replace(leaderName), Equals, http.StatusBadRequest)
+	c.Assert(replace(dead.GetConfig().Name), Equals, http.StatusOK)
+
+	// The replaced member is removed, and the new member is a learner until
+	// it starts and catches up.
+	c.Assert(readJSON(addr+"/status", &statuses), IsNil)
+	c.Assert(statuses, HasLen, 3)
+	for _, status := range statuses {
+		c.Assert(status.Name, Not(Equals), dead.GetConfig().Name)
+		c.Assert(status.IsLearner, Equals, status.Name == "")
+	}
+
+	// The new PD joins as the added member.
+	pd4, err := cluster.Join(func(cfg *server.Config) {
+		cfg.PeerUrls, cfg.AdvertisePeerUrls = peerURL, peerURL
+	})
+	c.Assert(err, IsNil)
+	c.Assert(pd4.Run(context.TODO()), IsNil)
+	c.Assert(pd4.GetClusterID(), Equals, leader.GetClusterID())
+	testutil.WaitUntil(c, func(c *C) bool {
+		var statuses []*server.MemberStatus
+		if err := readJSON(addr+"/status", &statuses); err != nil {
+			return false
+		}
+		names := make(map[string]bool)
+		for _, status := range statuses {
+			names[status.Name] = status.Healthy && !status.IsLearner
+		}
+		_, ok := names[dead.GetConfig().Name]
+		return len(statuses) == 3 && names[pd4.GetConfig().Name] && !ok
+	})
+}
+
+func readJSON(url string, data interface{}) error {
+	res, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return errors.Errorf("get %s failed, status: %v", url, res.StatusCode)
+	}
+	return json.NewDecoder(res.Body).Decode(data)
+}
+
 func (s *serverTestSuite) checkMemberList(c *C, clientURL string, configs []*server.Config) error {
 	httpClient := &http.Client{Timeout: 15 * time.Second}
 	addr := clientURL + "/pd/api/v1/members"
@@ -147,6 +237,69 @@ func (s *serverTestSuite) TestLeaderPriority(c *C) {
 	})
 }
 
+func (s *serverTestSuite) TestLeaderPolicy(c *C) {
+	c.Parallel()
+
+	cluster, err := tests.NewTestCluster(3, func(cfg *server.Config) {
+		cfg.Labels = map[string]string{"dc": cfg.Name}
+	})
+	c.Assert(err, IsNil)
+	defer cluster.Destroy()
+
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	leaderName := cluster.WaitLeader()
+	c.Assert(leaderName, Not(Equals), "")
+	addr := cluster.GetServer(leaderName).GetConfig().ClientUrls + "/pd/api/v1/members"
+
+	var target string
+	for _, s := range cluster.GetConfig().InitialServers {
+		if s.Name != leaderName {
+			target = s.Name
+			break
+		}
+	}
+	s.post(c, addr+"/leader-policy", fmt.Sprintf(`{"required": {"dc": %q}}`, target))
+	res, err := http.Post(addr+"/leader-policy", "application/json", bytes.NewBufferString(`{"required": {"dc": "a b"}}`))
+	c.Assert(err, IsNil)
+	res.Body.Close()
+	c.Assert(res.StatusCode, Equals, http.StatusBadRequest)
+
+	var members struct {
+		Members []struct {
+			Name   string            `json:"name"`
+			Labels map[string]string `json:"labels"`
+		} `json:"members"`
+		LeaderPolicy *server.LeaderPolicy `json:"leader_policy"`
+	}
+	c.Assert(readJSON(addr, &members), IsNil)
+	c.Assert(members.Members, HasLen, 3)
+	for _, m := range members.Members {
+		c.Assert(m.Labels, DeepEquals, map[string]string{"dc": m.Name})
+	}
+	c.Assert(members.LeaderPolicy.Required, DeepEquals, map[string]string{"dc": target})
+
+	// The leader is transferred to the member matching the policy.
+	testutil.WaitUntil(c, func(c *C) bool {
+		if cluster.GetLeader() != target {
+			time.Sleep(time.Second)
+			return false
+		}
+		return true
+	})
+
+	addr = cluster.GetServer(target).GetConfig().ClientUrls + "/pd/api/v1/members/leader-policy"
+	req, err := http.NewRequest(http.MethodDelete, addr, nil)
+	c.Assert(err, IsNil)
+	res, err = http.DefaultClient.Do(req)
+	c.Assert(err, IsNil)
+	res.Body.Close()
+	c.Assert(res.StatusCode, Equals, http.StatusOK)
+	var policy server.LeaderPolicy
+	c.Assert(readJSON(addr, &policy), IsNil)
+	c.Assert(policy.IsEmpty(), IsTrue)
+}
+
 func (s *serverTestSuite) post(c *C, url string, body string) {
 	testutil.WaitUntil(c, func(c *C) bool {
 		res, err := http.Post(url, "", bytes.NewBufferString(body))
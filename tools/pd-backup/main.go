@@ -0,0 +1,388 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pingcap/pd/pkg/encryption"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/tools/pd-backup/snapshot"
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/pkg/transport"
+)
+
+var (
+	endpoints = flag.String("endpoints", "http://127.0.0.1:2379", "endpoints urls")
+	clusterID = flag.Uint64("cluster-id", 0, "cluster ID to back up, it is read from etcd if not specified")
+	file      = flag.String("file", "pd-snapshot.json", "path of the snapshot file")
+	dataDir   = flag.String("data-dir", "", "data directory of a PD server, regions are read from or written to its local region storage if specified")
+	engine    = flag.String("engine", core.LevelDBEngine, "engine of the local region storage")
+	caPath    = flag.String("cacert", "", "path of file that contains list of trusted SSL CAs.")
+	certPath  = flag.String("cert", "", "path of file that contains X509 certificate in PEM format..")
+	keyPath   = flag.String("key", "", "path of file that contains X509 key in PEM format.")
+
+	allocIDMargin   = flag.Uint64("alloc-id-margin", defaultAllocIDMargin, "margin added to the saved alloc ID when restoring")
+	timestampMargin = flag.Duration("timestamp-margin", defaultTimestampMargin, "margin added to the later of the saved max timestamp and the current time when restoring")
+
+	masterKeyFile = flag.String("master-key-file", "", "path of the file that contains the hex encoded master key, the snapshot and the local region storage are encrypted with it")
+	masterKeyEnv  = flag.String("master-key-env", "", "name of the environment variable that contains the hex encoded master key, it is used if master-key-file is not specified")
+)
+
+const (
+	requestTimeout = 10 * time.Second
+	etcdTimeout    = 3 * time.Second
+
+	etcdRegionSource = "etcd"
+	// etcdBatchSize is less than the default max operations in an etcd txn.
+	etcdBatchSize = 100
+	etcdScanLimit = 1000
+
+	// defaultAllocIDMargin covers the IDs allocated after the snapshot is
+	// taken, it is much larger than the alloc step of PD.
+	defaultAllocIDMargin = 100000
+	// defaultTimestampMargin covers the timestamps allocated after the
+	// snapshot is taken and the clock drift of the restored cluster.
+	defaultTimestampMargin = time.Hour
+)
+
+// excludedKeys are owned by the running members, they are not part of the
+// cluster metadata.
+var excludedKeys = []string{"leader", "member/"}
+
+func exitErr(err error) {
+	fmt.Println(err.Error())
+	os.Exit(1)
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [flags] backup|restore\n", os.Args[0])
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() != 1 {
+		usage()
+		os.Exit(1)
+	}
+	keys, err := encryption.NewKeyManager(&encryption.Config{MasterKeyFile: *masterKeyFile, MasterKeyEnv: *masterKeyEnv})
+	if err != nil {
+		exitErr(err)
+	}
+	switch flag.Arg(0) {
+	case "backup":
+		s, err := backup(newEtcdClient(), keys)
+		if err != nil {
+			exitErr(err)
+		}
+		if err := s.Save(*file, keys); err != nil {
+			exitErr(err)
+		}
+		fmt.Printf("backup cluster %d at revision %d to %s successfully\n", s.ClusterID, s.Revision, *file)
+		printSummary(s)
+	case "restore":
+		s, err := snapshot.Load(*file, keys)
+		if err != nil {
+			exitErr(err)
+		}
+		now := time.Now()
+		if err := restore(newEtcdClient(), s, keys, now); err != nil {
+			exitErr(err)
+		}
+		fmt.Printf("restore cluster %d from %s successfully! please restart the PD cluster\n", s.ClusterID, *file)
+		printSummary(s)
+		fmt.Printf("restored alloc id: %d, restored max timestamp: %s\n",
+			s.SafeAllocID(*allocIDMargin), s.SafeTimestamp(now, *timestampMargin).Format(time.RFC3339Nano))
+	default:
+		usage()
+		os.Exit(1)
+	}
+}
+
+func printSummary(s *snapshot.Snapshot) {
+	fmt.Printf("stores: %d, regions: %d (from %s), alloc id: %d, max timestamp: %s, gc safe point: %d\n",
+		s.StoreCount, s.RegionCount, s.RegionSource, s.AllocID, s.MaxTimestamp.Format(time.RFC3339Nano), s.GCSafePoint)
+}
+
+func backup(client *clientv3.Client, keys *encryption.KeyManager) (*snapshot.Snapshot, error) {
+	id := *clusterID
+	if id == 0 {
+		var err error
+		if id, err = loadClusterID(client); err != nil {
+			return nil, err
+		}
+	}
+	s := &snapshot.Snapshot{
+		Version:      snapshot.Version,
+		ClusterID:    id,
+		CreatedAt:    time.Now(),
+		RegionSource: etcdRegionSource,
+	}
+	prefix := snapshot.RootPath(id) + "/"
+	endKey := clientv3.GetPrefixRangeEnd(prefix)
+	// All pages are read at the revision of the first one, so the snapshot is
+	// consistent.
+	startKey := prefix
+	for {
+		opts := []clientv3.OpOption{clientv3.WithRange(endKey), clientv3.WithLimit(etcdScanLimit)}
+		if s.Revision > 0 {
+			opts = append(opts, clientv3.WithRev(s.Revision))
+		}
+		ctx, cancel := context.WithTimeout(client.Ctx(), requestTimeout)
+		resp, err := client.Get(ctx, startKey, opts...)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		if s.Revision == 0 {
+			s.Revision = resp.Header.GetRevision()
+		}
+		for _, kv := range resp.Kvs {
+			key := strings.TrimPrefix(string(kv.Key), prefix)
+			if !isExcluded(key) {
+				s.KVs = append(s.KVs, &snapshot.KV{Key: key, Value: kv.Value})
+			}
+		}
+		if !resp.More {
+			break
+		}
+		startKey = string(resp.Kvs[len(resp.Kvs)-1].Key) + "\x00"
+	}
+	if s.Get(snapshot.ClusterKey) == nil {
+		return nil, fmt.Errorf("cluster %d is not bootstrapped", id)
+	}
+
+	if *dataDir != "" {
+		if err := loadLocalRegions(s, keys); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.Summarize(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func isExcluded(key string) bool {
+	for _, k := range excludedKeys {
+		if key == k || (strings.HasSuffix(k, "/") && strings.HasPrefix(key, k)) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadLocalRegions replaces the regions in etcd with the ones in the local
+// region storage, which is more up to date if use-region-storage is enabled.
+func loadLocalRegions(s *snapshot.Snapshot, keys *encryption.KeyManager) error {
+	base, err := core.NewKVEngine(*engine, core.RegionStoragePath(*dataDir, *engine))
+	if err != nil {
+		return err
+	}
+	kv := core.NewEncryptedKVEngine(base, keys)
+	defer kv.Close()
+
+	kvs := s.KVs[:0]
+	for _, pair := range s.KVs {
+		if !strings.HasPrefix(pair.Key, snapshot.RegionPrefix) {
+			kvs = append(kvs, pair)
+		}
+	}
+	iter := kv.NewIterator(snapshot.RegionPrefix, regionPath(math.MaxUint64))
+	defer iter.Release()
+	for iter.Next() {
+		kvs = append(kvs, &snapshot.KV{Key: iter.Key(), Value: []byte(iter.Value())})
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	sort.Slice(kvs, func(i, j int) bool { return kvs[i].Key < kvs[j].Key })
+	s.KVs, s.RegionSource = kvs, *engine
+	return nil
+}
+
+func regionPath(regionID uint64) string {
+	return path.Join(snapshot.RegionPrefix, fmt.Sprintf("%020d", regionID))
+}
+
+// restore writes the snapshot to a cluster which is not bootstrapped with the
+// cluster ID. The cluster meta is written at last, so the cluster is not
+// bootstrapped until all other pairs are written. If a previous restore
+// fails halfway, running it again overwrites the pairs written before and
+// removes the ones not in the snapshot. The alloc ID and the timestamp are
+// raised by the margins, so the restored cluster never reuses an ID or a
+// timestamp allocated after the snapshot is taken, or before now.
+func restore(client *clientv3.Client, s *snapshot.Snapshot, keys *encryption.KeyManager, now time.Time) error {
+	if err := s.Summarize(); err != nil {
+		return err
+	}
+	rootPath := snapshot.RootPath(s.ClusterID)
+	clusterKey := path.Join(rootPath, snapshot.ClusterKey)
+	ctx, cancel := context.WithTimeout(client.Ctx(), requestTimeout)
+	resp, err := client.Get(ctx, clusterKey, clientv3.WithCountOnly())
+	cancel()
+	if err != nil {
+		return err
+	}
+	if resp.Count > 0 {
+		return fmt.Errorf("failed to restore: cluster %d is already bootstrapped", s.ClusterID)
+	}
+
+	notBootstrapped := clientv3.Compare(clientv3.CreateRevision(clusterKey), "=", 0)
+	var ops []clientv3.Op
+	commit := func() error {
+		ctx, cancel := context.WithTimeout(client.Ctx(), requestTimeout)
+		defer cancel()
+		resp, err := client.Txn(ctx).If(notBootstrapped).Then(ops...).Commit()
+		if err != nil {
+			return err
+		}
+		if !resp.Succeeded {
+			return fmt.Errorf("failed to restore: cluster %d is bootstrapped during the restore", s.ClusterID)
+		}
+		ops = ops[:0]
+		return nil
+	}
+	add := func(op clientv3.Op) error {
+		ops = append(ops, op)
+		if len(ops) >= etcdBatchSize {
+			return commit()
+		}
+		return nil
+	}
+
+	// Remove the pairs left by a previous restore which are not in the
+	// snapshot.
+	stale, err := loadKeys(client, rootPath+"/")
+	if err != nil {
+		return err
+	}
+	for _, key := range stale {
+		if !isExcluded(key) && s.Get(key) == nil {
+			if err := add(clientv3.OpDelete(path.Join(rootPath, key))); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, kv := range s.KVs {
+		value := kv.Value
+		switch kv.Key {
+		case snapshot.ClusterKey:
+			continue
+		case snapshot.AllocIDKey:
+			value = snapshot.Uint64ToBytes(s.SafeAllocID(*allocIDMargin))
+		case snapshot.TimestampKey:
+			value = snapshot.Uint64ToBytes(uint64(s.SafeTimestamp(now, *timestampMargin).UnixNano()))
+		}
+		if err := add(clientv3.OpPut(path.Join(rootPath, kv.Key), string(value))); err != nil {
+			return err
+		}
+	}
+	if *dataDir != "" {
+		if err := saveLocalRegions(s, keys); err != nil {
+			return err
+		}
+	}
+	ops = append(ops,
+		clientv3.OpPut(snapshot.ClusterIDPath, string(snapshot.Uint64ToBytes(s.ClusterID))),
+		clientv3.OpPut(clusterKey, string(s.Get(snapshot.ClusterKey))),
+	)
+	return commit()
+}
+
+// loadKeys returns the keys with the prefix, relative to the prefix.
+func loadKeys(client *clientv3.Client, prefix string) ([]string, error) {
+	var keys []string
+	endKey := clientv3.GetPrefixRangeEnd(prefix)
+	startKey := prefix
+	for {
+		ctx, cancel := context.WithTimeout(client.Ctx(), requestTimeout)
+		resp, err := client.Get(ctx, startKey, clientv3.WithRange(endKey), clientv3.WithLimit(etcdScanLimit), clientv3.WithKeysOnly())
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		for _, kv := range resp.Kvs {
+			keys = append(keys, strings.TrimPrefix(string(kv.Key), prefix))
+		}
+		if !resp.More {
+			return keys, nil
+		}
+		startKey = string(resp.Kvs[len(resp.Kvs)-1].Key) + "\x00"
+	}
+}
+
+// saveLocalRegions writes the regions to the local region storage, which is
+// used by the server if use-region-storage is enabled.
+func saveLocalRegions(s *snapshot.Snapshot, keys *encryption.KeyManager) error {
+	base, err := core.NewKVEngine(*engine, core.RegionStoragePath(*dataDir, *engine))
+	if err != nil {
+		return err
+	}
+	kv := core.NewEncryptedKVEngine(base, keys)
+	defer kv.Close()
+	batch := &core.KVBatch{}
+	for _, pair := range s.KVs {
+		if strings.HasPrefix(pair.Key, snapshot.RegionPrefix) {
+			batch.Put(pair.Key, string(pair.Value))
+		}
+	}
+	return kv.Write(batch)
+}
+
+func loadClusterID(client *clientv3.Client) (uint64, error) {
+	ctx, cancel := context.WithTimeout(client.Ctx(), requestTimeout)
+	defer cancel()
+	resp, err := client.Get(ctx, snapshot.ClusterIDPath)
+	if err != nil {
+		return 0, err
+	}
+	if len(resp.Kvs) != 1 || len(resp.Kvs[0].Value) != 8 {
+		return 0, fmt.Errorf("failed to read cluster id from %s", snapshot.ClusterIDPath)
+	}
+	return binary.BigEndian.Uint64(resp.Kvs[0].Value), nil
+}
+
+func newEtcdClient() *clientv3.Client {
+	tlsInfo := transport.TLSInfo{
+		CertFile:      *certPath,
+		KeyFile:       *keyPath,
+		TrustedCAFile: *caPath,
+	}
+	tlsConfig, err := tlsInfo.ClientConfig()
+	if err != nil {
+		exitErr(err)
+	}
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   strings.Split(*endpoints, ","),
+		DialTimeout: etcdTimeout,
+		TLS:         tlsConfig,
+	})
+	if err != nil {
+		exitErr(err)
+	}
+	return client
+}
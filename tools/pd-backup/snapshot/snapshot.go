@@ -0,0 +1,235 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/pkg/encryption"
+	"github.com/pkg/errors"
+)
+
+// Version is the version of the snapshot file format. It is increased when
+// the format changes incompatibly.
+const Version = 1
+
+// Keys of the PD metadata, which are relative to the root path of a cluster.
+const (
+	ClusterKey     = "raft"
+	AllocIDKey     = "alloc_id"
+	TimestampKey   = "timestamp"
+	GCSafePointKey = "gc/safe_point"
+	StorePrefix    = "raft/s/"
+	RegionPrefix   = "raft/r/"
+
+	// ClusterIDPath is the etcd key of the cluster ID.
+	ClusterIDPath = "/pd/cluster_id"
+	pdRootPath    = "/pd"
+)
+
+// RootPath returns the etcd root path of the cluster.
+func RootPath(clusterID uint64) string {
+	return path.Join(pdRootPath, strconv.FormatUint(clusterID, 10))
+}
+
+// KV is a key-value pair of the PD metadata.
+type KV struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"`
+}
+
+// Snapshot is a consistent copy of the metadata of a PD cluster. The pairs
+// contain everything under the root path of the cluster, such as the cluster
+// meta, stores, regions, config, namespaces and GC safe points. The other
+// fields are decoded from the pairs for inspection.
+type Snapshot struct {
+	Version   int       `json:"version"`
+	ClusterID uint64    `json:"cluster_id"`
+	CreatedAt time.Time `json:"created_at"`
+	// Revision is the etcd revision the snapshot is read at.
+	Revision int64 `json:"revision"`
+	// RegionSource is where the regions are read from, etcd or a local region
+	// storage engine.
+	RegionSource string    `json:"region_source"`
+	AllocID      uint64    `json:"alloc_id"`
+	MaxTimestamp time.Time `json:"max_timestamp"`
+	GCSafePoint  uint64    `json:"gc_safe_point"`
+	StoreCount   int       `json:"store_count"`
+	RegionCount  int       `json:"region_count"`
+	KVs          []*KV     `json:"kvs"`
+}
+
+// Load reads the snapshot from the file. An encrypted file is decrypted with
+// the master keys.
+func Load(filename string, keys *encryption.KeyManager) (*Snapshot, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if data, err = keys.Decrypt(data, nil); err != nil {
+		return nil, errors.WithMessage(err, filename)
+	}
+	s := &Snapshot{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, errors.Wrapf(err, "invalid snapshot file %s", filename)
+	}
+	if s.Version != Version {
+		return nil, errors.Errorf("unsupported snapshot version %d, expect %d", s.Version, Version)
+	}
+	if s.ClusterID == 0 || s.Get(ClusterKey) == nil {
+		return nil, errors.Errorf("invalid snapshot file %s: missing cluster meta", filename)
+	}
+	return s, nil
+}
+
+// Save writes the snapshot to the file. The file is replaced atomically, and
+// it is encrypted with the current master key if keys is not nil.
+func (s *Snapshot) Save(filename string, keys *encryption.KeyManager) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if data, err = keys.Encrypt(data, nil); err != nil {
+		return err
+	}
+	tmp := filename + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := os.Rename(tmp, filename); err != nil {
+		os.Remove(tmp)
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// Get returns the value of the key, or nil if it does not exist.
+func (s *Snapshot) Get(key string) []byte {
+	i := sort.Search(len(s.KVs), func(i int) bool { return s.KVs[i].Key >= key })
+	if i < len(s.KVs) && s.KVs[i].Key == key {
+		return s.KVs[i].Value
+	}
+	return nil
+}
+
+// Stores decodes the stores in the snapshot.
+func (s *Snapshot) Stores() ([]*metapb.Store, error) {
+	var stores []*metapb.Store
+	err := s.scan(StorePrefix, func(kv *KV) error {
+		store := &metapb.Store{}
+		if err := store.Unmarshal(kv.Value); err != nil {
+			return errors.Wrapf(err, "invalid store %s", kv.Key)
+		}
+		stores = append(stores, store)
+		return nil
+	})
+	return stores, err
+}
+
+// Regions decodes the regions in the snapshot.
+func (s *Snapshot) Regions() ([]*metapb.Region, error) {
+	var regions []*metapb.Region
+	err := s.scan(RegionPrefix, func(kv *KV) error {
+		region := &metapb.Region{}
+		if err := region.Unmarshal(kv.Value); err != nil {
+			return errors.Wrapf(err, "invalid region %s", kv.Key)
+		}
+		regions = append(regions, region)
+		return nil
+	})
+	return regions, err
+}
+
+func (s *Snapshot) scan(prefix string, f func(kv *KV) error) error {
+	i := sort.Search(len(s.KVs), func(i int) bool { return s.KVs[i].Key >= prefix })
+	for ; i < len(s.KVs) && strings.HasPrefix(s.KVs[i].Key, prefix); i++ {
+		if err := f(s.KVs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Summarize fills the fields decoded from the pairs.
+func (s *Snapshot) Summarize() error {
+	var err error
+	if v := s.Get(AllocIDKey); v != nil {
+		if s.AllocID, err = bytesToUint64(v); err != nil {
+			return errors.Wrap(err, "invalid alloc id")
+		}
+	}
+	if v := s.Get(TimestampKey); v != nil {
+		nano, err := bytesToUint64(v)
+		if err != nil {
+			return errors.Wrap(err, "invalid timestamp")
+		}
+		s.MaxTimestamp = time.Unix(0, int64(nano))
+	}
+	if v := s.Get(GCSafePointKey); v != nil {
+		if s.GCSafePoint, err = strconv.ParseUint(string(v), 16, 64); err != nil {
+			return errors.Wrap(err, "invalid gc safe point")
+		}
+	}
+	stores, err := s.Stores()
+	if err != nil {
+		return err
+	}
+	regions, err := s.Regions()
+	if err != nil {
+		return err
+	}
+	s.StoreCount, s.RegionCount = len(stores), len(regions)
+	return nil
+}
+
+// SafeAllocID returns the alloc ID to restore. It is larger than the saved
+// one by the margin, which covers the IDs allocated after the snapshot is
+// taken.
+func (s *Snapshot) SafeAllocID(margin uint64) uint64 {
+	return s.AllocID + margin
+}
+
+// SafeTimestamp returns the max timestamp to restore. It is later than both
+// the saved one and now by the margin, so the restored cluster never
+// allocates a timestamp that may be allocated after the snapshot is taken.
+func (s *Snapshot) SafeTimestamp(now time.Time, margin time.Duration) time.Time {
+	ts := s.MaxTimestamp
+	if now.After(ts) {
+		ts = now
+	}
+	return ts.Add(margin)
+}
+
+// Uint64ToBytes encodes the value in the way PD saves IDs and timestamps.
+func Uint64ToBytes(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
+
+func bytesToUint64(b []byte) (uint64, error) {
+	if len(b) != 8 {
+		return 0, errors.Errorf("invalid data length %d", len(b))
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
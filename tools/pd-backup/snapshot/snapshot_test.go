@@ -0,0 +1,107 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/pkg/encryption"
+)
+
+func TestSnapshot(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testSnapshotSuite{})
+
+type testSnapshotSuite struct{}
+
+func mustMarshal(c *C, m interface{ Marshal() ([]byte, error) }) []byte {
+	data, err := m.Marshal()
+	c.Assert(err, IsNil)
+	return data
+}
+
+func (s *testSnapshotSuite) TestSaveAndLoad(c *C) {
+	now := time.Now()
+	snap := &Snapshot{
+		Version:   Version,
+		ClusterID: 42,
+		KVs: []*KV{
+			{Key: AllocIDKey, Value: Uint64ToBytes(1000)},
+			{Key: "config", Value: []byte("{}")},
+			{Key: GCSafePointKey, Value: []byte(strconv.FormatUint(433, 16))},
+			{Key: ClusterKey, Value: mustMarshal(c, &metapb.Cluster{Id: 42})},
+			{Key: RegionPrefix + "00000000000000000002", Value: mustMarshal(c, &metapb.Region{Id: 2})},
+			{Key: RegionPrefix + "00000000000000000003", Value: mustMarshal(c, &metapb.Region{Id: 3})},
+			{Key: StorePrefix + "00000000000000000001", Value: mustMarshal(c, &metapb.Store{Id: 1})},
+			{Key: TimestampKey, Value: Uint64ToBytes(uint64(now.UnixNano()))},
+		},
+	}
+	c.Assert(snap.Summarize(), IsNil)
+	c.Assert(snap.AllocID, Equals, uint64(1000))
+	c.Assert(snap.GCSafePoint, Equals, uint64(433))
+	c.Assert(snap.MaxTimestamp.Equal(now), IsTrue)
+	c.Assert(snap.StoreCount, Equals, 1)
+	c.Assert(snap.RegionCount, Equals, 2)
+
+	dir, err := ioutil.TempDir("/tmp", "test_snapshot")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "snapshot.json")
+	c.Assert(snap.Save(filename, nil), IsNil)
+	loaded, err := Load(filename, nil)
+	c.Assert(err, IsNil)
+	c.Assert(loaded.KVs, DeepEquals, snap.KVs)
+	regions, err := loaded.Regions()
+	c.Assert(err, IsNil)
+	c.Assert(regions, HasLen, 2)
+	c.Assert(regions[1].GetId(), Equals, uint64(3))
+	c.Assert(loaded.Get("config"), DeepEquals, []byte("{}"))
+	c.Assert(loaded.Get("conf"), IsNil)
+
+	// An encrypted snapshot can only be loaded with the master key.
+	keys, err := encryption.NewKeyManagerFromKey(strings.Repeat("ab", 32))
+	c.Assert(err, IsNil)
+	c.Assert(snap.Save(filename, keys), IsNil)
+	data, err := ioutil.ReadFile(filename)
+	c.Assert(err, IsNil)
+	c.Assert(encryption.IsEncrypted(data), IsTrue)
+	_, err = Load(filename, nil)
+	c.Assert(err, NotNil)
+	loaded, err = Load(filename, keys)
+	c.Assert(err, IsNil)
+	c.Assert(loaded.KVs, DeepEquals, snap.KVs)
+
+	snap.Version = Version + 1
+	c.Assert(snap.Save(filename, nil), IsNil)
+	_, err = Load(filename, nil)
+	c.Assert(err, NotNil)
+}
+
+func (s *testSnapshotSuite) TestSafeValues(c *C) {
+	now := time.Now()
+	snap := &Snapshot{AllocID: 1000, MaxTimestamp: now}
+	c.Assert(snap.SafeAllocID(100000), Equals, uint64(101000))
+	c.Assert(snap.SafeTimestamp(now.Add(-time.Hour), time.Minute).Equal(now.Add(time.Minute)), IsTrue)
+	c.Assert(snap.SafeTimestamp(now.Add(time.Hour), time.Minute).Equal(now.Add(time.Hour+time.Minute)), IsTrue)
+}
@@ -30,6 +30,8 @@ var (
 	namespacePrefix      = "pd/api/v1/config/namespace"
 	labelPropertyPrefix  = "pd/api/v1/config/label-property"
 	clusterVersionPrefix = "pd/api/v1/config/cluster-version"
+	configHistoryPrefix  = "pd/api/v1/config/history"
+	configRollbackPrefix = "pd/api/v1/config/rollback"
 )
 
 // NewConfigCommand return a config subcommand of rootCmd
@@ -41,9 +43,31 @@ func NewConfigCommand() *cobra.Command {
 	conf.AddCommand(NewShowConfigCommand())
 	conf.AddCommand(NewSetConfigCommand())
 	conf.AddCommand(NewDeleteConfigCommand())
+	conf.AddCommand(NewConfigHistoryCommand())
+	conf.AddCommand(NewConfigRollbackCommand())
 	return conf
 }
 
+// NewConfigHistoryCommand return a history subcommand of configCmd
+func NewConfigHistoryCommand() *cobra.Command {
+	sc := &cobra.Command{
+		Use:   "history",
+		Short: "show the change history of schedule and replication config",
+		Run:   showConfigHistoryCommandFunc,
+	}
+	return sc
+}
+
+// NewConfigRollbackCommand return a rollback subcommand of configCmd
+func NewConfigRollbackCommand() *cobra.Command {
+	sc := &cobra.Command{
+		Use:   "rollback <version>",
+		Short: "rollback schedule and replication config to the version in history",
+		Run:   rollbackConfigCommandFunc,
+	}
+	return sc
+}
+
 // NewShowConfigCommand return a show subcommand of configCmd
 func NewShowConfigCommand() *cobra.Command {
 	sc := &cobra.Command{
@@ -242,7 +266,40 @@ func showClusterVersionCommandFunc(cmd *cobra.Command, args []string) {
 	cmd.Println(r)
 }
 
+func showConfigHistoryCommandFunc(cmd *cobra.Command, args []string) {
+	r, err := doRequest(cmd, configHistoryPrefix, http.MethodGet)
+	if err != nil {
+		cmd.Printf("Failed to get config history: %s\n", err)
+		return
+	}
+	cmd.Println(r)
+}
+
+func rollbackConfigCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	if _, err := strconv.ParseUint(args[0], 10, 64); err != nil {
+		cmd.Println("version should be a number")
+		return
+	}
+	prefix := path.Join(configRollbackPrefix, args[0])
+	_, err := doRequest(cmd, prefix, http.MethodPost)
+	if err != nil {
+		cmd.Printf("Failed to rollback config: %s\n", err)
+		return
+	}
+	cmd.Println("Success!")
+}
+
 func postConfigDataWithPath(cmd *cobra.Command, key, value, path string) error {
+	_, err := postConfigData(cmd, key, value, path)
+	return err
+}
+
+// postConfigData posts the option and returns the response.
+func postConfigData(cmd *cobra.Command, key, value, path string) (string, error) {
 	var val interface{}
 	data := make(map[string]interface{})
 	val, err := strconv.ParseFloat(value, 64)
@@ -252,17 +309,13 @@ func postConfigDataWithPath(cmd *cobra.Command, key, value, path string) error {
 	data[key] = val
 	reqData, err := json.Marshal(data)
 	if err != nil {
-		return err
+		return "", err
 	}
 	req, err := getRequest(cmd, path, http.MethodPost, "application/json", bytes.NewBuffer(reqData))
 	if err != nil {
-		return err
-	}
-	_, err = dail(req)
-	if err != nil {
-		return err
+		return "", err
 	}
-	return nil
+	return dail(req)
 }
 
 func setConfigCommandFunc(cmd *cobra.Command, args []string) {
@@ -271,11 +324,13 @@ func setConfigCommandFunc(cmd *cobra.Command, args []string) {
 		return
 	}
 	opt, val := args[0], args[1]
-	err := postConfigDataWithPath(cmd, opt, val, configPrefix)
+	r, err := postConfigData(cmd, opt, val, configPrefix)
 	if err != nil {
 		cmd.Printf("Failed to set config: %s\n", err)
 		return
 	}
+	// The response lists the changed fields.
+	cmd.Println(r)
 	cmd.Println("Success!")
 }
 
@@ -0,0 +1,63 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"net/http"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	gcSafePointPrefix        = "pd/api/v1/gc/safepoint"
+	serviceGCSafePointPrefix = "pd/api/v1/gc/safepoint/service"
+)
+
+// NewServiceGCSafePointCommand return a service gc safe point subcommand of rootCmd
+func NewServiceGCSafePointCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "service-gc-safepoint",
+		Short: "show the gc safe point and the safe points of services",
+		Run:   showServiceGCSafePointCommandFunc,
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "delete <service_id>",
+		Short: "delete the safe point of a service",
+		Run:   deleteServiceGCSafePointCommandFunc,
+	})
+	return cmd
+}
+
+func showServiceGCSafePointCommandFunc(cmd *cobra.Command, args []string) {
+	r, err := doRequest(cmd, gcSafePointPrefix, http.MethodGet)
+	if err != nil {
+		cmd.Printf("Failed to get service gc safe points: %s\n", err)
+		return
+	}
+	cmd.Println(r)
+}
+
+func deleteServiceGCSafePointCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cmd.Println("Usage: service-gc-safepoint delete <service_id>")
+		return
+	}
+	prefix := serviceGCSafePointPrefix + "/" + args[0]
+	_, err := doRequest(cmd, prefix, http.MethodDelete)
+	if err != nil {
+		cmd.Printf("Failed to delete service gc safe point %s: %s\n", args[0], err)
+		return
+	}
+	cmd.Println("Success!")
+}
@@ -18,7 +18,9 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
@@ -30,18 +32,29 @@ var (
 // NewMemberCommand return a member subcommand of rootCmd
 func NewMemberCommand() *cobra.Command {
 	m := &cobra.Command{
-		Use:   "member [leader|delete|leader_priority]",
+		Use:   "member [leader|delete|leader_priority|leader_policy|status|replace]",
 		Short: "show the pd member status",
 		Run:   showMemberCommandFunc,
 	}
 	m.AddCommand(NewLeaderMemberCommand())
 	m.AddCommand(NewDeleteMemberCommand())
+	m.AddCommand(NewLeaderPolicyCommand())
 
 	m.AddCommand(&cobra.Command{
 		Use:   "leader_priority <member_name> <priority>",
 		Short: "set the member's priority to be elected as etcd leader",
 		Run:   setLeaderPriorityFunc,
 	})
+	m.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "show the raft status and the apply lag of the members",
+		Run:   showMemberStatusCommandFunc,
+	})
+	m.AddCommand(&cobra.Command{
+		Use:   "replace <member_name> <peer_urls>",
+		Short: "replace an unhealthy member with a new member of the comma separated peer urls",
+		Run:   replaceMemberCommandFunc,
+	})
 	return m
 }
 
@@ -88,6 +101,30 @@ func NewLeaderMemberCommand() *cobra.Command {
 	return d
 }
 
+// NewLeaderPolicyCommand return a leader_policy subcommand of memberCmd
+func NewLeaderPolicyCommand() *cobra.Command {
+	d := &cobra.Command{
+		Use:   "leader_policy <subcommand>",
+		Short: "place the leader on members by their labels",
+	}
+	d.AddCommand(&cobra.Command{
+		Use:   "show",
+		Short: "show the leader policy",
+		Run:   showLeaderPolicyCommandFunc,
+	})
+	d.AddCommand(&cobra.Command{
+		Use:   "set <required_labels> [<preferred_labels>]",
+		Short: "set the leader policy, labels are comma separated key=value pairs, use \"\" for no required labels",
+		Run:   setLeaderPolicyCommandFunc,
+	})
+	d.AddCommand(&cobra.Command{
+		Use:   "delete",
+		Short: "delete the leader policy",
+		Run:   deleteLeaderPolicyCommandFunc,
+	})
+	return d
+}
+
 func showMemberCommandFunc(cmd *cobra.Command, args []string) {
 	r, err := doRequest(cmd, membersPrefix, http.MethodGet)
 	if err != nil {
@@ -183,3 +220,96 @@ func setLeaderPriorityFunc(cmd *cobra.Command, args []string) {
 	}
 	cmd.Println("Success!")
 }
+
+func showMemberStatusCommandFunc(cmd *cobra.Command, args []string) {
+	r, err := doRequest(cmd, membersPrefix+"/status", http.MethodGet)
+	if err != nil {
+		cmd.Printf("Failed to get the status of pd members: %s\n", err)
+		return
+	}
+	cmd.Println(r)
+}
+
+func replaceMemberCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		cmd.Println("Usage: member replace <member_name> <peer_urls>")
+		return
+	}
+	data := map[string]interface{}{
+		"name":      args[0],
+		"peer-urls": strings.Split(args[1], ","),
+	}
+	reqData, _ := json.Marshal(data)
+	req, err := getRequest(cmd, membersPrefix+"/replace", http.MethodPost, "application/json", bytes.NewBuffer(reqData))
+	if err != nil {
+		cmd.Printf("Failed to replace member %s: %s\n", args[0], err)
+		return
+	}
+	r, err := dail(req)
+	if err != nil {
+		cmd.Printf("Failed to replace member %s: %s\n", args[0], err)
+		return
+	}
+	cmd.Println(r)
+}
+
+func showLeaderPolicyCommandFunc(cmd *cobra.Command, args []string) {
+	r, err := doRequest(cmd, membersPrefix+"/leader-policy", http.MethodGet)
+	if err != nil {
+		cmd.Printf("Failed to get the leader policy: %s\n", err)
+		return
+	}
+	cmd.Println(r)
+}
+
+func setLeaderPolicyCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) < 1 || len(args) > 2 {
+		cmd.Println("Usage: member leader_policy set <required_labels> [<preferred_labels>]")
+		return
+	}
+	data := make(map[string]interface{})
+	for i, key := range []string{"required", "preferred"}[:len(args)] {
+		labels, err := parseLabels(args[i])
+		if err != nil {
+			cmd.Printf("Failed to set the leader policy: %s\n", err)
+			return
+		}
+		data[key] = labels
+	}
+	reqData, _ := json.Marshal(data)
+	req, err := getRequest(cmd, membersPrefix+"/leader-policy", http.MethodPost, "application/json", bytes.NewBuffer(reqData))
+	if err != nil {
+		cmd.Printf("Failed to set the leader policy: %s\n", err)
+		return
+	}
+	if _, err = dail(req); err != nil {
+		cmd.Printf("Failed to set the leader policy: %s\n", err)
+		return
+	}
+	cmd.Println("Success!")
+}
+
+// parseLabels parses comma separated key=value pairs.
+func parseLabels(s string) (map[string]string, error) {
+	labels := make(map[string]string)
+	if s == "" {
+		return labels, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return nil, errors.Errorf("invalid label %q, should be key=value", pair)
+		}
+		labels[kv[0]] = kv[1]
+	}
+	return labels, nil
+}
+
+func deleteLeaderPolicyCommandFunc(cmd *cobra.Command, args []string) {
+	_, err := doRequest(cmd, membersPrefix+"/leader-policy", http.MethodDelete)
+	if err != nil {
+		cmd.Printf("Failed to delete the leader policy: %s\n", err)
+		return
+	}
+	cmd.Println("Success!")
+}
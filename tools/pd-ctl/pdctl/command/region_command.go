@@ -31,18 +31,21 @@ import (
 )
 
 var (
-	regionsPrefix          = "pd/api/v1/regions"
-	regionsStorePrefix     = "pd/api/v1/regions/store"
-	regionsCheckPrefix     = "pd/api/v1/regions/check"
-	regionsWriteflowPrefix = "pd/api/v1/regions/writeflow"
-	regionsReadflowPrefix  = "pd/api/v1/regions/readflow"
-	regionsConfVerPrefix   = "pd/api/v1/regions/confver"
-	regionsVersionPrefix   = "pd/api/v1/regions/version"
-	regionsSizePrefix      = "pd/api/v1/regions/size"
-	regionsKeyPrefix       = "pd/api/v1/regions/key"
-	regionsSiblingPrefix   = "pd/api/v1/regions/sibling"
-	regionIDPrefix         = "pd/api/v1/region/id"
-	regionKeyPrefix        = "pd/api/v1/region/key"
+	regionsPrefix           = "pd/api/v1/regions"
+	regionsStorePrefix      = "pd/api/v1/regions/store"
+	regionsCheckPrefix      = "pd/api/v1/regions/check"
+	regionsWriteflowPrefix  = "pd/api/v1/regions/writeflow"
+	regionsReadflowPrefix   = "pd/api/v1/regions/readflow"
+	regionsConfVerPrefix    = "pd/api/v1/regions/confver"
+	regionsVersionPrefix    = "pd/api/v1/regions/version"
+	regionsSizePrefix       = "pd/api/v1/regions/size"
+	regionsKeyPrefix        = "pd/api/v1/regions/key"
+	regionsSiblingPrefix    = "pd/api/v1/regions/sibling"
+	regionsHealthPrefix     = "pd/api/v1/regions/health"
+	regionsSimulatePrefix   = "pd/api/v1/regions/simulate-failure"
+	regionsTableStatsPrefix = "pd/api/v1/stats/tables"
+	regionIDPrefix          = "pd/api/v1/region/id"
+	regionKeyPrefix         = "pd/api/v1/region/key"
 )
 
 // NewRegionCommand returns a region subcommand of rootCmd
@@ -57,6 +60,9 @@ func NewRegionCommand() *cobra.Command {
 	r.AddCommand(NewRegionWithSiblingCommand())
 	r.AddCommand(NewRegionWithStoreCommand())
 	r.AddCommand(NewRegionsWithStartKeyCommand())
+	r.AddCommand(NewRegionHealthCommand())
+	r.AddCommand(NewRegionSimulateFailureCommand())
+	r.AddCommand(NewRegionTableStatsCommand())
 
 	topRead := &cobra.Command{
 		Use:   `topread <limit> [--jq="<query string>"]`,
@@ -436,6 +442,152 @@ func showRegionWithCheckCommandFunc(cmd *cobra.Command, args []string) {
 	cmd.Println(r)
 }
 
+// NewRegionHealthCommand returns a health subcommand of regionCmd.
+func NewRegionHealthCommand() *cobra.Command {
+	r := &cobra.Command{
+		Use:   `health [--format=raw|encode|hex] [--start_key=<key>] [--end_key=<key>] [--namespace=<namespace>] [--samples=<n>] [--jq="<query string>"]`,
+		Short: "show the health summary and the isolation levels of regions",
+		Run:   showRegionHealthCommandFunc,
+	}
+	r.Flags().String("format", "hex", "the key format")
+	r.Flags().String("start_key", "", "only summarize regions which overlap the keys from the start key")
+	r.Flags().String("end_key", "", "only summarize regions which overlap the keys before the end key")
+	r.Flags().String("namespace", "", "only summarize regions in the namespace")
+	r.Flags().Int("samples", 0, "the number of sample region IDs in each bucket")
+	r.Flags().String("jq", "", "jq query")
+	return r
+}
+
+func showRegionHealthCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	query := url.Values{}
+	for _, name := range []string{"start_key", "end_key"} {
+		value, _ := cmd.Flags().GetString(name)
+		if value == "" {
+			continue
+		}
+		key, err := parseKey(cmd.Flags(), value)
+		if err != nil {
+			cmd.Println("Error: ", err)
+			return
+		}
+		query.Set(name, key)
+	}
+	if namespace, _ := cmd.Flags().GetString("namespace"); namespace != "" {
+		query.Set("namespace", namespace)
+	}
+	if samples, _ := cmd.Flags().GetInt("samples"); samples > 0 {
+		query.Set("samples", strconv.Itoa(samples))
+	}
+	prefix := regionsHealthPrefix
+	if len(query) > 0 {
+		prefix += "?" + query.Encode()
+	}
+	r, err := doRequest(cmd, prefix, http.MethodGet)
+	if err != nil {
+		cmd.Printf("Failed to get region health: %s\n", err)
+		return
+	}
+	if flag := cmd.Flag("jq"); flag != nil && flag.Value.String() != "" {
+		printWithJQFilter(r, flag.Value.String())
+		return
+	}
+	cmd.Println(r)
+}
+
+// NewRegionSimulateFailureCommand returns a simulate-failure subcommand of regionCmd.
+func NewRegionSimulateFailureCommand() *cobra.Command {
+	r := &cobra.Command{
+		Use:   `simulate-failure <label_key>=<label_value>[,<label_key>=<label_value>...] [--samples=<n>] [--jq="<query string>"]`,
+		Short: "show the impact on regions if the stores matching the labels went down",
+		Run:   showRegionSimulateFailureCommandFunc,
+	}
+	r.Flags().Int("samples", 0, "the number of sample region IDs in each bucket")
+	r.Flags().String("jq", "", "jq query")
+	return r
+}
+
+func showRegionSimulateFailureCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	query := url.Values{}
+	query.Set("label", args[0])
+	if samples, _ := cmd.Flags().GetInt("samples"); samples > 0 {
+		query.Set("samples", strconv.Itoa(samples))
+	}
+	r, err := doRequest(cmd, regionsSimulatePrefix+"?"+query.Encode(), http.MethodGet)
+	if err != nil {
+		cmd.Printf("Failed to simulate the failure: %s\n", err)
+		return
+	}
+	if flag := cmd.Flag("jq"); flag != nil && flag.Value.String() != "" {
+		printWithJQFilter(r, flag.Value.String())
+		return
+	}
+	cmd.Println(r)
+}
+
+// NewRegionTableStatsCommand returns a table-stats subcommand of regionCmd.
+func NewRegionTableStatsCommand() *cobra.Command {
+	r := &cobra.Command{
+		Use:   `table-stats [--format=raw|encode|hex] [--start_key=<key>] [--end_key=<key>] [--group_by=table|index] [--sort_by=size|keys|region_count|read_bytes|written_bytes] [--offset=<n>] [--limit=<n>] [--jq="<query string>"]`,
+		Short: "show the statistics of regions grouped by table or index",
+		Run:   showRegionTableStatsCommandFunc,
+	}
+	r.Flags().String("format", "hex", "the key format")
+	r.Flags().String("start_key", "", "only count regions from the start key")
+	r.Flags().String("end_key", "", "only count regions before the end key")
+	r.Flags().String("group_by", "table", "group regions by table or index")
+	r.Flags().String("sort_by", "size", "sort the groups from the largest size, keys, region_count, read_bytes or written_bytes")
+	r.Flags().Int("offset", 0, "the number of groups to skip")
+	r.Flags().Int("limit", 100, "the max number of groups to show")
+	r.Flags().String("jq", "", "jq query")
+	return r
+}
+
+func showRegionTableStatsCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	query := url.Values{}
+	for _, name := range []string{"start_key", "end_key"} {
+		value, _ := cmd.Flags().GetString(name)
+		if value == "" {
+			continue
+		}
+		key, err := parseKey(cmd.Flags(), value)
+		if err != nil {
+			cmd.Println("Error: ", err)
+			return
+		}
+		query.Set(name, key)
+	}
+	groupBy, _ := cmd.Flags().GetString("group_by")
+	sortBy, _ := cmd.Flags().GetString("sort_by")
+	offset, _ := cmd.Flags().GetInt("offset")
+	limit, _ := cmd.Flags().GetInt("limit")
+	query.Set("group_by", groupBy)
+	query.Set("sort_by", sortBy)
+	query.Set("offset", strconv.Itoa(offset))
+	query.Set("limit", strconv.Itoa(limit))
+	r, err := doRequest(cmd, regionsTableStatsPrefix+"?"+query.Encode(), http.MethodGet)
+	if err != nil {
+		cmd.Printf("Failed to get table stats: %s\n", err)
+		return
+	}
+	if flag := cmd.Flag("jq"); flag != nil && flag.Value.String() != "" {
+		printWithJQFilter(r, flag.Value.String())
+		return
+	}
+	cmd.Println(r)
+}
+
 // NewRegionWithSiblingCommand returns a region with sibling subcommand of regionCmd
 func NewRegionWithSiblingCommand() *cobra.Command {
 	r := &cobra.Command{
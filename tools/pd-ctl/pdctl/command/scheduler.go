@@ -16,6 +16,7 @@ package command
 import (
 	"net/http"
 	"net/url"
+	"path"
 	"strconv"
 
 	"github.com/spf13/cobra"
@@ -34,6 +35,7 @@ func NewSchedulerCommand() *cobra.Command {
 	c.AddCommand(NewShowSchedulerCommand())
 	c.AddCommand(NewAddSchedulerCommand())
 	c.AddCommand(NewRemoveSchedulerCommand())
+	c.AddCommand(NewTraceSchedulerCommand())
 	return c
 }
 
@@ -316,3 +318,44 @@ func removeSchedulerCommandFunc(cmd *cobra.Command, args []string) {
 		return
 	}
 }
+
+// NewTraceSchedulerCommand returns a command to show or toggle the decision
+// traces of a scheduler.
+func NewTraceSchedulerCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   `trace <scheduler> [enable|disable] [--jq="<query string>"]`,
+		Short: "show the decision traces of a scheduler, or enable or disable recording them",
+		Run:   traceSchedulerCommandFunc,
+	}
+	c.Flags().String("jq", "", "jq query")
+	return c
+}
+
+func traceSchedulerCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 && len(args) != 2 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	prefix := path.Join(schedulersPrefix, args[0], "trace")
+	if len(args) == 2 {
+		switch args[1] {
+		case "enable":
+			postJSON(cmd, prefix, map[string]interface{}{"enable": true})
+		case "disable":
+			postJSON(cmd, prefix, map[string]interface{}{"enable": false})
+		default:
+			cmd.Println(cmd.UsageString())
+		}
+		return
+	}
+	r, err := doRequest(cmd, prefix, http.MethodGet)
+	if err != nil {
+		cmd.Printf("Failed to get scheduler trace: %s\n", err)
+		return
+	}
+	if flag := cmd.Flag("jq"); flag != nil && flag.Value.String() != "" {
+		printWithJQFilter(r, flag.Value.String())
+		return
+	}
+	cmd.Println(r)
+}
@@ -30,13 +30,15 @@ var (
 // NewStoreCommand return a stores subcommand of rootCmd
 func NewStoreCommand() *cobra.Command {
 	s := &cobra.Command{
-		Use:   `store [delete|label|weight] <store_id> [--jq="<query string>"]`,
+		Use:   `store [delete|label|weight|config|score] <store_id> [--jq="<query string>"]`,
 		Short: "show the store status",
 		Run:   showStoreCommandFunc,
 	}
 	s.AddCommand(NewDeleteStoreCommand())
 	s.AddCommand(NewLabelStoreCommand())
 	s.AddCommand(NewSetStoreWeightCommand())
+	s.AddCommand(NewStoreConfigCommand())
+	s.AddCommand(NewStoreScoreCommand())
 	s.Flags().String("jq", "", "jq query")
 	return s
 }
@@ -70,6 +72,32 @@ func NewSetStoreWeightCommand() *cobra.Command {
 	}
 }
 
+// NewStoreConfigCommand returns a config subcommand of storeCmd.
+func NewStoreConfigCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "config <store_id> [<option> <value>]",
+		Short: "show or set a store's schedule config overrides",
+		Run:   storeConfigCommandFunc,
+	}
+	c.AddCommand(&cobra.Command{
+		Use:   "delete <store_id> [<option>]",
+		Short: "remove a store's schedule config overrides",
+		Run:   deleteStoreConfigCommandFunc,
+	})
+	return c
+}
+
+// NewStoreScoreCommand returns a score subcommand of storeCmd.
+func NewStoreScoreCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   `score [<store_id>] [--jq="<query string>"]`,
+		Short: "show the balance scores of stores and why they are not balanced",
+		Run:   showStoreScoreCommandFunc,
+	}
+	c.Flags().String("jq", "", "jq query")
+	return c
+}
+
 // NewStoresCommand returns a store subcommand of rootCmd
 func NewStoresCommand() *cobra.Command {
 	s := &cobra.Command{
@@ -110,6 +138,31 @@ func showStoreCommandFunc(cmd *cobra.Command, args []string) {
 	cmd.Println(r)
 }
 
+func showStoreScoreCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) > 1 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	prefix := path.Join(storesPrefix, "scores")
+	if len(args) == 1 {
+		if _, err := strconv.Atoi(args[0]); err != nil {
+			cmd.Println("store_id should be a number")
+			return
+		}
+		prefix = fmt.Sprintf(path.Join(storePrefix, "score"), args[0])
+	}
+	r, err := doRequest(cmd, prefix, http.MethodGet)
+	if err != nil {
+		cmd.Printf("Failed to get store score: %s\n", err)
+		return
+	}
+	if flag := cmd.Flag("jq"); flag != nil && flag.Value.String() != "" {
+		printWithJQFilter(r, flag.Value.String())
+		return
+	}
+	cmd.Println(r)
+}
+
 func deleteStoreCommandFunc(cmd *cobra.Command, args []string) {
 	if len(args) != 1 {
 		cmd.Println("Usage: store delete <store_id>")
@@ -163,6 +216,54 @@ func setStoreWeightCommandFunc(cmd *cobra.Command, args []string) {
 	})
 }
 
+func storeConfigCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 && len(args) != 3 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	if _, err := strconv.Atoi(args[0]); err != nil {
+		cmd.Println("store_id should be a number")
+		return
+	}
+	prefix := fmt.Sprintf(path.Join(storePrefix, "config"), args[0])
+	if len(args) == 1 {
+		r, err := doRequest(cmd, prefix, http.MethodGet)
+		if err != nil {
+			cmd.Printf("Failed to get store config: %s\n", err)
+			return
+		}
+		cmd.Println(r)
+		return
+	}
+	value, err := strconv.ParseFloat(args[2], 64)
+	if err != nil {
+		cmd.Println("value should be a number")
+		return
+	}
+	postJSON(cmd, prefix, map[string]interface{}{args[1]: value})
+}
+
+func deleteStoreConfigCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 && len(args) != 2 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	if _, err := strconv.Atoi(args[0]); err != nil {
+		cmd.Println("store_id should be a number")
+		return
+	}
+	prefix := fmt.Sprintf(path.Join(storePrefix, "config"), args[0])
+	if len(args) == 2 {
+		postJSON(cmd, prefix, map[string]interface{}{args[1]: nil})
+		return
+	}
+	if _, err := doRequest(cmd, prefix, http.MethodDelete); err != nil {
+		cmd.Printf("Failed to delete store config: %s\n", err)
+		return
+	}
+	cmd.Println("Success!")
+}
+
 func removeTombStoneCommandFunc(cmd *cobra.Command, args []string) {
 	prefix := fmt.Sprintf(path.Join(storePrefix, "remove-tombstone"), "")
 	_, err := doRequest(cmd, prefix, http.MethodDelete)
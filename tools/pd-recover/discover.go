@@ -0,0 +1,162 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/raft_serverpb"
+	"github.com/pingcap/pd/pkg/encryption"
+	"github.com/pingcap/pd/tools/pd-backup/snapshot"
+)
+
+const (
+	// storeIdentSuffix is the suffix of the dumped raft_serverpb.StoreIdent
+	// of a TiKV store.
+	storeIdentSuffix = ".ident"
+	// regionStateSuffix is the suffix of the dumped
+	// raft_serverpb.RegionLocalState of a region in a TiKV store.
+	regionStateSuffix = ".region"
+)
+
+// discovery collects the cluster ID and the max allocated ID from the
+// metadata of the original cluster.
+type discovery struct {
+	clusterID uint64
+	// clusterIDSource is the file the cluster ID is read from.
+	clusterIDSource string
+	maxID           uint64
+	stores          map[uint64]struct{}
+	regions         map[uint64]struct{}
+}
+
+func newDiscovery() *discovery {
+	return &discovery{
+		stores:  make(map[uint64]struct{}),
+		regions: make(map[uint64]struct{}),
+	}
+}
+
+func (d *discovery) setClusterID(id uint64, source string) error {
+	if id == 0 {
+		return fmt.Errorf("invalid cluster id 0 in %s", source)
+	}
+	if d.clusterID != 0 && d.clusterID != id {
+		return fmt.Errorf("cluster id %d in %s mismatches cluster id %d in %s", id, source, d.clusterID, d.clusterIDSource)
+	}
+	d.clusterID, d.clusterIDSource = id, source
+	return nil
+}
+
+func (d *discovery) observeID(id uint64) {
+	if id > d.maxID {
+		d.maxID = id
+	}
+}
+
+func (d *discovery) observeStore(store *metapb.Store) {
+	d.stores[store.GetId()] = struct{}{}
+	d.observeID(store.GetId())
+}
+
+func (d *discovery) observeRegion(region *metapb.Region) {
+	d.regions[region.GetId()] = struct{}{}
+	d.observeID(region.GetId())
+	for _, peer := range region.GetPeers() {
+		d.observeID(peer.GetId())
+		d.observeID(peer.GetStoreId())
+	}
+}
+
+// loadSnapshot collects the metadata from a snapshot exported by pd-backup.
+// The allocated ID in the snapshot is also safe to use. An encrypted snapshot
+// is decrypted with the master keys.
+func (d *discovery) loadSnapshot(filename string, keys *encryption.KeyManager) error {
+	s, err := snapshot.Load(filename, keys)
+	if err != nil {
+		return err
+	}
+	if err := d.setClusterID(s.ClusterID, filename); err != nil {
+		return err
+	}
+	if err := s.Summarize(); err != nil {
+		return err
+	}
+	d.observeID(s.AllocID)
+	stores, err := s.Stores()
+	if err != nil {
+		return err
+	}
+	for _, store := range stores {
+		d.observeStore(store)
+	}
+	regions, err := s.Regions()
+	if err != nil {
+		return err
+	}
+	for _, region := range regions {
+		d.observeRegion(region)
+	}
+	return nil
+}
+
+// loadTiKVDump collects the metadata from the files dumped from TiKV stores
+// in the directory. Store idents and region states are protobuf encoded, as
+// they are saved in TiKV.
+func (d *discovery) loadTiKVDump(dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		switch filepath.Ext(path) {
+		case storeIdentSuffix:
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			ident := &raft_serverpb.StoreIdent{}
+			if err := ident.Unmarshal(data); err != nil {
+				return fmt.Errorf("invalid store ident %s: %v", path, err)
+			}
+			if err := d.setClusterID(ident.GetClusterId(), path); err != nil {
+				return err
+			}
+			d.observeStore(&metapb.Store{Id: ident.GetStoreId()})
+		case regionStateSuffix:
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			state := &raft_serverpb.RegionLocalState{}
+			if err := state.Unmarshal(data); err != nil {
+				return fmt.Errorf("invalid region state %s: %v", path, err)
+			}
+			if state.GetRegion() == nil {
+				return fmt.Errorf("invalid region state %s: missing region", path)
+			}
+			d.observeRegion(state.GetRegion())
+		}
+		return nil
+	})
+}
+
+// safeAllocID returns the alloc ID to recover, which is larger than all
+// discovered IDs by the margin, to cover the IDs allocated but not seen.
+func (d *discovery) safeAllocID(margin uint64) uint64 {
+	return d.maxID + margin
+}
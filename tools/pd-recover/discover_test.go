@@ -0,0 +1,106 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/raft_serverpb"
+	"github.com/pingcap/pd/tools/pd-backup/snapshot"
+)
+
+func TestDiscovery(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testDiscoverySuite{})
+
+type testDiscoverySuite struct {
+	dir string
+}
+
+func (s *testDiscoverySuite) SetUpTest(c *C) {
+	var err error
+	s.dir, err = ioutil.TempDir("/tmp", "test_pd_recover")
+	c.Assert(err, IsNil)
+}
+
+func (s *testDiscoverySuite) TearDownTest(c *C) {
+	os.RemoveAll(s.dir)
+}
+
+func (s *testDiscoverySuite) mustWrite(c *C, name string, m interface{ Marshal() ([]byte, error) }) {
+	data, err := m.Marshal()
+	c.Assert(err, IsNil)
+	filename := filepath.Join(s.dir, name)
+	c.Assert(os.MkdirAll(filepath.Dir(filename), 0700), IsNil)
+	c.Assert(ioutil.WriteFile(filename, data, 0600), IsNil)
+}
+
+func (s *testDiscoverySuite) TestSnapshot(c *C) {
+	store, err := (&metapb.Store{Id: 1}).Marshal()
+	c.Assert(err, IsNil)
+	region, err := (&metapb.Region{Id: 20, Peers: []*metapb.Peer{{Id: 21, StoreId: 1}}}).Marshal()
+	c.Assert(err, IsNil)
+	meta, err := (&metapb.Cluster{Id: 42}).Marshal()
+	c.Assert(err, IsNil)
+	snap := &snapshot.Snapshot{
+		Version:   snapshot.Version,
+		ClusterID: 42,
+		KVs: []*snapshot.KV{
+			{Key: snapshot.AllocIDKey, Value: snapshot.Uint64ToBytes(1000)},
+			{Key: snapshot.ClusterKey, Value: meta},
+			{Key: snapshot.RegionPrefix + "00000000000000000020", Value: region},
+			{Key: snapshot.StorePrefix + "00000000000000000001", Value: store},
+		},
+	}
+	filename := filepath.Join(s.dir, "snapshot.json")
+	c.Assert(snap.Save(filename, nil), IsNil)
+
+	d := newDiscovery()
+	c.Assert(d.loadSnapshot(filename, nil), IsNil)
+	c.Assert(d.clusterID, Equals, uint64(42))
+	c.Assert(d.maxID, Equals, uint64(1000))
+	c.Assert(d.stores, HasLen, 1)
+	c.Assert(d.regions, HasLen, 1)
+	c.Assert(d.safeAllocID(100), Equals, uint64(1100))
+}
+
+func (s *testDiscoverySuite) TestTiKVDump(c *C) {
+	s.mustWrite(c, "tikv1/store.ident", &raft_serverpb.StoreIdent{ClusterId: 42, StoreId: 1})
+	s.mustWrite(c, "tikv2/store.ident", &raft_serverpb.StoreIdent{ClusterId: 42, StoreId: 2})
+	s.mustWrite(c, "tikv1/2.region", &raft_serverpb.RegionLocalState{
+		Region: &metapb.Region{Id: 2, Peers: []*metapb.Peer{{Id: 3, StoreId: 1}, {Id: 1024, StoreId: 2}}},
+	})
+	s.mustWrite(c, "tikv2/2.region", &raft_serverpb.RegionLocalState{
+		Region: &metapb.Region{Id: 2, Peers: []*metapb.Peer{{Id: 3, StoreId: 1}, {Id: 1024, StoreId: 2}}},
+	})
+	c.Assert(ioutil.WriteFile(filepath.Join(s.dir, "README"), []byte("ignored"), 0600), IsNil)
+
+	d := newDiscovery()
+	c.Assert(d.loadTiKVDump(s.dir), IsNil)
+	c.Assert(d.clusterID, Equals, uint64(42))
+	c.Assert(d.maxID, Equals, uint64(1024))
+	c.Assert(d.stores, HasLen, 2)
+	c.Assert(d.regions, HasLen, 1)
+
+	// Stores of different clusters are mixed.
+	s.mustWrite(c, "tikv3/store.ident", &raft_serverpb.StoreIdent{ClusterId: 43, StoreId: 3})
+	c.Assert((newDiscovery()).loadTiKVDump(s.dir), NotNil)
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/binary"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -12,6 +13,7 @@ import (
 	"time"
 
 	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/pkg/encryption"
 	"go.etcd.io/etcd/clientv3"
 	"go.etcd.io/etcd/pkg/transport"
 )
@@ -23,6 +25,13 @@ var (
 	caPath    = flag.String("cacert", "", "path of file that contains list of trusted SSL CAs.")
 	certPath  = flag.String("cert", "", "path of file that contains X509 certificate in PEM format..")
 	keyPath   = flag.String("key", "", "path of file that contains X509 key in PEM format.")
+
+	snapshotPath  = flag.String("snapshot", "", "path of a snapshot exported by pd-backup to discover cluster ID and alloc ID")
+	tikvDumpDir   = flag.String("tikv-dump", "", "directory of dumped TiKV store idents (*.ident) and region states (*.region) to discover cluster ID and alloc ID")
+	allocIDMargin = flag.Uint64("alloc-id-margin", defaultAllocIDMargin, "margin added to the max discovered ID to get a safe alloc ID")
+	dryRun        = flag.Bool("dry-run", false, "print the cluster ID and alloc ID to recover without writing to etcd")
+	masterKeyFile = flag.String("master-key-file", "", "path of the file that contains the hex encoded master key to decrypt the snapshot")
+	masterKeyEnv  = flag.String("master-key-env", "", "name of the environment variable that contains the hex encoded master key to decrypt the snapshot")
 )
 
 const (
@@ -31,6 +40,10 @@ const (
 
 	pdRootPath      = "/pd"
 	pdClusterIDPath = "/pd/cluster_id"
+
+	// defaultAllocIDMargin covers the IDs allocated after the snapshot or
+	// dumps are taken, it is much larger than the alloc step of PD.
+	defaultAllocIDMargin = 100000
 )
 
 func exitErr(err error) {
@@ -40,6 +53,11 @@ func exitErr(err error) {
 
 func main() {
 	flag.Parse()
+	if *snapshotPath != "" || *tikvDumpDir != "" {
+		if err := discover(); err != nil {
+			exitErr(err)
+		}
+	}
 	if *clusterID == 0 {
 		fmt.Println("please specify safe cluster-id")
 		return
@@ -48,6 +66,10 @@ func main() {
 		fmt.Println("please specify safe alloc-id")
 		return
 	}
+	if *dryRun {
+		fmt.Printf("dry run: recover with cluster-id %d and alloc-id %d\n", *clusterID, *allocID)
+		return
+	}
 
 	rootPath := path.Join(pdRootPath, strconv.FormatUint(*clusterID, 10))
 	clusterRootPath := path.Join(rootPath, "raft")
@@ -108,9 +130,66 @@ func main() {
 		fmt.Println("failed to recover: the cluster is already bootstrapped")
 		return
 	}
+	if err := verify(client); err != nil {
+		exitErr(err)
+	}
 	fmt.Println("recover success! please restart the PD cluster")
 }
 
+// discover fills the unspecified cluster ID and alloc ID with the ones
+// discovered from the snapshot or TiKV dumps, and checks the specified ones.
+func discover() error {
+	d := newDiscovery()
+	if *snapshotPath != "" {
+		keys, err := encryption.NewKeyManager(&encryption.Config{MasterKeyFile: *masterKeyFile, MasterKeyEnv: *masterKeyEnv})
+		if err != nil {
+			return err
+		}
+		if err := d.loadSnapshot(*snapshotPath, keys); err != nil {
+			return err
+		}
+	}
+	if *tikvDumpDir != "" {
+		if err := d.loadTiKVDump(*tikvDumpDir); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("discovered %d stores and %d regions, cluster id: %d, max id: %d\n", len(d.stores), len(d.regions), d.clusterID, d.maxID)
+
+	switch {
+	case *clusterID == 0 && d.clusterID == 0:
+		return errors.New("failed to discover cluster id, please specify cluster-id")
+	case *clusterID == 0:
+		*clusterID = d.clusterID
+	case d.clusterID != 0 && *clusterID != d.clusterID:
+		return fmt.Errorf("cluster-id %d mismatches the discovered cluster id %d in %s", *clusterID, d.clusterID, d.clusterIDSource)
+	}
+	if *allocID == 0 {
+		*allocID = d.safeAllocID(*allocIDMargin)
+	} else if *allocID <= d.maxID {
+		return fmt.Errorf("alloc-id %d is not safe, it should be larger than the max discovered id %d", *allocID, d.maxID)
+	}
+	return nil
+}
+
+// verify reads the recovered IDs back from etcd.
+func verify(client *clientv3.Client) error {
+	ctx, cancel := context.WithTimeout(client.Ctx(), requestTimeout)
+	defer cancel()
+	allocIDPath := path.Join(pdRootPath, strconv.FormatUint(*clusterID, 10), "alloc_id")
+	resp, err := client.Txn(ctx).Then(clientv3.OpGet(pdClusterIDPath), clientv3.OpGet(allocIDPath)).Commit()
+	if err != nil {
+		return err
+	}
+	for i, expect := range []uint64{*clusterID, *allocID} {
+		kvs := resp.Responses[i].GetResponseRange().GetKvs()
+		if len(kvs) != 1 || string(kvs[0].Value) != string(uint64ToBytes(expect)) {
+			return fmt.Errorf("failed to verify: %d is not saved", expect)
+		}
+	}
+	return nil
+}
+
 func uint64ToBytes(v uint64) []byte {
 	b := make([]byte, 8)
 	binary.BigEndian.PutUint64(b, v)
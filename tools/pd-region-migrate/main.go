@@ -0,0 +1,318 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/pkg/encryption"
+	"github.com/pingcap/pd/server/core"
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/pkg/transport"
+)
+
+var (
+	endpoints = flag.String("endpoints", "http://127.0.0.1:2379", "endpoints urls")
+	clusterID = flag.Uint64("cluster-id", 0, "cluster ID, it is read from etcd if not specified")
+	dataDir   = flag.String("data-dir", "", "data directory of the PD server which owns the local region storage")
+	from      = flag.String("from", "etcd", "source of regions, one of etcd, leveldb and bbolt")
+	to        = flag.String("to", core.BoltEngine, "target of regions, one of etcd, leveldb and bbolt")
+	caPath    = flag.String("cacert", "", "path of file that contains list of trusted SSL CAs.")
+	certPath  = flag.String("cert", "", "path of file that contains X509 certificate in PEM format..")
+	keyPath   = flag.String("key", "", "path of file that contains X509 key in PEM format.")
+
+	masterKeyFile = flag.String("master-key-file", "", "path of the file that contains the hex encoded master key, the local region storage is encrypted with it")
+	masterKeyEnv  = flag.String("master-key-env", "", "name of the environment variable that contains the hex encoded master key, it is used if master-key-file is not specified")
+)
+
+const (
+	requestTimeout = 10 * time.Second
+	etcdTimeout    = 3 * time.Second
+
+	pdRootPath      = "/pd"
+	pdClusterIDPath = "/pd/cluster_id"
+
+	etcdName = "etcd"
+	// etcdBatchSize is less than the default max operations in an etcd txn.
+	etcdBatchSize   = 100
+	engineBatchSize = 1024
+)
+
+func exitErr(err error) {
+	fmt.Println(err.Error())
+	os.Exit(1)
+}
+
+// regionPath is the key of a region in both etcd, under the cluster root
+// path, and the local region storage.
+func regionPath(regionID uint64) string {
+	return path.Join("raft", "r", fmt.Sprintf("%020d", regionID))
+}
+
+// regionStorage is etcd or a local region storage engine.
+type regionStorage interface {
+	// scan calls f for the regions in key order from the start key, until
+	// the number of regions reaches the limit. It returns the number of
+	// scanned regions.
+	scan(startKey string, limit int, f func(key, value string) error) (int, error)
+	// write saves a batch of regions.
+	write(keys, values []string) error
+}
+
+type etcdStorage struct {
+	client   *clientv3.Client
+	rootPath string
+}
+
+func (s *etcdStorage) scan(startKey string, limit int, f func(key, value string) error) (int, error) {
+	ctx, cancel := context.WithTimeout(s.client.Ctx(), requestTimeout)
+	defer cancel()
+	resp, err := s.client.Get(ctx, path.Join(s.rootPath, startKey),
+		clientv3.WithRange(path.Join(s.rootPath, regionPath(math.MaxUint64))),
+		clientv3.WithLimit(int64(limit)))
+	if err != nil {
+		return 0, err
+	}
+	for _, kv := range resp.Kvs {
+		key := strings.TrimPrefix(string(kv.Key), s.rootPath+"/")
+		if err := f(key, string(kv.Value)); err != nil {
+			return 0, err
+		}
+	}
+	return len(resp.Kvs), nil
+}
+
+func (s *etcdStorage) write(keys, values []string) error {
+	for len(keys) > 0 {
+		n := etcdBatchSize
+		if len(keys) < n {
+			n = len(keys)
+		}
+		ops := make([]clientv3.Op, 0, n)
+		for i := 0; i < n; i++ {
+			ops = append(ops, clientv3.OpPut(path.Join(s.rootPath, keys[i]), values[i]))
+		}
+		ctx, cancel := context.WithTimeout(s.client.Ctx(), requestTimeout)
+		_, err := s.client.Txn(ctx).Then(ops...).Commit()
+		cancel()
+		if err != nil {
+			return err
+		}
+		keys, values = keys[n:], values[n:]
+	}
+	return nil
+}
+
+type engineStorage struct {
+	engine core.KVEngine
+}
+
+func (s *engineStorage) scan(startKey string, limit int, f func(key, value string) error) (int, error) {
+	return s.scanRange(startKey, regionPath(math.MaxUint64), limit, f)
+}
+
+func (s *engineStorage) scanRange(startKey, endKey string, limit int, f func(key, value string) error) (int, error) {
+	iter := s.engine.NewIterator(startKey, endKey)
+	defer iter.Release()
+	count := 0
+	for count < limit && iter.Next() {
+		if err := f(iter.Key(), iter.Value()); err != nil {
+			return 0, err
+		}
+		count++
+	}
+	return count, iter.Error()
+}
+
+func (s *engineStorage) write(keys, values []string) error {
+	batch := &core.KVBatch{}
+	for i := range keys {
+		batch.Put(keys[i], values[i])
+	}
+	return s.engine.Write(batch)
+}
+
+func main() {
+	flag.Parse()
+	if *from == *to {
+		fmt.Println("the source and the target should be different")
+		return
+	}
+	keys, err := encryption.NewKeyManager(&encryption.Config{MasterKeyFile: *masterKeyFile, MasterKeyEnv: *masterKeyEnv})
+	if err != nil {
+		exitErr(err)
+	}
+	var (
+		client  *clientv3.Client
+		engines []core.KVEngine
+	)
+	open := func(name string) regionStorage {
+		if name == etcdName {
+			if client == nil {
+				client = newEtcdClient()
+			}
+			return &etcdStorage{client: client, rootPath: getRootPath(client)}
+		}
+		if *dataDir == "" {
+			exitErr(fmt.Errorf("please specify the data-dir of the PD server for %s", name))
+		}
+		base, err := core.NewKVEngine(name, core.RegionStoragePath(*dataDir, name))
+		if err != nil {
+			exitErr(err)
+		}
+		// The regions are decrypted from the source and encrypted again to the
+		// target, as the values are bound to the keys of the engine.
+		engine := core.NewEncryptedKVEngine(base, keys)
+		engines = append(engines, engine)
+		return &engineStorage{engine: engine}
+	}
+	source, target := open(*from), open(*to)
+	defer func() {
+		for _, engine := range engines {
+			engine.Close()
+		}
+	}()
+
+	count, err := migrate(source, target)
+	if err != nil {
+		exitErr(err)
+	}
+	fmt.Printf("migrate %d regions from %s to %s successfully\n", count, *from, *to)
+
+	// The metrics history is kept in the local engine only.
+	sourceEngine, ok1 := source.(*engineStorage)
+	targetEngine, ok2 := target.(*engineStorage)
+	if !ok1 || !ok2 {
+		return
+	}
+	count, err = migrateMetricsHistory(sourceEngine, targetEngine)
+	if err != nil {
+		exitErr(err)
+	}
+	fmt.Printf("migrate %d metrics history samples from %s to %s successfully\n", count, *from, *to)
+}
+
+func migrate(source, target regionStorage) (int, error) {
+	batchSize := engineBatchSize
+	if *from == etcdName || *to == etcdName {
+		batchSize = etcdBatchSize
+	}
+	var (
+		total  int
+		keys   []string
+		values []string
+		nextID uint64
+	)
+	for {
+		keys, values = keys[:0], values[:0]
+		n, err := source.scan(regionPath(nextID), batchSize, func(key, value string) error {
+			region := &metapb.Region{}
+			if err := region.Unmarshal([]byte(value)); err != nil {
+				return fmt.Errorf("invalid region %s: %v", key, err)
+			}
+			keys, values = append(keys, key), append(values, value)
+			nextID = region.GetId() + 1
+			return nil
+		})
+		if err != nil {
+			return total, err
+		}
+		if err := target.write(keys, values); err != nil {
+			return total, err
+		}
+		total += n
+		if n < batchSize {
+			return total, nil
+		}
+	}
+}
+
+// migrateMetricsHistory copies the metrics history samples between the local
+// engines.
+func migrateMetricsHistory(source, target *engineStorage) (int, error) {
+	// The keys are the prefix and the time joined by "/", and "0" is the next
+	// byte of "/".
+	startKey, endKey := core.MetricsHistoryPrefix+"/", core.MetricsHistoryPrefix+"0"
+	var (
+		total  int
+		keys   []string
+		values []string
+	)
+	for {
+		keys, values = keys[:0], values[:0]
+		n, err := source.scanRange(startKey, endKey, engineBatchSize, func(key, value string) error {
+			keys, values = append(keys, key), append(values, value)
+			return nil
+		})
+		if err != nil {
+			return total, err
+		}
+		if err := target.write(keys, values); err != nil {
+			return total, err
+		}
+		total += n
+		if n < engineBatchSize {
+			return total, nil
+		}
+		startKey = keys[len(keys)-1] + "\x00"
+	}
+}
+
+func newEtcdClient() *clientv3.Client {
+	tlsInfo := transport.TLSInfo{
+		CertFile:      *certPath,
+		KeyFile:       *keyPath,
+		TrustedCAFile: *caPath,
+	}
+	tlsConfig, err := tlsInfo.ClientConfig()
+	if err != nil {
+		exitErr(err)
+	}
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   strings.Split(*endpoints, ","),
+		DialTimeout: etcdTimeout,
+		TLS:         tlsConfig,
+	})
+	if err != nil {
+		exitErr(err)
+	}
+	return client
+}
+
+func getRootPath(client *clientv3.Client) string {
+	id := *clusterID
+	if id == 0 {
+		ctx, cancel := context.WithTimeout(client.Ctx(), requestTimeout)
+		defer cancel()
+		resp, err := client.Get(ctx, pdClusterIDPath)
+		if err != nil {
+			exitErr(err)
+		}
+		if len(resp.Kvs) != 1 || len(resp.Kvs[0].Value) != 8 {
+			exitErr(fmt.Errorf("failed to read cluster id from %s", pdClusterIDPath))
+		}
+		id = binary.BigEndian.Uint64(resp.Kvs[0].Value)
+	}
+	return path.Join(pdRootPath, strconv.FormatUint(id, 10))
+}
@@ -0,0 +1,122 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/pkg/encryption"
+	"github.com/pingcap/pd/server/core"
+)
+
+func TestMigrate(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testMigrateSuite{})
+
+type testMigrateSuite struct {
+	dir string
+}
+
+func (s *testMigrateSuite) SetUpTest(c *C) {
+	var err error
+	s.dir, err = ioutil.TempDir("/tmp", "test_pd_region_migrate")
+	c.Assert(err, IsNil)
+}
+
+func (s *testMigrateSuite) TearDownTest(c *C) {
+	os.RemoveAll(s.dir)
+}
+
+func (s *testMigrateSuite) mustOpen(c *C, name string, keys *encryption.KeyManager) core.KVEngine {
+	base, err := core.NewKVEngine(name, core.RegionStoragePath(s.dir, name))
+	c.Assert(err, IsNil)
+	return core.NewEncryptedKVEngine(base, keys)
+}
+
+func (s *testMigrateSuite) TestMigrateEncrypted(c *C) {
+	keys, err := encryption.NewKeyManagerFromKey(strings.Repeat("ab", 32))
+	c.Assert(err, IsNil)
+	source := s.mustOpen(c, core.LevelDBEngine, keys)
+	defer source.Close()
+	target := s.mustOpen(c, core.BoltEngine, keys)
+
+	const n = engineBatchSize + 10
+	batch := &core.KVBatch{}
+	for id := uint64(1); id <= n; id++ {
+		value, err := (&metapb.Region{Id: id}).Marshal()
+		c.Assert(err, IsNil)
+		batch.Put(regionPath(id), string(value))
+	}
+	c.Assert(source.Write(batch), IsNil)
+
+	count, err := migrate(&engineStorage{engine: source}, &engineStorage{engine: target})
+	c.Assert(err, IsNil)
+	c.Assert(count, Equals, n)
+	for _, id := range []uint64{1, n} {
+		value, err := target.Load(regionPath(id))
+		c.Assert(err, IsNil)
+		region := &metapb.Region{}
+		c.Assert(region.Unmarshal([]byte(value)), IsNil)
+		c.Assert(region.GetId(), Equals, id)
+	}
+
+	// The regions are encrypted in the target.
+	c.Assert(target.Close(), IsNil)
+	raw, err := core.NewKVEngine(core.BoltEngine, core.RegionStoragePath(s.dir, core.BoltEngine))
+	c.Assert(err, IsNil)
+	defer raw.Close()
+	value, err := raw.Load(regionPath(1))
+	c.Assert(err, IsNil)
+	c.Assert(encryption.IsEncrypted([]byte(value)), IsTrue)
+
+	// The encrypted regions can not be migrated without the master key.
+	_, err = migrate(&engineStorage{engine: core.NewEncryptedKVEngine(raw, nil)}, &engineStorage{engine: source})
+	c.Assert(err, NotNil)
+}
+
+func (s *testMigrateSuite) TestMigrateMetricsHistory(c *C) {
+	source := s.mustOpen(c, core.LevelDBEngine, nil)
+	defer source.Close()
+	target := s.mustOpen(c, core.BoltEngine, nil)
+	defer target.Close()
+
+	const n = engineBatchSize + 10
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	history := core.NewMetricsHistory(source)
+	for i := 0; i < n; i++ {
+		sample := &core.MetricsSample{Time: start.Unix() + int64(i), Stores: []*core.StoreMetrics{{StoreID: 1}}}
+		c.Assert(history.Save(sample, 24*time.Hour), IsNil)
+	}
+	// A region is not a sample.
+	value, err := (&metapb.Region{Id: 1}).Marshal()
+	c.Assert(err, IsNil)
+	c.Assert(source.Save(regionPath(1), string(value)), IsNil)
+
+	count, err := migrateMetricsHistory(&engineStorage{engine: source}, &engineStorage{engine: target})
+	c.Assert(err, IsNil)
+	c.Assert(count, Equals, n)
+	samples, err := core.NewMetricsHistory(target).Load(start, start.Add(time.Hour), 0)
+	c.Assert(err, IsNil)
+	c.Assert(samples, HasLen, n)
+	_, err = target.Load(regionPath(1))
+	c.Assert(err, NotNil)
+}